@@ -0,0 +1,71 @@
+package grpc_client
+
+import (
+	"sync"
+	"time"
+)
+
+// Breaker stops calling a failing service: after Threshold consecutive failures it opens and rejects calls
+// until Cooldown has passed, then lets a single probe through. the probe closes it again on success
+type Breaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+	// now is swapped in tests
+	now func() time.Time
+}
+
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{Threshold: threshold, Cooldown: cooldown, now: time.Now}
+}
+
+// Allow report whether a call may go through, a zero Threshold never opens the breaker
+func (b *Breaker) Allow() bool {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.Threshold <= 0 || b.failures < b.Threshold {
+		return true
+	}
+
+	//open, only one probe at a time once the cooldown is over
+	if b.probing || b.now().Sub(b.openedAt) < b.Cooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// Success record a call that went through, it closes the breaker
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+}
+
+// Abandon record a call whose outcome tells nothing about the service, like one canceled by its caller
+func (b *Breaker) Abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// Failure record a failed call, the breaker (re)opens once failures reach the threshold
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.Threshold > 0 && b.failures >= b.Threshold {
+		b.openedAt = b.now()
+	}
+}
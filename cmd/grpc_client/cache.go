@@ -0,0 +1,304 @@
+package grpc_client
+
+import (
+	"container/list"
+	"context"
+	"golek_bookmark_service/pkg/contracts"
+	"golek_bookmark_service/pkg/models"
+	"sync"
+	"time"
+)
+
+// defaults used when the RPC_CACHE_* settings are left empty
+const (
+	defaultCacheTTL           = 5 * time.Minute
+	defaultCacheCapacity      = 10000
+	defaultCacheLookupTimeout = 10 * time.Second
+	defaultCacheMissTTL       = 30 * time.Second
+)
+
+// PostCache keeps the details fetched from the wrapped post service for TTL, at most Capacity posts of them
+// with the least recently used evicted first. posts the service didn't return are remembered for MissTTL only,
+// a partial answer may be transient. concurrent lookups of the same posts share a single call to the service
+type PostCache struct {
+	contracts.GRPCPostService
+	TTL      time.Duration
+	Capacity int
+	// MissTTL is how long posts missing from an answer aren't asked again, 0 asks every time
+	MissTTL time.Duration
+	// LookupTimeout bounds a lookup of the wrapped service, it doesn't end with the caller that started it
+	LookupTimeout time.Duration
+
+	mu       sync.Mutex
+	entries  map[string]*list.Element
+	recent   *list.List
+	inflight map[string]*flight
+	// generation moves on every invalidation, lookups started before it don't store what they got
+	generation uint64
+	stats      CacheStats
+	// now is swapped in tests
+	now func() time.Time
+}
+
+// CacheStats count lookups by post, Coalesced misses waited on a lookup another caller started
+type CacheStats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Coalesced uint64 `json:"coalesced"`
+	Size      int    `json:"size"`
+}
+
+type cacheEntry struct {
+	id        string
+	post      models.Post
+	found     bool
+	expiresAt time.Time
+}
+
+// flight is a lookup of the wrapped service, done is closed once posts or err are set
+type flight struct {
+	done  chan struct{}
+	posts map[string]models.Post
+	err   error
+}
+
+func NewPostCache(service contracts.GRPCPostService, ttl time.Duration, capacity int) *PostCache {
+	return &PostCache{
+		GRPCPostService: service,
+		TTL:             ttl,
+		Capacity:        capacity,
+		MissTTL:         defaultCacheMissTTL,
+		LookupTimeout:   defaultCacheLookupTimeout,
+		entries:         make(map[string]*list.Element),
+		recent:          list.New(),
+		inflight:        make(map[string]*flight),
+		now:             time.Now,
+	}
+}
+
+// Cached wrap the post service with a PostCache configured by the RPC_CACHE_* settings,
+// a zero RPC_CACHE_TTL leaves the service uncached
+func Cached(config contracts.AppConfig, service contracts.GRPCPostService) (contracts.GRPCPostService, error) {
+
+	app := config.GetAppConfig()
+	ttl, err := durationOr(app["RPC_CACHE_TTL"], defaultCacheTTL)
+	if err != nil {
+		return nil, err
+	}
+	capacity, err := intOr(app["RPC_CACHE_SIZE"], defaultCacheCapacity)
+	if err != nil {
+		return nil, err
+	}
+	missTTL, err := durationOr(app["RPC_CACHE_MISS_TTL"], defaultCacheMissTTL)
+	if err != nil {
+		return nil, err
+	}
+	lookupTimeout, err := durationOr(app["RPC_CACHE_LOOKUP_TIMEOUT"], defaultCacheLookupTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if ttl == 0 || capacity == 0 {
+		return service, nil
+	}
+
+	cache := NewPostCache(service, ttl, capacity)
+	cache.MissTTL = missTTL
+	cache.LookupTimeout = lookupTimeout
+	return cache, nil
+}
+
+// Fetch answer from the cache what it can and ask the wrapped service for the rest, in the order of 'postIDs'.
+// callers joining a lookup started by another share its outcome, each of them stops waiting when its own ctx ends
+func (c *PostCache) Fetch(ctx context.Context, postIDs []string) ([]models.Post, error) {
+
+	found := make(map[string]models.Post)
+	waits := make([]*flight, 0)
+	missing := make([]string, 0)
+
+	c.mu.Lock()
+	now := c.now()
+	requested := make(map[string]bool)
+	for _, id := range postIDs {
+		if requested[id] {
+			continue
+		}
+		requested[id] = true
+
+		if entry, ok := c.lookup(id, now); ok {
+			c.stats.Hits++
+			if entry.found {
+				found[id] = entry.post
+			}
+			continue
+		}
+
+		c.stats.Misses++
+		if f, ok := c.inflight[id]; ok {
+			c.stats.Coalesced++
+			waits = append(waits, f)
+			continue
+		}
+		missing = append(missing, id)
+	}
+
+	var own *flight
+	if len(missing) > 0 {
+		own = &flight{done: make(chan struct{})}
+		for _, id := range missing {
+			c.inflight[id] = own
+		}
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	if own != nil {
+		go c.fly(withoutCancel{ctx}, own, missing, generation)
+		waits = append(waits, own)
+	}
+
+	for _, f := range waits {
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if f.err != nil {
+			return nil, f.err
+		}
+		for id, post := range f.posts {
+			if requested[id] {
+				found[id] = post
+			}
+		}
+	}
+
+	posts := make([]models.Post, 0, len(found))
+	for _, id := range postIDs {
+		if post, ok := found[id]; ok {
+			posts = append(posts, post)
+			delete(found, id)
+		}
+	}
+
+	return posts, nil
+}
+
+// fly look the posts up from the wrapped service and hand the outcome to everybody waiting on 'f'.
+// it runs on a ctx the callers can't cancel, bounded by LookupTimeout; failures aren't cached, the next caller tries again
+func (c *PostCache) fly(ctx context.Context, f *flight, postIDs []string, generation uint64) {
+
+	if c.LookupTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.LookupTimeout)
+		defer cancel()
+	}
+
+	posts, err := c.GRPCPostService.Fetch(ctx, postIDs)
+
+	f.err = err
+	f.posts = make(map[string]models.Post)
+	for _, post := range posts {
+		f.posts[post.ID.Hex()] = post
+	}
+
+	c.mu.Lock()
+	for _, id := range postIDs {
+		if c.inflight[id] == f {
+			delete(c.inflight, id)
+		}
+	}
+	if err == nil && generation == c.generation {
+		now := c.now()
+		for _, id := range postIDs {
+			if post, ok := f.posts[id]; ok {
+				c.store(cacheEntry{id: id, post: post, found: true, expiresAt: now.Add(c.TTL)})
+			} else if c.MissTTL > 0 {
+				c.store(cacheEntry{id: id, found: false, expiresAt: now.Add(c.MissTTL)})
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	close(f.done)
+}
+
+// Invalidate forget the posts, their next lookup goes to the service.
+// lookups already running when it's called don't fill the cache
+func (c *PostCache) Invalidate(postIDs ...string) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for _, id := range postIDs {
+		if element, ok := c.entries[id]; ok {
+			c.recent.Remove(element)
+			delete(c.entries, id)
+		}
+		delete(c.inflight, id)
+	}
+}
+
+func (c *PostCache) Stats() CacheStats {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Size = c.recent.Len()
+	return stats
+}
+
+// lookup return the fresh entry of the post and mark it recently used, the caller must hold the lock
+func (c *PostCache) lookup(id string, now time.Time) (cacheEntry, bool) {
+
+	element, ok := c.entries[id]
+	if !ok {
+		return cacheEntry{}, false
+	}
+
+	entry := element.Value.(cacheEntry)
+	if !now.Before(entry.expiresAt) {
+		c.recent.Remove(element)
+		delete(c.entries, id)
+		return cacheEntry{}, false
+	}
+
+	c.recent.MoveToFront(element)
+	return entry, true
+}
+
+// store add or replace the entry and evict the least recently used beyond capacity, the caller must hold the lock
+func (c *PostCache) store(entry cacheEntry) {
+
+	if element, ok := c.entries[entry.id]; ok {
+		element.Value = entry
+		c.recent.MoveToFront(element)
+		return
+	}
+
+	c.entries[entry.id] = c.recent.PushFront(entry)
+	for c.Capacity > 0 && c.recent.Len() > c.Capacity {
+		oldest := c.recent.Back()
+		c.recent.Remove(oldest)
+		delete(c.entries, oldest.Value.(cacheEntry).id)
+	}
+}
+
+// withoutCancel keep the values of a ctx but not its deadline or cancellation, like context.WithoutCancel
+// which the go version of the module predates
+type withoutCancel struct {
+	context.Context
+}
+
+func (withoutCancel) Deadline() (deadline time.Time, ok bool) {
+	return time.Time{}, false
+}
+
+func (withoutCancel) Done() <-chan struct{} {
+	return nil
+}
+
+func (withoutCancel) Err() error {
+	return nil
+}
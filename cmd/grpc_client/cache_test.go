@@ -0,0 +1,217 @@
+package grpc_client
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"golek_bookmark_service/pkg/contracts"
+	"golek_bookmark_service/pkg/models"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingPostService knows the posts of 'names', it records every lookup and holds them while 'gate' is open
+type countingPostService struct {
+	contracts.GRPCPostService
+	mu      sync.Mutex
+	names   map[string]string
+	lookups [][]string
+	gate    chan struct{}
+	err     error
+}
+
+func (s *countingPostService) Fetch(ctx context.Context, postIDs []string) ([]models.Post, error) {
+
+	s.mu.Lock()
+	s.lookups = append(s.lookups, postIDs)
+	gate := s.gate
+	s.mu.Unlock()
+
+	if gate != nil {
+		<-gate
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	posts := make([]models.Post, 0)
+	for _, id := range postIDs {
+		if name, ok := s.names[id]; ok {
+			posts = append(posts, models.Post{ID: models.GenerateObjectIDFromHex(id), Name: name})
+		}
+	}
+	return posts, nil
+}
+
+func (s *countingPostService) calls() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]string{}, s.lookups...)
+}
+
+func namesOf(posts []models.Post) []string {
+	names := make([]string, 0)
+	for _, p := range posts {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func TestPostCacheServesFreshEntries(t *testing.T) {
+
+	goPost, rustPost, deleted := models.GenerateObjectID().Hex(), models.GenerateObjectID().Hex(), models.GenerateObjectID().Hex()
+	service := &countingPostService{names: map[string]string{goPost: "Learning Go", rustPost: "Rust basics"}}
+	now := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewPostCache(service, time.Minute, 10)
+	cache.now = func() time.Time { return now }
+
+	posts, err := cache.Fetch(context.Background(), []string{goPost, deleted})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, namesOf(posts), []string{"Learning Go"})
+
+	//cached posts, the unknown one included, aren't asked again
+	posts, _ = cache.Fetch(context.Background(), []string{rustPost, deleted, goPost})
+	assert.Equal(t, namesOf(posts), []string{"Rust basics", "Learning Go"})
+	assert.Equal(t, service.calls(), [][]string{{goPost, deleted}, {rustPost}})
+	assert.Equal(t, cache.Stats(), CacheStats{Hits: 2, Misses: 3, Size: 3})
+
+	//a post missing from the answer is asked again sooner than the ones found
+	now = now.Add(cache.MissTTL)
+	_, _ = cache.Fetch(context.Background(), []string{goPost, deleted})
+	assert.Equal(t, service.calls()[2], []string{deleted})
+
+	now = now.Add(time.Minute)
+	_, _ = cache.Fetch(context.Background(), []string{goPost})
+	assert.Equal(t, len(service.calls()), 4)
+
+	//failures aren't cached
+	service.err = errors.New("unavailable")
+	cache.Invalidate(goPost)
+	_, err = cache.Fetch(context.Background(), []string{goPost})
+	assert.NotEqual(t, err, nil)
+	service.err = nil
+	posts, _ = cache.Fetch(context.Background(), []string{goPost})
+	assert.Equal(t, namesOf(posts), []string{"Learning Go"})
+	assert.Equal(t, len(service.calls()), 6)
+
+	//misses can be left out of the cache altogether
+	cache.MissTTL = 0
+	cache.Invalidate(deleted)
+	_, _ = cache.Fetch(context.Background(), []string{deleted})
+	_, _ = cache.Fetch(context.Background(), []string{deleted})
+	assert.Equal(t, len(service.calls()), 8)
+}
+
+func TestPostCacheEvictsLeastRecentlyUsed(t *testing.T) {
+
+	ids := []string{models.GenerateObjectID().Hex(), models.GenerateObjectID().Hex(), models.GenerateObjectID().Hex()}
+	service := &countingPostService{names: map[string]string{ids[0]: "first", ids[1]: "second", ids[2]: "third"}}
+	cache := NewPostCache(service, time.Hour, 2)
+
+	_, _ = cache.Fetch(context.Background(), ids[:2])
+	_, _ = cache.Fetch(context.Background(), ids[:1])
+	_, _ = cache.Fetch(context.Background(), ids[2:])
+	assert.Equal(t, cache.Stats().Size, 2)
+
+	//the second post was used least recently
+	_, _ = cache.Fetch(context.Background(), ids)
+	assert.Equal(t, service.calls()[2], []string{ids[1]})
+}
+
+func TestPostCacheCoalescesConcurrentLookups(t *testing.T) {
+
+	postID := models.GenerateObjectID().Hex()
+	service := &countingPostService{names: map[string]string{postID: "Learning Go"}, gate: make(chan struct{})}
+	cache := NewPostCache(service, time.Hour, 10)
+
+	var wg sync.WaitGroup
+	names := make([][]string, 10)
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			posts, _ := cache.Fetch(context.Background(), []string{postID})
+			names[i] = namesOf(posts)
+		}(i)
+	}
+
+	//every caller but the first joins its lookup
+	for cache.Stats().Misses < 10 {
+		time.Sleep(time.Millisecond)
+	}
+	close(service.gate)
+	wg.Wait()
+
+	assert.Equal(t, len(service.calls()), 1)
+	assert.Equal(t, cache.Stats().Coalesced, uint64(9))
+	for _, n := range names {
+		assert.Equal(t, n, []string{"Learning Go"})
+	}
+}
+
+func TestPostCacheInvalidationSkipsRunningLookups(t *testing.T) {
+
+	postID := models.GenerateObjectID().Hex()
+	gate := make(chan struct{})
+	service := &countingPostService{names: map[string]string{postID: "Old name"}, gate: gate}
+	cache := NewPostCache(service, time.Hour, 10)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = cache.Fetch(context.Background(), []string{postID})
+	}()
+	for len(service.calls()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	//renamed while the lookup was on its way
+	cache.Invalidate(postID)
+	service.mu.Lock()
+	service.names = map[string]string{postID: "New name"}
+	service.gate = nil
+	service.mu.Unlock()
+	close(gate)
+	<-done
+
+	posts, _ := cache.Fetch(context.Background(), []string{postID})
+	assert.Equal(t, namesOf(posts), []string{"New name"})
+	assert.Equal(t, len(service.calls()), 2)
+}
+
+func TestPostCacheLookupOutlivesItsCaller(t *testing.T) {
+
+	postID := models.GenerateObjectID().Hex()
+	gate := make(chan struct{})
+	service := &countingPostService{names: map[string]string{postID: "Learning Go"}, gate: gate}
+	cache := NewPostCache(service, time.Hour, 10)
+
+	//the caller starting the lookup gives up while another one waits on it
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan error)
+	go func() {
+		_, err := cache.Fetch(ctx, []string{postID})
+		started <- err
+	}()
+	for len(service.calls()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	joined := make(chan []string)
+	go func() {
+		posts, _ := cache.Fetch(context.Background(), []string{postID})
+		joined <- namesOf(posts)
+	}()
+	for cache.Stats().Coalesced == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	assert.Equal(t, <-started, context.Canceled)
+	close(gate)
+	assert.Equal(t, <-joined, []string{"Learning Go"})
+	assert.Equal(t, len(service.calls()), 1)
+}
@@ -8,82 +8,330 @@ import (
 	"golek_bookmark_service/pkg/models"
 	ps "golek_bookmark_service/pkg/models/proto_schema"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcStatus "google.golang.org/grpc/status"
 	"log"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
 )
 
+// ErrPostServiceUnavailable is returned without calling the post service while its breaker is open
+var ErrPostServiceUnavailable = errors.New("post metadata unavailable")
+
+// defaults used when the RPC_* settings are left empty
+const (
+	defaultTimeout          = 2 * time.Second
+	defaultMaxRetries       = 2
+	defaultBackoff          = 100 * time.Millisecond
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+	defaultChunkSize        = 100
+	defaultParallelism      = 4
+	// maxBackoff caps the delay between two attempts however many retries came before
+	maxBackoff = 2 * time.Second
+)
+
+// transientCodes are failures worth retrying, the service may well answer the same request a moment later
+var transientCodes = map[codes.Code]bool{
+	codes.Unavailable:       true,
+	codes.DeadlineExceeded:  true,
+	codes.ResourceExhausted: true,
+	codes.Aborted:           true,
+}
+
 type GRPCServiceClient struct {
 	HOST   string
 	PORT   string
 	Client ps.PostServiceClient
+	// Timeout bounds every attempt, zero leaves only the caller's deadline
+	Timeout time.Duration
+	// MaxRetries is how many more attempts a transient failure gets
+	MaxRetries int
+	// Backoff is the base delay before a retry, it doubles every retry and is jittered
+	Backoff time.Duration
+	// Breaker is optional, without it every call reaches the service
+	Breaker *Breaker
+	TLS     TLSSettings
+	// ChunkSize is the most post IDs sent in one request, zero sends them all at once
+	ChunkSize int
+	// Parallelism is how many chunks are requested at the same time
+	Parallelism int
 }
 
+// Fetch look the posts up in chunks of ChunkSize, the posts found come back in the order of 'postIDs',
+// the ones the service tombstoned come back flagged deleted.
+// the breaker sees the whole lookup as one call
 func (c *GRPCServiceClient) Fetch(ctx context.Context, postIDs []string) ([]models.Post, error) {
 
-	//cID := ps.CoursesID{CoursesID: []string{"6300988647b1637e7974b3d9", "6300988647b1637e7974b3d6"}}
-	courses, err := c.Client.Fetch(ctx, &ps.PostIDs{Id: postIDs})
+	if c.Breaker != nil && !c.Breaker.Allow() {
+		return nil, ErrPostServiceUnavailable
+	}
+
+	courses, err := c.fetchChunks(ctx, chunk(postIDs, c.ChunkSize))
+	c.record(ctx, err)
 	if err != nil {
 		log.Println("gRPC Client: PostService: Fetch Error >>", err)
 		return nil, err
 	}
 
-	posts := make([]models.Post, 0)
-	for _, c := range courses.List {
+	byID := make(map[string]*ps.Post)
+	for _, list := range courses {
+		for _, c := range list.GetList() {
+			byID[c.Id] = c
+		}
+	}
+
+	posts := make([]models.Post, 0, len(byID))
+	for _, id := range postIDs {
+		c, ok := byID[id]
+		if !ok {
+			continue
+		}
+		delete(byID, id)
+		if c.Deleted {
+			posts = append(posts, models.Post{ID: models.GenerateObjectIDFromHex(c.Id), Availability: models.PostDeleted})
+			continue
+		}
 		posts = append(posts, models.Post{
 			ID:       models.GenerateObjectIDFromHex(c.Id),
 			Name:     c.Name,
 			ImageUrl: c.ImageUrl,
+			OwnerID:  c.UserId,
 		})
 	}
 
 	return posts, nil
 }
 
+// fetchChunks request the chunks at most Parallelism at a time, the first failure cancels the chunks still running
+func (c *GRPCServiceClient) fetchChunks(ctx context.Context, chunks [][]string) ([]*ps.Posts, error) {
+
+	if len(chunks) == 1 {
+		courses, err := c.fetchWithRetries(ctx, chunks[0])
+		return []*ps.Posts{courses}, err
+	}
+
+	parallelism := c.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	results := make([]*ps.Posts, len(chunks))
+	slots := make(chan struct{}, parallelism)
+
+	for i := range chunks {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			courses, err := c.fetchWithRetries(ctx, chunks[i])
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			results[i] = courses
+		}(i)
+	}
+	wg.Wait()
+
+	if firstErr == nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return results, firstErr
+}
+
+// fetchWithRetries attempt the call until it succeeds, fails for good or runs out of retries
+func (c *GRPCServiceClient) fetchWithRetries(ctx context.Context, postIDs []string) (*ps.Posts, error) {
+
+	for attempt := 0; ; attempt++ {
+
+		courses, err := c.fetchOnce(ctx, postIDs)
+		if err == nil || !isTransient(err) || attempt >= c.MaxRetries {
+			return courses, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(c.backoff(attempt)):
+		}
+	}
+}
+
+func (c *GRPCServiceClient) fetchOnce(ctx context.Context, postIDs []string) (*ps.Posts, error) {
+
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	return c.Client.Fetch(ctx, &ps.PostIDs{Id: postIDs})
+}
+
+// backoff pick a random delay up to Backoff doubled 'attempt' times, spreading retries of concurrent callers apart
+func (c *GRPCServiceClient) backoff(attempt int) time.Duration {
+
+	if c.Backoff <= 0 {
+		return 0
+	}
+
+	ceiling := c.Backoff << attempt
+	if ceiling <= 0 || ceiling > maxBackoff {
+		ceiling = maxBackoff
+	}
+
+	return time.Duration(rand.Int63n(int64(ceiling)))
+}
+
+// record tell the breaker how the call went, only transient failures count against the service
+func (c *GRPCServiceClient) record(ctx context.Context, err error) {
+	switch {
+	case c.Breaker == nil:
+	case err == nil:
+		c.Breaker.Success()
+	case ctx.Err() != nil:
+		//the caller gave up, that says nothing about the service
+		c.Breaker.Abandon()
+	case isTransient(err):
+		c.Breaker.Failure()
+	default:
+		//the service answered, even if with an error
+		c.Breaker.Success()
+	}
+}
+
+// chunk split the IDs into slices of at most 'size', a size below one keeps them together
+func chunk(postIDs []string, size int) [][]string {
+
+	if size < 1 || len(postIDs) <= size {
+		return [][]string{postIDs}
+	}
+
+	chunks := make([][]string, 0, (len(postIDs)+size-1)/size)
+	for size < len(postIDs) {
+		chunks = append(chunks, postIDs[:size:size])
+		postIDs = postIDs[size:]
+	}
+	return append(chunks, postIDs)
+}
+
+func isTransient(err error) bool {
+	return transientCodes[grpcStatus.Code(err)]
+}
+
 func (c *GRPCServiceClient) Dial() (ps.PostServiceClient, error) {
 
 	host := c.HOST + ":" + c.PORT
 
-	conn, err := grpc.Dial(host, grpc.WithInsecure())
+	transport, err := transportCredentials(c.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.Dial(host, grpc.WithTransportCredentials(transport))
 	if err != nil {
 		return nil, errors.New(fmt.Sprintf("could not connect to %v %v", host, err))
 	}
 
-	log.Println("GRPC Connected to", host)
+	log.Println("GRPC Connected to", host, "over", c.TLS.Mode)
 
 	c.Client = ps.NewPostServiceClient(conn)
 
 	return c.Client, nil
 }
 
-func New(config contracts.Config) contracts.GRPCPostService {
-	return &GRPCServiceClient{HOST: config.GetAppConfig()["RPC_TARGET_HOST"], PORT: config.GetAppConfig()["RPC_TARGET_PORT"]}
+// New build the post service client from the RPC_* settings, empty ones take their default
+func New(config contracts.AppConfig) (contracts.GRPCPostService, error) {
+
+	app := config.GetAppConfig()
+	client := &GRPCServiceClient{HOST: app["RPC_TARGET_HOST"], PORT: app["RPC_TARGET_PORT"]}
+
+	var err error
+	if client.Timeout, err = durationOr(app["RPC_TIMEOUT"], defaultTimeout); err != nil {
+		return nil, err
+	}
+	if client.MaxRetries, err = intOr(app["RPC_MAX_RETRIES"], defaultMaxRetries); err != nil {
+		return nil, err
+	}
+	if client.Backoff, err = durationOr(app["RPC_RETRY_BACKOFF"], defaultBackoff); err != nil {
+		return nil, err
+	}
+
+	threshold, err := intOr(app["RPC_BREAKER_THRESHOLD"], defaultBreakerThreshold)
+	if err != nil {
+		return nil, err
+	}
+	cooldown, err := durationOr(app["RPC_BREAKER_COOLDOWN"], defaultBreakerCooldown)
+	if err != nil {
+		return nil, err
+	}
+	client.Breaker = NewBreaker(threshold, cooldown)
+
+	if client.ChunkSize, err = intOr(app["RPC_CHUNK_SIZE"], defaultChunkSize); err != nil {
+		return nil, err
+	}
+	if client.Parallelism, err = intOr(app["RPC_PARALLELISM"], defaultParallelism); err != nil {
+		return nil, err
+	}
+
+	client.TLS = TLSSettings{
+		Mode:       app["RPC_TLS_MODE"],
+		CAFile:     app["RPC_TLS_CA_FILE"],
+		CertFile:   app["RPC_TLS_CERT_FILE"],
+		KeyFile:    app["RPC_TLS_KEY_FILE"],
+		ServerName: app["RPC_TLS_SERVER_NAME"],
+	}
+	if client.TLS.Mode == "" {
+		client.TLS.Mode = TLSModeTLS
+	}
+	if err := client.TLS.Validate(); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+func durationOr(value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return d, nil
 }
 
-//Procedural Test
-//func serviceCourse() ps.CoursesServiceClient {
-//
-//	host := "172.24.0.3:6060"
-//
-//	conn, err := grpc.Dial(host, grpc.WithInsecure())
-//	if err != nil {
-//		log.Fatal("could not connect to", host, err)
-//	}
-//
-//	return ps.NewCoursesServiceClient(conn)
-//}
-//
-//func main() {
-//
-//	coursesServices := serviceCourse()
-//
-//	cID := ps.CoursesID{CoursesID: []string{"6300988647b1637e7974b3d9", "6300988647b1637e7974b3d6"}}
-//
-//	courses, err := coursesServices.Fetch(context.TODO(), &cID)
-//	if err != nil {
-//		return
-//	}
-//
-//	for _, c := range courses.Fetch {
-//		log.Println(c)
-//	}
-//
-//}
+func intOr(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("invalid number %q", value)
+	}
+	return i, nil
+}
@@ -0,0 +1,282 @@
+package grpc_client
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"golek_bookmark_service/pkg/models"
+	ps "golek_bookmark_service/pkg/models/proto_schema"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	grpcStatus "google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakePostServer answers Fetch with 'fetch', counting the calls it receives and keeping their post IDs
+type fakePostServer struct {
+	ps.UnimplementedPostServiceServer
+	calls    int32
+	fetch    func(ctx context.Context, call int32) (*ps.Posts, error)
+	mu       sync.Mutex
+	requests [][]string
+}
+
+func (f *fakePostServer) Fetch(ctx context.Context, request *ps.PostIDs) (*ps.Posts, error) {
+	f.mu.Lock()
+	f.requests = append(f.requests, request.Id)
+	f.mu.Unlock()
+	return f.fetch(ctx, atomic.AddInt32(&f.calls, 1))
+}
+
+// newTestClient serve 'server' over an in-process connection and point a plaintext client without breaker at it
+func newTestClient(t *testing.T, server *fakePostServer) *GRPCServiceClient {
+	return &GRPCServiceClient{
+		Client:     dialTestServer(t, serveTestServer(t, server), insecure.NewCredentials()),
+		Timeout:    time.Second,
+		MaxRetries: 2,
+		Backoff:    time.Millisecond,
+	}
+}
+
+func serveTestServer(t *testing.T, server *fakePostServer, opts ...grpc.ServerOption) *bufconn.Listener {
+
+	listener := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer(opts...)
+	ps.RegisterPostServiceServer(s, server)
+	go func() {
+		_ = s.Serve(listener)
+	}()
+	t.Cleanup(s.Stop)
+
+	return listener
+}
+
+func dialTestServer(t *testing.T, listener *bufconn.Listener, transport credentials.TransportCredentials) ps.PostServiceClient {
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(transport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return ps.NewPostServiceClient(conn)
+}
+
+func failing(code codes.Code) func(ctx context.Context, call int32) (*ps.Posts, error) {
+	return func(ctx context.Context, call int32) (*ps.Posts, error) {
+		return nil, grpcStatus.Error(code, "failing")
+	}
+}
+
+func TestFetchFlagsTombstonedPosts(t *testing.T) {
+
+	kept, deleted, left := models.GenerateObjectID().Hex(), models.GenerateObjectID().Hex(), models.GenerateObjectID().Hex()
+	server := &fakePostServer{fetch: func(ctx context.Context, call int32) (*ps.Posts, error) {
+		return &ps.Posts{List: []*ps.Post{{Id: deleted, Deleted: true}, {Id: kept, Name: "Kept", UserId: "author"}}}, nil
+	}}
+	client := newTestClient(t, server)
+
+	//a post left out of the answer isn't reported at all
+	posts, err := client.Fetch(context.Background(), []string{kept, deleted, left})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, posts, []models.Post{
+		{ID: models.GenerateObjectIDFromHex(kept), Name: "Kept", OwnerID: "author"},
+		{ID: models.GenerateObjectIDFromHex(deleted), Availability: models.PostDeleted},
+	})
+}
+
+func TestFetchRetriesTransientFailures(t *testing.T) {
+
+	postID := models.GenerateObjectID().Hex()
+	server := &fakePostServer{fetch: func(ctx context.Context, call int32) (*ps.Posts, error) {
+		if call < 3 {
+			return nil, grpcStatus.Error(codes.Unavailable, "restarting")
+		}
+		return &ps.Posts{List: []*ps.Post{{Id: postID, Name: "Learning Go"}}}, nil
+	}}
+	client := newTestClient(t, server)
+
+	posts, err := client.Fetch(context.Background(), []string{postID})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, server.calls, int32(3))
+	assert.Equal(t, posts[0].Name, "Learning Go")
+
+	//retries run out
+	server.calls = 0
+	server.fetch = failing(codes.Unavailable)
+	_, err = client.Fetch(context.Background(), []string{postID})
+	assert.Equal(t, grpcStatus.Code(err), codes.Unavailable)
+	assert.Equal(t, server.calls, int32(3))
+
+	//a request the service rejects would be rejected again
+	server.calls = 0
+	server.fetch = failing(codes.InvalidArgument)
+	_, err = client.Fetch(context.Background(), []string{postID})
+	assert.Equal(t, grpcStatus.Code(err), codes.InvalidArgument)
+	assert.Equal(t, server.calls, int32(1))
+}
+
+func TestFetchTimesOutHangingService(t *testing.T) {
+
+	server := &fakePostServer{fetch: func(ctx context.Context, call int32) (*ps.Posts, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+	client := newTestClient(t, server)
+	client.Timeout = 20 * time.Millisecond
+	client.MaxRetries = 1
+
+	started := time.Now()
+	_, err := client.Fetch(context.Background(), []string{models.GenerateObjectID().Hex()})
+	assert.Equal(t, grpcStatus.Code(err), codes.DeadlineExceeded)
+	assert.Equal(t, time.Since(started) < time.Second, true)
+	assert.Equal(t, atomic.LoadInt32(&server.calls), int32(2))
+
+	//the caller's own deadline stops the retries
+	client.Timeout = 0
+	client.MaxRetries = 5
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = client.Fetch(ctx, []string{models.GenerateObjectID().Hex()})
+	assert.Equal(t, grpcStatus.Code(err), codes.DeadlineExceeded)
+	assert.Equal(t, atomic.LoadInt32(&server.calls), int32(3))
+}
+
+func TestBreakerShortCircuitsFailingService(t *testing.T) {
+
+	now := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+	server := &fakePostServer{fetch: failing(codes.Unavailable)}
+	client := newTestClient(t, server)
+	client.MaxRetries = 0
+	client.Breaker = NewBreaker(2, time.Minute)
+	client.Breaker.now = func() time.Time { return now }
+
+	fetch := func() error {
+		_, err := client.Fetch(context.Background(), []string{models.GenerateObjectID().Hex()})
+		return err
+	}
+
+	assert.Equal(t, grpcStatus.Code(fetch()), codes.Unavailable)
+	assert.Equal(t, grpcStatus.Code(fetch()), codes.Unavailable)
+	//open: answered right away without reaching the service
+	assert.Equal(t, errors.Is(fetch(), ErrPostServiceUnavailable), true)
+	assert.Equal(t, server.calls, int32(2))
+
+	//a failed probe after the cooldown opens it again
+	now = now.Add(time.Minute)
+	assert.Equal(t, grpcStatus.Code(fetch()), codes.Unavailable)
+	assert.Equal(t, errors.Is(fetch(), ErrPostServiceUnavailable), true)
+	assert.Equal(t, server.calls, int32(3))
+
+	//a successful probe closes it
+	now = now.Add(time.Minute)
+	server.fetch = func(ctx context.Context, call int32) (*ps.Posts, error) { return &ps.Posts{}, nil }
+	assert.Equal(t, fetch(), nil)
+	assert.Equal(t, fetch(), nil)
+	assert.Equal(t, server.calls, int32(5))
+
+	//errors of a healthy service don't count
+	server.fetch = failing(codes.InvalidArgument)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, grpcStatus.Code(fetch()), codes.InvalidArgument)
+	}
+	assert.Equal(t, server.calls, int32(8))
+}
+
+func TestFetchSplitsLargeLookupsIntoChunks(t *testing.T) {
+
+	postIDs := make([]string, 250)
+	for i := range postIDs {
+		postIDs[i] = models.GenerateObjectID().Hex()
+	}
+
+	var running, mostRunning int32
+	server := &fakePostServer{}
+	server.fetch = func(ctx context.Context, call int32) (*ps.Posts, error) {
+		if n := atomic.AddInt32(&running, 1); n > atomic.LoadInt32(&mostRunning) {
+			atomic.StoreInt32(&mostRunning, n)
+		}
+		defer atomic.AddInt32(&running, -1)
+		time.Sleep(10 * time.Millisecond)
+
+		//the service answers in its own order and doesn't know the first post of a chunk
+		server.mu.Lock()
+		requested := server.requests[call-1]
+		server.mu.Unlock()
+		posts := &ps.Posts{}
+		for i := len(requested) - 1; i > 0; i-- {
+			posts.List = append(posts.List, &ps.Post{Id: requested[i], Name: requested[i]})
+		}
+		return posts, nil
+	}
+	client := newTestClient(t, server)
+	client.ChunkSize = 100
+	client.Parallelism = 2
+
+	posts, err := client.Fetch(context.Background(), postIDs)
+	assert.Equal(t, err, nil)
+
+	sizes := make([]int, 0)
+	for _, r := range server.requests {
+		sizes = append(sizes, len(r))
+	}
+	sort.Ints(sizes)
+	assert.Equal(t, sizes, []int{50, 100, 100})
+	assert.Equal(t, atomic.LoadInt32(&mostRunning) <= 2, true)
+
+	expected := make([]string, 0)
+	for i, id := range postIDs {
+		if i%100 != 0 {
+			expected = append(expected, id)
+		}
+	}
+	names := make([]string, 0)
+	for _, p := range posts {
+		names = append(names, p.Name)
+	}
+	assert.Equal(t, names, expected)
+
+	//one failing chunk fails the lookup
+	server.fetch = func(ctx context.Context, call int32) (*ps.Posts, error) {
+		if call == 5 {
+			return nil, grpcStatus.Error(codes.InvalidArgument, "too many")
+		}
+		return &ps.Posts{}, nil
+	}
+	_, err = client.Fetch(context.Background(), postIDs)
+	assert.Equal(t, grpcStatus.Code(err), codes.InvalidArgument)
+}
+
+func TestNewReadsSettings(t *testing.T) {
+
+	client, err := New(appConfig{"RPC_TIMEOUT": "500ms", "RPC_MAX_RETRIES": "0", "RPC_BREAKER_THRESHOLD": "3"})
+	assert.Equal(t, err, nil)
+	c := client.(*GRPCServiceClient)
+	assert.Equal(t, c.Timeout, 500*time.Millisecond)
+	assert.Equal(t, c.MaxRetries, 0)
+	assert.Equal(t, c.Backoff, defaultBackoff)
+	assert.Equal(t, c.Breaker.Threshold, 3)
+	assert.Equal(t, c.Breaker.Cooldown, defaultBreakerCooldown)
+	assert.Equal(t, c.ChunkSize, defaultChunkSize)
+
+	for _, cfg := range []appConfig{{"RPC_TIMEOUT": "soon"}, {"RPC_MAX_RETRIES": "-1"}, {"RPC_BREAKER_COOLDOWN": "1"}, {"RPC_CHUNK_SIZE": "-100"}} {
+		_, err := New(cfg)
+		assert.NotEqual(t, err, nil)
+	}
+}
+
+type appConfig map[string]string
+
+func (c appConfig) GetAppConfig() map[string]string {
+	return c
+}
@@ -0,0 +1,205 @@
+package grpc_client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// modes of the connection to the post service, plaintext has to be asked for explicitly
+const (
+	TLSModeTLS       = "tls"
+	TLSModeMutual    = "mtls"
+	TLSModePlaintext = "plaintext"
+)
+
+// TLSSettings tell how the post service connection is secured. CAFile verifies the server, the system roots
+// do when it's empty; CertFile and KeyFile are the client certificate presented in mtls mode
+type TLSSettings struct {
+	Mode       string
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	ServerName string
+}
+
+// Validate check the settings are complete for their mode
+func (s TLSSettings) Validate() error {
+	switch s.Mode {
+	case TLSModePlaintext:
+		return nil
+	case TLSModeTLS:
+		if (s.CertFile == "") != (s.KeyFile == "") {
+			return errors.New("tls client certificate needs both a cert and a key file")
+		}
+		return nil
+	case TLSModeMutual:
+		if s.CertFile == "" || s.KeyFile == "" {
+			return errors.New("mtls needs a client cert and key file")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown tls mode %q, expected tls, mtls or plaintext", s.Mode)
+}
+
+// transportCredentials build the credentials of the settings' mode, certificates are loaded once upfront
+// so broken paths fail at boot rather than on the first call
+func transportCredentials(settings TLSSettings) (credentials.TransportCredentials, error) {
+
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	if settings.Mode == TLSModePlaintext {
+		return insecure.NewCredentials(), nil
+	}
+
+	reloading := &reloadingCredentials{settings: settings}
+	if _, err := reloading.load(); err != nil {
+		return nil, err
+	}
+	return reloading, nil
+}
+
+// reloadingCredentials hand every new connection TLS settings built from the current files,
+// certificates rotated on disk are picked up by the next handshake without a restart
+type reloadingCredentials struct {
+	settings TLSSettings
+
+	mu      sync.Mutex
+	stamps  []fileStamp
+	current credentials.TransportCredentials
+}
+
+// fileStamp identify a version of a file, a rewrite changes its modification time or size
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func (r *reloadingCredentials) ClientHandshake(ctx context.Context, authority string, rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	current, err := r.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	return current.ClientHandshake(ctx, authority, rawConn)
+}
+
+func (r *reloadingCredentials) ServerHandshake(rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errors.New("post service credentials only secure the client side")
+}
+
+func (r *reloadingCredentials) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "tls", SecurityVersion: "1.2", ServerName: r.settings.ServerName}
+}
+
+func (r *reloadingCredentials) Clone() credentials.TransportCredentials {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return &reloadingCredentials{settings: r.settings, stamps: r.stamps, current: r.current}
+}
+
+func (r *reloadingCredentials) OverrideServerName(serverName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.settings.ServerName = serverName
+	r.stamps = nil
+	return nil
+}
+
+// load return the credentials of the files as they are now, rebuilt only when one of them changed.
+// a rotation caught half written keeps the previous credentials until the files make sense again
+func (r *reloadingCredentials) load() (credentials.TransportCredentials, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stamps, err := r.stampFiles()
+	if err == nil && r.current != nil && equalStamps(stamps, r.stamps) {
+		return r.current, nil
+	}
+
+	var config *tls.Config
+	if err == nil {
+		config, err = r.tlsConfig()
+	}
+	if err != nil {
+		if r.current != nil {
+			log.Println("gRPC Client: keeping the previous TLS certificates >>", err)
+			return r.current, nil
+		}
+		return nil, err
+	}
+
+	if r.current != nil {
+		log.Println("gRPC Client: TLS certificates reloaded")
+	}
+	r.current = credentials.NewTLS(config)
+	r.stamps = stamps
+
+	return r.current, nil
+}
+
+func (r *reloadingCredentials) tlsConfig() (*tls.Config, error) {
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: r.settings.ServerName}
+
+	if r.settings.CAFile != "" {
+		ca, err := os.ReadFile(r.settings.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate found in %s", r.settings.CAFile)
+		}
+	}
+
+	if r.settings.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(r.settings.CertFile, r.settings.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	return config, nil
+}
+
+func (r *reloadingCredentials) stampFiles() ([]fileStamp, error) {
+	stamps := make([]fileStamp, 0, 3)
+	for _, path := range []string{r.settings.CAFile, r.settings.CertFile, r.settings.KeyFile} {
+		if path == "" {
+			stamps = append(stamps, fileStamp{})
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		stamps = append(stamps, fileStamp{modTime: info.ModTime(), size: info.Size()})
+	}
+	return stamps, nil
+}
+
+func equalStamps(a []fileStamp, b []fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].modTime.Equal(b[i].modTime) || a[i].size != b[i].size {
+			return false
+		}
+	}
+	return true
+}
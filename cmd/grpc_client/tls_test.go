@@ -0,0 +1,209 @@
+package grpc_client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/go-playground/assert/v2"
+	ps "golek_bookmark_service/pkg/models/proto_schema"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA signs the certificates of a test, its PEM goes to CA files and server pools
+type testCA struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	pem         []byte
+}
+
+func newTestCA(t *testing.T, name string) testCA {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, _ := x509.ParseCertificate(der)
+
+	return testCA{certificate: certificate, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue sign a leaf certificate for 'name', returned as PEM certificate and key
+func (ca testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) (certPEM []byte, keyPEM []byte) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// serveTLS serve a healthy post service named "posts.internal" signed by 'ca', client certificates
+// are required and checked against 'clientCA' when given
+func serveTLS(t *testing.T, ca testCA, clientCA *testCA) func(transport credentials.TransportCredentials) error {
+
+	certPEM, keyPEM := ca.issue(t, "posts.internal", x509.ExtKeyUsageServerAuth)
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &tls.Config{Certificates: []tls.Certificate{certificate}}
+	if clientCA != nil {
+		config.ClientCAs = x509.NewCertPool()
+		config.ClientCAs.AppendCertsFromPEM(clientCA.pem)
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	server := &fakePostServer{fetch: func(ctx context.Context, call int32) (*ps.Posts, error) { return &ps.Posts{}, nil }}
+	listener := serveTestServer(t, server, grpc.Creds(credentials.NewTLS(config)))
+
+	//every fetch dials anew, like a connection re-established after a rotation
+	return func(transport credentials.TransportCredentials) error {
+		client := &GRPCServiceClient{Client: dialTestServer(t, listener, transport), Timeout: 2 * time.Second}
+		_, err := client.Fetch(context.Background(), []string{})
+		return err
+	}
+}
+
+func writeFile(t *testing.T, path string, content []byte, modTime time.Time) string {
+	t.Helper()
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	//a rewrite within the same clock tick still counts as a change
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTLSVerifiesTheServer(t *testing.T) {
+
+	dir := t.TempDir()
+	serverCA, otherCA := newTestCA(t, "server-ca"), newTestCA(t, "other-ca")
+	fetch := serveTLS(t, serverCA, nil)
+
+	transport, err := transportCredentials(TLSSettings{
+		Mode:       TLSModeTLS,
+		CAFile:     writeFile(t, filepath.Join(dir, "ca.pem"), serverCA.pem, time.Now()),
+		ServerName: "posts.internal",
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, fetch(transport), nil)
+
+	//a server signed by another CA isn't trusted
+	untrusted, _ := transportCredentials(TLSSettings{
+		Mode:       TLSModeTLS,
+		CAFile:     writeFile(t, filepath.Join(dir, "other.pem"), otherCA.pem, time.Now()),
+		ServerName: "posts.internal",
+	})
+	assert.NotEqual(t, fetch(untrusted), nil)
+
+	//neither is a name the certificate wasn't issued for
+	misnamed, _ := transportCredentials(TLSSettings{Mode: TLSModeTLS, CAFile: filepath.Join(dir, "ca.pem"), ServerName: "bookmarks.internal"})
+	assert.NotEqual(t, fetch(misnamed), nil)
+
+	assert.NotEqual(t, fetch(insecure.NewCredentials()), nil)
+}
+
+func TestMutualTLSReloadsRotatedCertificates(t *testing.T) {
+
+	dir := t.TempDir()
+	serverCA, oldClientCA, clientCA := newTestCA(t, "server-ca"), newTestCA(t, "old-client-ca"), newTestCA(t, "client-ca")
+	fetch := serveTLS(t, serverCA, &clientCA)
+
+	modTime := time.Now().Add(-time.Hour)
+	certPEM, keyPEM := oldClientCA.issue(t, "bookmarks", x509.ExtKeyUsageClientAuth)
+	settings := TLSSettings{
+		Mode:       TLSModeMutual,
+		CAFile:     writeFile(t, filepath.Join(dir, "ca.pem"), serverCA.pem, modTime),
+		CertFile:   writeFile(t, filepath.Join(dir, "client.pem"), certPEM, modTime),
+		KeyFile:    writeFile(t, filepath.Join(dir, "client-key.pem"), keyPEM, modTime),
+		ServerName: "posts.internal",
+	}
+	transport, err := transportCredentials(settings)
+	assert.Equal(t, err, nil)
+
+	//the server doesn't trust the certificate yet
+	assert.NotEqual(t, fetch(transport), nil)
+
+	//a key written without its certificate keeps the previous pair
+	previous, _ := transport.(*reloadingCredentials).load()
+	_, rotatedKey := clientCA.issue(t, "bookmarks", x509.ExtKeyUsageClientAuth)
+	writeFile(t, settings.KeyFile, rotatedKey, modTime.Add(time.Minute))
+	current, err := transport.(*reloadingCredentials).load()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, current == previous, true)
+
+	certPEM, keyPEM = clientCA.issue(t, "bookmarks", x509.ExtKeyUsageClientAuth)
+	writeFile(t, settings.CertFile, certPEM, modTime.Add(2*time.Minute))
+	writeFile(t, settings.KeyFile, keyPEM, modTime.Add(2*time.Minute))
+	assert.Equal(t, fetch(transport), nil)
+
+	//without a client certificate the server hangs up
+	withoutCertificate, _ := transportCredentials(TLSSettings{Mode: TLSModeTLS, CAFile: settings.CAFile, ServerName: "posts.internal"})
+	assert.NotEqual(t, fetch(withoutCertificate), nil)
+}
+
+func TestTLSSettingsMustBeComplete(t *testing.T) {
+
+	for _, settings := range []TLSSettings{
+		{},
+		{Mode: "ssl"},
+		{Mode: TLSModeMutual, CertFile: "client.pem"},
+		{Mode: TLSModeTLS, KeyFile: "client-key.pem"},
+	} {
+		assert.NotEqual(t, settings.Validate(), nil)
+	}
+
+	//files are read upfront
+	_, err := transportCredentials(TLSSettings{Mode: TLSModeTLS, CAFile: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.NotEqual(t, err, nil)
+
+	//plaintext has to be asked for
+	client, err := New(appConfig{})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, client.(*GRPCServiceClient).TLS.Mode, TLSModeTLS)
+	_, err = New(appConfig{"RPC_TLS_MODE": "mtls"})
+	assert.NotEqual(t, err, nil)
+}
@@ -0,0 +1,222 @@
+package grpc_server
+
+import (
+	"context"
+	"golek_bookmark_service/pkg/contracts"
+	"golek_bookmark_service/pkg/contracts/status"
+	"golek_bookmark_service/pkg/http/middleware"
+	"golek_bookmark_service/pkg/http/requests"
+	"golek_bookmark_service/pkg/models"
+	ps "golek_bookmark_service/pkg/models/proto_schema"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	grpcStatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log"
+	"net"
+)
+
+type BookmarkServiceServer struct {
+	ps.UnimplementedBookmarkServiceServer
+	BookmarkUsecase contracts.BookmarkUsecase
+	Authenticator   *middleware.Authenticator
+}
+
+func (s *BookmarkServiceServer) FetchByUserId(ctx context.Context, request *ps.BookmarkUserID) (*ps.Bookmark, error) {
+
+	//gRPC callers get every saved post at once
+	bookmark, _, opStatus, err := s.BookmarkUsecase.FetchByUserId(ctx, request.UserId, []string{}, models.PostFilter{}, models.CursorPage{})
+	if err != nil {
+		return nil, toStatusError(opStatus, err)
+	}
+
+	return toProtoBookmark(bookmark), nil
+}
+
+func (s *BookmarkServiceServer) AddPost(ctx context.Context, request *ps.BookmarkPostsRequest) (*ps.BookmarkPostsResponse, error) {
+
+	opStatus, err := s.BookmarkUsecase.AddPost(ctx, &requests.AddPostBookmarkRequest{
+		UserID:       request.UserId,
+		CollectionID: request.CollectionId,
+		Posts:        toRequestPosts(request.PostIds),
+	}, request.UserId)
+	if err != nil {
+		return nil, toStatusError(opStatus, err)
+	}
+
+	return &ps.BookmarkPostsResponse{Message: "success"}, nil
+}
+
+func (s *BookmarkServiceServer) RevokePost(ctx context.Context, request *ps.BookmarkPostsRequest) (*ps.BookmarkPostsResponse, error) {
+
+	opStatus, err := s.BookmarkUsecase.RevokePost(ctx, &requests.DeleteAttachedPostRequest{
+		UserID:       request.UserId,
+		CollectionID: request.CollectionId,
+		Posts:        toRequestPosts(request.PostIds),
+	}, request.UserId)
+	if err != nil {
+		return nil, toStatusError(opStatus, err)
+	}
+
+	return &ps.BookmarkPostsResponse{Message: "success"}, nil
+}
+
+func (s *BookmarkServiceServer) IsBookmarked(ctx context.Context, request *ps.IsBookmarkedRequest) (*ps.IsBookmarkedResponse, error) {
+
+	if len(request.PostIds) > requests.MaxLookupPostIDs {
+		return nil, grpcStatus.Errorf(codes.InvalidArgument, "too many post_ids, maximum is %d", requests.MaxLookupPostIDs)
+	}
+
+	statuses, opStatus, err := s.BookmarkUsecase.IsBookmarked(ctx, request.UserId, request.PostIds)
+	if err != nil {
+		return nil, toStatusError(opStatus, err)
+	}
+
+	response := &ps.IsBookmarkedResponse{
+		Bookmarked: make(map[string]bool),
+		Statuses:   make(map[string]*ps.BookmarkStatus),
+	}
+	for id, st := range statuses {
+		response.Bookmarked[id] = st.Bookmarked
+		response.Statuses[id] = &ps.BookmarkStatus{Bookmarked: st.Bookmarked}
+		if st.SavedAt != nil {
+			response.Statuses[id].SavedAt = timestamppb.New(*st.SavedAt)
+		}
+	}
+
+	return response, nil
+}
+
+// AuthenticateInterceptor resolve the caller the same way the HTTP middleware does:
+// from the bearer token of the "authorization" metadata, or from the x-user-* metadata in header mode
+func (s *BookmarkServiceServer) AuthenticateInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	var authenticated *middleware.AuthenticatedRequest
+	if s.Authenticator.Mode == middleware.AuthModeHeader {
+
+		if p, ok := peer.FromContext(ctx); !ok || !s.Authenticator.TrustedPeer(p.Addr.String()) {
+			log.Println("gRPC Server: Request from untrusted address")
+			return nil, grpcStatus.Error(codes.PermissionDenied, middleware.ErrUntrustedGateway.Error())
+		}
+
+		userPermission := first("x-user-permission")
+		userRole := first("x-user-role")
+		userId := first("x-user-id")
+
+		if userId == "" || userRole == "" {
+			log.Println("gRPC Server: Request Metadata is Invalid")
+			return nil, grpcStatus.Error(codes.Unauthenticated, "request metadata is invalid")
+		}
+
+		authenticated = &middleware.AuthenticatedRequest{
+			Permissions: userPermission,
+			UserID:      userId,
+			Role:        userRole,
+		}
+
+	} else {
+
+		token := middleware.BearerToken(first("authorization"))
+		verified, err := s.Authenticator.VerifyToken(token)
+		if err != nil {
+			log.Println("gRPC Server: Request Token is Invalid >>", err)
+			return nil, grpcStatus.Error(codes.Unauthenticated, "request token is invalid")
+		}
+		authenticated = verified
+	}
+
+	return handler(middleware.WithAuthenticated(ctx, authenticated), req)
+}
+
+// Serve start listening on the given port, it blocks until the server stops
+func (s *BookmarkServiceServer) Serve(port string) error {
+
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return err
+	}
+
+	server := grpc.NewServer(grpc.UnaryInterceptor(s.AuthenticateInterceptor))
+	ps.RegisterBookmarkServiceServer(server, s)
+
+	log.Println("gRPC Server: BookmarkService listening on", listener.Addr())
+
+	return server.Serve(listener)
+}
+
+func New(bookmarkUsecase contracts.BookmarkUsecase, authenticator *middleware.Authenticator) *BookmarkServiceServer {
+	return &BookmarkServiceServer{BookmarkUsecase: bookmarkUsecase, Authenticator: authenticator}
+}
+
+func toRequestPosts(postIDs []string) []requests.Post {
+	posts := make([]requests.Post, 0)
+	for _, id := range postIDs {
+		posts = append(posts, requests.Post{ID: id})
+	}
+	return posts
+}
+
+func toProtoBookmark(bookmark models.Bookmark) *ps.Bookmark {
+
+	collections := make([]*ps.BookmarkCollection, 0)
+	for _, c := range bookmark.Collections {
+		collections = append(collections, &ps.BookmarkCollection{
+			Id:        c.ID.Hex(),
+			Name:      c.Name,
+			IsDefault: c.IsDefault,
+		})
+	}
+
+	posts := make([]*ps.BookmarkedPost, 0)
+	for _, p := range bookmark.Posts {
+		post := &ps.BookmarkedPost{
+			Id:           p.ID.Hex(),
+			CollectionId: p.CollectionID.Hex(),
+			Name:         p.Name,
+			ImageUrl:     p.ImageUrl,
+			Note:         p.Note,
+			Tags:         p.Tags,
+			Availability: string(p.Availability),
+		}
+		if p.AddedAt != nil {
+			post.AddedAt = timestamppb.New(*p.AddedAt)
+		}
+		if p.SnapshotAt != nil {
+			post.SnapshotAt = timestamppb.New(*p.SnapshotAt)
+		}
+		posts = append(posts, post)
+	}
+
+	return &ps.Bookmark{
+		Id:          bookmark.ID.Hex(),
+		UserId:      bookmark.UserID,
+		Collections: collections,
+		Posts:       posts,
+	}
+}
+
+// toStatusError translate usecase operation status into gRPC status codes
+func toStatusError(opStatus status.OperationStatus, err error) error {
+	switch opStatus {
+	case status.BookmarkNotExist, status.BookmarkCollectionNotExist, status.BookmarkPostNotExist:
+		return grpcStatus.Error(codes.NotFound, err.Error())
+	case status.OperationUnauthorized:
+		return grpcStatus.Error(codes.PermissionDenied, err.Error())
+	case status.OperationForbidden:
+		return grpcStatus.Error(codes.PermissionDenied, err.Error())
+	case status.BookmarkDuplicationOccurs:
+		return grpcStatus.Error(codes.AlreadyExists, err.Error())
+	default:
+		return grpcStatus.Error(codes.Internal, err.Error())
+	}
+}
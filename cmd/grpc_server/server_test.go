@@ -0,0 +1,220 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"golek_bookmark_service/pkg/contracts"
+	"golek_bookmark_service/pkg/contracts/status"
+	"golek_bookmark_service/pkg/http/middleware"
+	"golek_bookmark_service/pkg/http/requests"
+	"golek_bookmark_service/pkg/models"
+	ps "golek_bookmark_service/pkg/models/proto_schema"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	grpcStatus "google.golang.org/grpc/status"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeBookmarkUsecase answers the lookups the handlers forward and records their arguments
+type fakeBookmarkUsecase struct {
+	contracts.BookmarkUsecase
+	postIDs  []string
+	bookmark models.Bookmark
+	added    *requests.AddPostBookmarkRequest
+	revoked  *requests.DeleteAttachedPostRequest
+	opStatus status.OperationStatus
+	err      error
+}
+
+func (f *fakeBookmarkUsecase) FetchByUserId(ctx context.Context, userID string, exclude []string, filter models.PostFilter, page models.CursorPage) (models.Bookmark, *models.Cursor, status.OperationStatus, error) {
+	return f.bookmark, nil, f.opStatus, f.err
+}
+
+func (f *fakeBookmarkUsecase) AddPost(ctx context.Context, request *requests.AddPostBookmarkRequest, userID string) (status.OperationStatus, error) {
+	f.added = request
+	return f.opStatus, f.err
+}
+
+func (f *fakeBookmarkUsecase) RevokePost(ctx context.Context, request *requests.DeleteAttachedPostRequest, userID string) (status.OperationStatus, error) {
+	f.revoked = request
+	return f.opStatus, f.err
+}
+
+func (f *fakeBookmarkUsecase) IsBookmarked(ctx context.Context, userID string, postIDs []string) (map[string]models.BookmarkStatus, status.OperationStatus, error) {
+	f.postIDs = postIDs
+	statuses := make(map[string]models.BookmarkStatus)
+	for _, id := range postIDs {
+		statuses[id] = models.BookmarkStatus{}
+	}
+	return statuses, status.OperationSuccess, nil
+}
+
+func TestIsBookmarkedCapsThePostIDs(t *testing.T) {
+
+	usecase := &fakeBookmarkUsecase{}
+	server := New(usecase, nil)
+
+	postIDs := make([]string, 0)
+	for i := 0; i < requests.MaxLookupPostIDs; i++ {
+		postIDs = append(postIDs, fmt.Sprint(i))
+	}
+	response, err := server.IsBookmarked(context.Background(), &ps.IsBookmarkedRequest{UserId: "owner", PostIds: postIDs})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(response.Statuses), requests.MaxLookupPostIDs)
+
+	usecase.postIDs = nil
+	_, err = server.IsBookmarked(context.Background(), &ps.IsBookmarkedRequest{UserId: "owner", PostIds: append(postIDs, "one too many")})
+	assert.Equal(t, grpcStatus.Code(err), codes.InvalidArgument)
+	assert.Equal(t, len(usecase.postIDs), 0)
+}
+
+func TestFetchByUserIdMapsTheBookmark(t *testing.T) {
+
+	addedAt := time.Date(2022, 9, 1, 10, 0, 0, 0, time.UTC)
+	snapshotAt := addedAt.Add(time.Hour)
+	collection := models.Collection{ID: models.GenerateObjectID(), Name: models.DefaultCollectionName, IsDefault: true}
+	kept, deleted := models.GenerateObjectID(), models.GenerateObjectID()
+	usecase := &fakeBookmarkUsecase{bookmark: models.Bookmark{
+		ID:          models.GenerateObjectID(),
+		UserID:      "owner",
+		Collections: []models.Collection{collection},
+		Posts: []models.Post{
+			{ID: kept, CollectionID: collection.ID, Name: "Lost wallet", AddedAt: &addedAt, SnapshotAt: &snapshotAt, Note: "call", Tags: []string{"campus"}, Availability: models.PostAvailable},
+			{ID: deleted, CollectionID: collection.ID, Availability: models.PostDeleted},
+		},
+	}}
+
+	bookmark, err := New(usecase, nil).FetchByUserId(context.Background(), &ps.BookmarkUserID{UserId: "owner"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, bookmark.UserId, "owner")
+	assert.Equal(t, len(bookmark.Collections), 1)
+	assert.Equal(t, bookmark.Collections[0].Id, collection.ID.Hex())
+	assert.Equal(t, bookmark.Collections[0].IsDefault, true)
+
+	assert.Equal(t, len(bookmark.Posts), 2)
+	post := bookmark.Posts[0]
+	assert.Equal(t, post.Id, kept.Hex())
+	assert.Equal(t, post.CollectionId, collection.ID.Hex())
+	assert.Equal(t, post.Name, "Lost wallet")
+	assert.Equal(t, post.Note, "call")
+	assert.Equal(t, post.Tags, []string{"campus"})
+	assert.Equal(t, post.AddedAt.AsTime(), addedAt)
+	assert.Equal(t, post.SnapshotAt.AsTime(), snapshotAt)
+	assert.Equal(t, post.Availability, "ok")
+	assert.Equal(t, bookmark.Posts[1].Availability, "deleted")
+	assert.Equal(t, bookmark.Posts[1].AddedAt, nil)
+
+	usecase.opStatus, usecase.err = status.BookmarkNotExist, errors.New("document not matched")
+	_, err = New(usecase, nil).FetchByUserId(context.Background(), &ps.BookmarkUserID{UserId: "nobody"})
+	assert.Equal(t, grpcStatus.Code(err), codes.NotFound)
+}
+
+func TestWritesForwardTheCollection(t *testing.T) {
+
+	usecase := &fakeBookmarkUsecase{opStatus: status.BookmarkPostSuccess}
+	server := New(usecase, nil)
+	collectionID, postID := models.GenerateObjectID().Hex(), models.GenerateObjectID().Hex()
+	request := &ps.BookmarkPostsRequest{UserId: "owner", CollectionId: collectionID, PostIds: []string{postID}}
+
+	response, err := server.AddPost(context.Background(), request)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, response.Message, "success")
+	assert.Equal(t, *usecase.added, requests.AddPostBookmarkRequest{UserID: "owner", CollectionID: collectionID, Posts: []requests.Post{{ID: postID}}})
+
+	_, err = server.RevokePost(context.Background(), request)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, *usecase.revoked, requests.DeleteAttachedPostRequest{UserID: "owner", CollectionID: collectionID, Posts: []requests.Post{{ID: postID}}})
+
+	//usecase failures come back as gRPC codes
+	for opStatus, code := range map[status.OperationStatus]codes.Code{
+		status.BookmarkCollectionNotExist: codes.NotFound,
+		status.OperationForbidden:         codes.PermissionDenied,
+		status.OperationUnauthorized:      codes.PermissionDenied,
+		status.BookmarkDuplicationOccurs:  codes.AlreadyExists,
+		status.BookmarkPostFailed:         codes.Internal,
+	} {
+		usecase.opStatus, usecase.err = opStatus, errors.New("failed")
+		_, err = server.AddPost(context.Background(), request)
+		assert.Equal(t, grpcStatus.Code(err), code)
+		_, err = server.RevokePost(context.Background(), request)
+		assert.Equal(t, grpcStatus.Code(err), code)
+	}
+}
+
+type authConfig map[string]string
+
+func (c authConfig) GetAuthConfig() map[string]string {
+	return c
+}
+
+// intercept run the interceptor around a handler returning the caller it was handed
+func intercept(server *BookmarkServiceServer, ctx context.Context) (*middleware.AuthenticatedRequest, error) {
+	caller, err := server.AuthenticateInterceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		authenticated, _ := middleware.Authenticated(ctx)
+		return authenticated, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return caller.(*middleware.AuthenticatedRequest), nil
+}
+
+func TestAuthenticateInterceptorVerifiesTokens(t *testing.T) {
+
+	authenticator, err := middleware.NewAuthenticator(authConfig{"JWT_SECRET": "secret", "JWT_AUDIENCE": "bookmarks"})
+	assert.Equal(t, err, nil)
+	server := New(&fakeBookmarkUsecase{}, authenticator)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":  "user-1",
+		"role": "user",
+		"aud":  "bookmarks",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("secret"))
+	assert.Equal(t, err, nil)
+
+	caller, err := intercept(server, metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token)))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, caller.UserID, "user-1")
+	assert.Equal(t, caller.Role, "user")
+
+	//header metadata means nothing in jwt mode
+	for _, md := range []metadata.MD{
+		metadata.Pairs(),
+		metadata.Pairs("authorization", "Bearer "+token+"x"),
+		metadata.Pairs("x-user-id", "admin", "x-user-role", "admin"),
+	} {
+		_, err = intercept(server, metadata.NewIncomingContext(context.Background(), md))
+		assert.Equal(t, grpcStatus.Code(err), codes.Unauthenticated)
+	}
+}
+
+func TestAuthenticateInterceptorTrustsOnlyGateways(t *testing.T) {
+
+	authenticator, err := middleware.NewAuthenticator(authConfig{"MODE": "header", "TRUSTED_GATEWAYS": "10.0.0.0/8"})
+	assert.Equal(t, err, nil)
+	server := New(&fakeBookmarkUsecase{}, authenticator)
+
+	from := func(ip string, md metadata.MD) context.Context {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 41000}})
+		return metadata.NewIncomingContext(ctx, md)
+	}
+	headers := metadata.Pairs("x-user-id", "user-1", "x-user-role", "user", "x-user-permission", "bookmark:read")
+
+	caller, err := intercept(server, from("10.1.2.3", headers))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, *caller, middleware.AuthenticatedRequest{UserID: "user-1", Role: "user", Permissions: "bookmark:read"})
+
+	_, err = intercept(server, from("192.168.1.10", headers))
+	assert.Equal(t, grpcStatus.Code(err), codes.PermissionDenied)
+
+	_, err = intercept(server, from("10.1.2.3", metadata.Pairs("x-user-role", "user")))
+	assert.Equal(t, grpcStatus.Code(err), codes.Unauthenticated)
+}
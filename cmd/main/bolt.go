@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"golek_bookmark_service/pkg/database"
+	"log"
+	"os"
+)
+
+// runBoltCommand handle "backup <file>" and "compact" for the bolt driver
+func runBoltCommand(db *database.BoltDatabase, args []string) {
+
+	switch args[0] {
+	case "backup":
+		if len(args) < 2 {
+			fmt.Println("usage: backup <file>")
+			os.Exit(2)
+		}
+		size, err := db.Backup(args[1])
+		if err != nil {
+			log.Fatalln("Backup:", err)
+		}
+		fmt.Printf("backed up %s to %s (%d bytes)\n", db.DbPath, args[1], size)
+
+	case "compact":
+		//the file lock taken by Prepare already guarantees the service isn't running
+		before, after, err := db.Compact()
+		if err != nil {
+			log.Fatalln("Compact:", err)
+		}
+		fmt.Printf("compacted %s from %d to %d bytes\n", db.DbPath, before, after)
+	}
+}
@@ -1,14 +1,22 @@
 package main
 
 import (
+	"context"
+	"expvar"
 	"github.com/gin-gonic/gin"
 	"golek_bookmark_service/cmd/grpc_client"
+	"golek_bookmark_service/cmd/grpc_server"
 	"golek_bookmark_service/pkg/config"
-	"golek_bookmark_service/pkg/database"
-	"golek_bookmark_service/pkg/database/migrations"
+	"golek_bookmark_service/pkg/contracts"
+	"golek_bookmark_service/pkg/events"
 	"golek_bookmark_service/pkg/http/controllers"
-	"golek_bookmark_service/pkg/repositories"
+	"golek_bookmark_service/pkg/http/middleware"
+	"golek_bookmark_service/pkg/policy"
 	"golek_bookmark_service/pkg/usecase"
+	"golek_bookmark_service/pkg/workers"
+	"os"
+	"strconv"
+	"time"
 )
 
 func main() {
@@ -18,31 +26,124 @@ func main() {
 	//Create Config Instance
 	cfg := config.New(".env")
 
-	//Connecting Databases
-	db := database.New(cfg)
-	db.Prepare()
-
-	//Migrations
-	mg := migrations.New(db)
-	mg.MigrateSettings()
-
 	//Setup Bookmarks
 	//Repo
-	bookmarkRepo := repositories.NewBookmarkDBRepository(
-		db.GetConnection(),
-		db.GetCollection(cfg.GetDBConfig()["COLLECTION_BOOKMARKS"]),
-	)
+	bookmarkRepo := newBookmarkRepository(cfg, os.Args[1:])
+	if bookmarkRepo == nil {
+		return
+	}
 
 	//Connect to Course Service via GRPC
-	grpcPostService := grpc_client.New(cfg)
-	_, err := grpcPostService.Dial()
+	grpcPostService, err := grpc_client.New(cfg)
+	if err != nil {
+		panic(err.Error())
+	}
+	//popular posts are looked up once for every user who saved them
+	grpcPostService, err = grpc_client.Cached(cfg, grpcPostService)
 	if err != nil {
 		panic(err.Error())
 	}
+	_, err = grpcPostService.Dial()
+	if err != nil {
+		panic(err.Error())
+	}
+	//cache hits and misses are served with the other runtime metrics on /debug/vars
+	if cache, ok := grpcPostService.(*grpc_client.PostCache); ok {
+		expvar.Publish("post_cache", expvar.Func(func() interface{} { return cache.Stats() }))
+	}
+
+	//Purge soft deleted bookmarks in the background
+	if retention := cfg.GetAppConfig()["TRASH_RETENTION"]; retention != "" {
+		retentionWindow, err := time.ParseDuration(retention)
+		if err != nil {
+			panic(err)
+		}
+
+		interval := time.Hour
+		if i := cfg.GetAppConfig()["TRASH_PURGE_INTERVAL"]; i != "" {
+			interval, err = time.ParseDuration(i)
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		go workers.NewTrashPurger(bookmarkRepo, retentionWindow, interval).Run(context.Background())
+	}
+
+	//Prune posts deleted from the post service as their events come in
+	transport, err := events.New(cfg)
+	if err != nil {
+		panic(err)
+	}
+	if transport != nil {
+		consumer := workers.NewPostDeletedConsumer(bookmarkRepo, transport)
+		if cache, ok := grpcPostService.(workers.PostInvalidator); ok {
+			consumer.Cache = cache
+		}
+
+		go func() {
+			if err := consumer.Run(context.Background()); err != nil {
+				panic(err)
+			}
+		}()
+	}
 
-	bookmarkUsecase := usecase.NewBookmarkUsecase(bookmarkRepo, grpcPostService)
+	//Publish the bookmark events waiting in the outbox. every change writes one, so the relay always runs:
+	//only NATS carries them to other services, without it they are discarded rather than kept forever
+	var relayTransport contracts.EventTransport = events.DiscardTransport{}
+	if _, ok := transport.(*events.NATSTransport); ok {
+		relayTransport = transport
+	}
+	interval := time.Second
+	if i := cfg.GetAppConfig()["OUTBOX_RELAY_INTERVAL"]; i != "" {
+		interval, err = time.ParseDuration(i)
+		if err != nil {
+			panic(err)
+		}
+	}
+	batchSize := int64(100)
+	if b := cfg.GetAppConfig()["OUTBOX_BATCH_SIZE"]; b != "" {
+		batchSize, err = strconv.ParseInt(b, 10, 64)
+		if err != nil {
+			panic(err)
+		}
+	}
+	go workers.NewOutboxRelay(bookmarkRepo, relayTransport, interval, batchSize).Run(context.Background())
+
+	//Authenticate callers with signed tokens, or trust a gateway's headers
+	authenticator, err := middleware.NewAuthenticator(cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	//Map roles to the permissions they're granted
+	rolePolicy, err := policy.Parse(cfg.GetAuthConfig()["ROLE_PERMISSIONS"])
+	if err != nil {
+		panic(err)
+	}
+
+	//Show, hide or prune saved posts the post service no longer knows
+	missingPosts, err := usecase.ParseMissingPosts(cfg.GetAppConfig()["MISSING_POSTS"])
+	if err != nil {
+		panic(err)
+	}
+
+	bookmarkUsecase := usecase.NewBookmarkUsecase(bookmarkRepo, grpcPostService, rolePolicy, missingPosts)
 	//Setup Delivery/Controller
-	controllers.SetupHandler(engine, &bookmarkUsecase)
+	controllers.SetupHandler(engine, &bookmarkUsecase, authenticator.Middleware())
+	engine.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+
+	//Serve BookmarkService over gRPC next to the HTTP API
+	grpcPort := cfg.GetAppConfig()["GRPC_PORT"]
+	if grpcPort == "" {
+		grpcPort = "9090"
+	}
+	go func() {
+		err := grpc_server.New(bookmarkUsecase, authenticator).Serve(grpcPort)
+		if err != nil {
+			panic(err)
+		}
+	}()
 
 	if port := cfg.GetAppConfig()["PORT"]; port == "" {
 		err := engine.Run(":8080")
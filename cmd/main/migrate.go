@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"golek_bookmark_service/pkg/database/migrations"
+	"log"
+	"os"
+	"strconv"
+)
+
+// runMigrateCommand handle "migrate up", "migrate down [steps]" and "migrate status"
+func runMigrateCommand(migrator migrations.Runner, args []string) {
+
+	if len(args) == 0 {
+		fmt.Println("usage: migrate up|down [steps]|status")
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			log.Fatalln("Migrations:", err)
+		}
+		for _, m := range applied {
+			fmt.Printf("applied  %d_%s\n", m.Version, m.Name)
+		}
+		fmt.Printf("%d migrations applied\n", len(applied))
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				log.Fatalln("Migrations: steps must be a positive number")
+			}
+			steps = n
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalln("Migrations:", err)
+		}
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalln("Migrations:", err)
+		}
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%-4d %-28s %s\n", s.Version, s.Name, appliedAt)
+		}
+
+	default:
+		fmt.Println("usage: migrate up|down [steps]|status")
+		os.Exit(2)
+	}
+}
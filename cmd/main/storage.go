@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"golek_bookmark_service/pkg/contracts"
+	"golek_bookmark_service/pkg/database"
+	"golek_bookmark_service/pkg/database/migrations"
+	"golek_bookmark_service/pkg/repositories"
+	"log"
+)
+
+// newBookmarkRepository build the repository of the configured DB_DRIVER and bring its schema up to date.
+// it returns nil once a "migrate", "backup" or "compact" command has been handled, the process should exit then
+func newBookmarkRepository(cfg contracts.Config, args []string) contracts.BookmarksRepository {
+
+	command := ""
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	driver := cfg.GetDBConfig()["DRIVER"]
+	if (command == "backup" || command == "compact") && driver != "bolt" {
+		log.Fatalf("Storage: %s is only supported by the bolt driver", command)
+	}
+
+	switch driver {
+	case "memory":
+		if command == "migrate" {
+			log.Println("Migrations: the memory driver has no schema to migrate")
+			return nil
+		}
+		log.Println("Storage: using the in-memory repository, bookmarks are lost on restart")
+		return repositories.NewBookmarkMemoryRepository()
+
+	case "bolt":
+		db := database.NewBolt(cfg)
+		db.Prepare()
+
+		switch command {
+		case "migrate":
+			log.Println("Migrations: the bolt driver has no schema to migrate")
+			return nil
+		case "backup", "compact":
+			runBoltCommand(db, args)
+			return nil
+		}
+
+		return repositories.NewBookmarkBoltRepository(db.GetConnection())
+
+	case "postgres":
+		db := database.NewPostgres(cfg)
+		db.Prepare()
+
+		if command == "migrate" {
+			runMigrateCommand(migrations.NewPostgres(db), args[1:])
+			return nil
+		}
+
+		if cfg.GetDBConfig()["MIGRATE_ON_BOOT"] != "false" {
+			applied, err := migrations.NewPostgres(db).Up(context.Background())
+			if err != nil {
+				panic(err)
+			}
+			log.Printf("Migrations: %d applied", len(applied))
+		}
+
+		return repositories.NewBookmarkPostgresRepository(db.GetConnection())
+
+	case "", "mongo":
+		//Connecting Databases
+		db := database.New(cfg)
+		db.Prepare()
+
+		//Migrations
+		//run "main migrate up|down|status" to manage them by hand
+		if command == "migrate" {
+			runMigrateCommand(migrations.New(db), args[1:])
+			return nil
+		}
+
+		if cfg.GetDBConfig()["MIGRATE_ON_BOOT"] != "false" {
+			applied, err := migrations.New(db).Up(context.Background())
+			if err != nil {
+				panic(err)
+			}
+			log.Printf("Migrations: %d applied", len(applied))
+		}
+
+		return repositories.NewBookmarkDBRepository(
+			db.GetConnection(),
+			db.GetCollection(cfg.GetDBConfig()["COLLECTION_BOOKMARKS"]),
+		)
+
+	default:
+		log.Fatalf("Storage: unknown DB_DRIVER %q", driver)
+		return nil
+	}
+}
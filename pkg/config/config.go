@@ -10,6 +10,7 @@ import (
 type Config struct {
 	App      map[string]string
 	Database map[string]string
+	Auth     map[string]string
 }
 
 func New(envpath string) contracts.Config {
@@ -24,16 +25,81 @@ func New(envpath string) contracts.Config {
 
 	c.App = map[string]string{}
 	c.App["PORT"] = os.Getenv("APP_PORT")
+	c.App["GRPC_PORT"] = os.Getenv("APP_GRPC_PORT")
 	c.App["RPC_TARGET_HOST"] = os.Getenv("RPC_TARGET_HOST")
 	c.App["RPC_TARGET_PORT"] = os.Getenv("RPC_TARGET_PORT")
+	//post service calls: per attempt timeout and retry backoff are Go durations, e.g. 2s and 100ms;
+	//the breaker opens after RPC_BREAKER_THRESHOLD failed calls in a row, 0 disables it
+	c.App["RPC_TIMEOUT"] = os.Getenv("RPC_TIMEOUT")
+	c.App["RPC_MAX_RETRIES"] = os.Getenv("RPC_MAX_RETRIES")
+	c.App["RPC_RETRY_BACKOFF"] = os.Getenv("RPC_RETRY_BACKOFF")
+	c.App["RPC_BREAKER_THRESHOLD"] = os.Getenv("RPC_BREAKER_THRESHOLD")
+	c.App["RPC_BREAKER_COOLDOWN"] = os.Getenv("RPC_BREAKER_COOLDOWN")
+	//"tls" (default) verifies the post service, "mtls" also presents the client cert and key,
+	//"plaintext" turns encryption off. files are checked on every handshake, rotated ones apply to new connections
+	c.App["RPC_TLS_MODE"] = os.Getenv("RPC_TLS_MODE")
+	//PEM files, an empty CA trusts the system roots
+	c.App["RPC_TLS_CA_FILE"] = os.Getenv("RPC_TLS_CA_FILE")
+	c.App["RPC_TLS_CERT_FILE"] = os.Getenv("RPC_TLS_CERT_FILE")
+	c.App["RPC_TLS_KEY_FILE"] = os.Getenv("RPC_TLS_KEY_FILE")
+	//overrides the name the server certificate is checked against, defaults to RPC_TARGET_HOST
+	c.App["RPC_TLS_SERVER_NAME"] = os.Getenv("RPC_TLS_SERVER_NAME")
+	//post details are cached for RPC_CACHE_TTL (default 5m, 0 disables the cache), at most RPC_CACHE_SIZE posts
+	c.App["RPC_CACHE_TTL"] = os.Getenv("RPC_CACHE_TTL")
+	c.App["RPC_CACHE_SIZE"] = os.Getenv("RPC_CACHE_SIZE")
+	//posts missing from an answer are only remembered for RPC_CACHE_MISS_TTL (default 30s, 0 doesn't remember them)
+	c.App["RPC_CACHE_MISS_TTL"] = os.Getenv("RPC_CACHE_MISS_TTL")
+	//a lookup shared by concurrent callers runs until RPC_CACHE_LOOKUP_TIMEOUT (default 10s), whoever of them gives up
+	c.App["RPC_CACHE_LOOKUP_TIMEOUT"] = os.Getenv("RPC_CACHE_LOOKUP_TIMEOUT")
+	//lookups are sent RPC_CHUNK_SIZE post IDs at a time (default 100, 0 sends them at once), RPC_PARALLELISM chunks concurrently
+	c.App["RPC_CHUNK_SIZE"] = os.Getenv("RPC_CHUNK_SIZE")
+	c.App["RPC_PARALLELISM"] = os.Getenv("RPC_PARALLELISM")
+	//Go durations, e.g. 720h; purging is disabled while the retention is empty
+	c.App["TRASH_RETENTION"] = os.Getenv("BOOKMARK_TRASH_RETENTION")
+	c.App["TRASH_PURGE_INTERVAL"] = os.Getenv("BOOKMARK_TRASH_PURGE_INTERVAL")
+	//show (default), hide or prune saved posts the post service reports as deleted
+	c.App["MISSING_POSTS"] = os.Getenv("BOOKMARK_MISSING_POSTS")
+	//nats or inprocess, events are off while empty; instances sharing EVENTS_QUEUE_GROUP split the messages.
+	//nats consumes through JetStream durable consumers, named after EVENTS_QUEUE_GROUP, of the EVENTS_NATS_STREAM stream
+	c.App["EVENTS_TRANSPORT"] = os.Getenv("EVENTS_TRANSPORT")
+	c.App["EVENTS_NATS_URL"] = os.Getenv("EVENTS_NATS_URL")
+	c.App["EVENTS_NATS_STREAM"] = os.Getenv("EVENTS_NATS_STREAM")
+	c.App["EVENTS_QUEUE_GROUP"] = os.Getenv("EVENTS_QUEUE_GROUP")
+	//the outbox relay publishes up to OUTBOX_BATCH_SIZE events at a time every OUTBOX_RELAY_INTERVAL (default 1s),
+	//over nats; with any other transport, or none, it discards them
+	c.App["OUTBOX_RELAY_INTERVAL"] = os.Getenv("OUTBOX_RELAY_INTERVAL")
+	c.App["OUTBOX_BATCH_SIZE"] = os.Getenv("OUTBOX_BATCH_SIZE")
 
 	c.Database = map[string]string{}
+	//storage backend, "mongo" (default), "postgres", "bolt" or "memory"
+	c.Database["DRIVER"] = os.Getenv("DB_DRIVER")
+	//bolt only, the database file, defaults to bookmarks.db
+	c.Database["PATH"] = os.Getenv("DB_PATH")
 	c.Database["USERNAME"] = os.Getenv("DB_USERNAME")
 	c.Database["PASSWORD"] = os.Getenv("DB_PASSWORD")
 	c.Database["HOST"] = os.Getenv("DB_HOST")
 	c.Database["PORT"] = os.Getenv("DB_PORT_IN")
 	c.Database["NAME"] = os.Getenv("DB_NAME")
 	c.Database["COLLECTION_BOOKMARKS"] = os.Getenv("DB_COLLECTION_BOOKMARKS")
+	//postgres only, defaults to "disable"
+	c.Database["SSL_MODE"] = os.Getenv("DB_SSL_MODE")
+	//pending migrations are applied at boot unless set to "false"
+	c.Database["MIGRATE_ON_BOOT"] = os.Getenv("DB_MIGRATE_ON_BOOT")
+
+	c.Auth = map[string]string{}
+	//"jwt" (default) verifies bearer tokens, "header" trusts the X-User-* headers set by a gateway
+	c.Auth["MODE"] = os.Getenv("AUTH_MODE")
+	//header mode only and required there, comma separated CIDRs allowed to call the service directly
+	c.Auth["TRUSTED_GATEWAYS"] = os.Getenv("AUTH_TRUSTED_GATEWAYS")
+	//HS256 (default) with JWT_SECRET or RS256 with the keys of JWKS_FILE
+	c.Auth["JWT_ALGORITHM"] = os.Getenv("AUTH_JWT_ALGORITHM")
+	c.Auth["JWT_SECRET"] = os.Getenv("AUTH_JWT_SECRET")
+	c.Auth["JWKS_FILE"] = os.Getenv("AUTH_JWKS_FILE")
+	c.Auth["JWT_AUDIENCE"] = os.Getenv("AUTH_JWT_AUDIENCE")
+	c.Auth["JWT_ISSUER"] = os.Getenv("AUTH_JWT_ISSUER")
+	//role grants as "role=perm,perm;role=perm", e.g. "user=bookmark:read,bookmark:write;admin=bookmark:admin",
+	//bookmark:admin implies read and write. empty keeps the built-in user and admin roles
+	c.Auth["ROLE_PERMISSIONS"] = os.Getenv("AUTH_ROLE_PERMISSIONS")
 
 	return &c
 }
@@ -45,3 +111,7 @@ func (c *Config) GetDBConfig() map[string]string {
 func (c Config) GetAppConfig() map[string]string {
 	return c.App
 }
+
+func (c Config) GetAuthConfig() map[string]string {
+	return c.Auth
+}
@@ -6,39 +6,97 @@ import (
 	"golek_bookmark_service/pkg/contracts/status"
 	"golek_bookmark_service/pkg/http/requests"
 	"golek_bookmark_service/pkg/models"
+	"time"
 )
 
 type BookmarksRepository interface {
-	// Fetch Fetch all data from database;
+	// Fetch Fetch the active bookmarks matching 'filter' in its sort order;
 	// 'exclude' param specify which model fields you want to skip/unselect;
-	// 'limit' and 'skip param are used to perform some kind of pagination
-	Fetch(ctx context.Context, exclude []string, limit int64, skip int64) (bookmarks []models.Bookmark, opStatus status.OperationStatus, err error)
+	// at most 'limit' bookmarks coming after the 'after' cursor are returned, 'total' counts every match regardless of paging
+	Fetch(ctx context.Context, filter models.BookmarkFilter, exclude []string, limit int64, after *models.Cursor) (bookmarks []models.Bookmark, total int64, opStatus status.OperationStatus, err error)
 	// FetchById fetch data by id;
 	// 'exclude' param specify which model fields you want to skip/unselect;
+	// an id that isn't a valid object id doesn't exist
 	FetchById(ctx context.Context, id string, exclude []string) (bookmark models.Bookmark, opStatus status.OperationStatus, err error)
 	FetchByUserId(ctx context.Context, userID string, exclude []string) (bookmark models.Bookmark, opStatus status.OperationStatus, err error)
+	// FetchSavedPosts fetch only the items among 'postIDs' saved in the user's bookmark, one per collection holding them
+	FetchSavedPosts(ctx context.Context, userID string, postIDs []string) (posts []models.Post, opStatus status.OperationStatus, err error)
+	// SearchPosts fetch the posts saved in the user's bookmark whose name snapshot, note or tags hold every word of 'query',
+	// in the order they were saved
+	SearchPosts(ctx context.Context, userID string, query string) (posts []models.Post, opStatus status.OperationStatus, err error)
+	// RefreshSnapshots overwrite the name and image snapshot of every saved copy of the given posts, in every bookmark
+	RefreshSnapshots(ctx context.Context, snapshots []models.Post) (opStatus status.OperationStatus, err error)
+	// PrunePosts detach the posts from every bookmark, trashed ones included, once they are gone from the post service.
+	// active bookmarks report the posts they lost as revoked
+	PrunePosts(ctx context.Context, postIDs []string) (opStatus status.OperationStatus, err error)
+	// FetchOutbox fetch at most 'limit' events waiting to be published, in the order they were written.
+	// Create, AddPost, RevokePost, PrunePosts, DeleteCollection and Delete write their event in the same transaction as their change
+	FetchOutbox(ctx context.Context, limit int64) (outbox []models.OutboxEvent, opStatus status.OperationStatus, err error)
+	// RemoveOutboxEvents drop published events from the outbox
+	RemoveOutboxEvents(ctx context.Context, eventIDs []string) (opStatus status.OperationStatus, err error)
+	// CountByPosts count, for every post id, how many users saved it at least once
+	CountByPosts(ctx context.Context, postIDs []string) (counts map[string]int64, opStatus status.OperationStatus, err error)
+	// FetchUsersByPost fetch the ids of users who saved the post, 'total' counts every matching user regardless of paging
+	FetchUsersByPost(ctx context.Context, postID string, limit int64, skip int64) (userIDs []string, total int64, opStatus status.OperationStatus, err error)
 	Create(ctx context.Context, bookmark *models.Bookmark) (bookmarkID primitive.ObjectID, opStatus status.OperationStatus, err error)
 	Update(ctx context.Context, bookmark *models.Bookmark, bookmarkID string) (opStatus status.OperationStatus, err error)
-	AddPost(ctx context.Context, userID string, coursesID []string) (opStatus status.OperationStatus, err error)
+	// Delete soft delete the bookmark by setting its deleted_at, it stays in the trash until restored or purged
 	Delete(ctx context.Context, bookmarkID string) (opStatus status.OperationStatus, err error)
-	RevokePost(ctx context.Context, userID string, coursesID []string) (opStatus status.OperationStatus, err error)
+	// FetchTrashed fetch soft deleted bookmarks of a user, latest deleted first
+	FetchTrashed(ctx context.Context, userID string, limit int64, skip int64) (bookmarks []models.Bookmark, opStatus status.OperationStatus, err error)
+	// FetchTrashedById fetch one soft deleted bookmark of a user, bookmarks of others or not in the trash don't exist
+	FetchTrashedById(ctx context.Context, userID string, bookmarkID string) (bookmark models.Bookmark, opStatus status.OperationStatus, err error)
+	Restore(ctx context.Context, bookmarkID string) (opStatus status.OperationStatus, err error)
+	// Purge hard delete every bookmark soft deleted before 'deletedBefore'
+	Purge(ctx context.Context, deletedBefore time.Time) (purged int64, opStatus status.OperationStatus, err error)
+	// AddPost attach posts to the user's collection, 'collectionID' must point to an existing collection
+	AddPost(ctx context.Context, userID string, collectionID string, postIDs []string) (opStatus status.OperationStatus, err error)
+	// UpdatePost edit note and tags of a saved post, a nil 'note' or 'tags' leaves the field untouched;
+	// an empty 'collectionID' edits the post in every collection it is saved in
+	UpdatePost(ctx context.Context, userID string, collectionID string, postID string, note *string, tags []string) (opStatus status.OperationStatus, err error)
+	// RevokePost detach posts from the user's collection, an empty 'collectionID' revokes them from every collection
+	RevokePost(ctx context.Context, userID string, collectionID string, postIDs []string) (opStatus status.OperationStatus, err error)
+	CreateCollection(ctx context.Context, userID string, collection *models.Collection) (opStatus status.OperationStatus, err error)
+	RenameCollection(ctx context.Context, userID string, collectionID string, name string) (opStatus status.OperationStatus, err error)
+	// DeleteCollection remove the collection along with every post saved in it, the posts are reported revoked from it
+	DeleteCollection(ctx context.Context, userID string, collectionID string) (opStatus status.OperationStatus, err error)
 	GenerateModelID() primitive.ObjectID
 	GenerateObjectIDFromString(id string) primitive.ObjectID
 }
 
 type BookmarkUsecase interface {
-	// Fetch Fetch all data from database;
+	// Fetch Fetch bookmarks matching 'filter' for admins, anybody else only ever gets their own bookmark;
 	// 'exclude' param specify which model fields you want to skip/unselect;
-	// 'limit' and 'skip param are used to perform some kind of pagination
-	Fetch(ctx context.Context, exclude []string, limit int64, skip int64) (bookmarks []models.Bookmark, opStatus status.OperationStatus, err error)
+	// 'next' continues the listing and is nil on the last page, 'total' counts every match regardless of paging
+	Fetch(ctx context.Context, filter models.BookmarkFilter, exclude []string, page models.CursorPage) (bookmarks []models.Bookmark, total int64, next *models.Cursor, opStatus status.OperationStatus, err error)
 
 	// FetchById fetch data by id;
 	// 'exclude' param specify which model fields you want to skip/unselect;
 	FetchById(ctx context.Context, id string, exclude []string) (bookmark models.Bookmark, opStatus status.OperationStatus, err error)
 
-	FetchByUserId(ctx context.Context, userID string, exclude []string) (bookmark models.Bookmark, opStatus status.OperationStatus, err error)
+	// FetchByUserId fetch the user's bookmark with one page of its saved posts matching 'filter' in its sort order;
+	// 'next' continues the posts and is nil on the last page
+	FetchByUserId(ctx context.Context, userID string, exclude []string, filter models.PostFilter, page models.CursorPage) (bookmark models.Bookmark, next *models.Cursor, opStatus status.OperationStatus, err error)
+	// SearchPosts fetch one page of the user's saved posts whose name, note or tags hold every word of 'query',
+	// oldest saved first; 'next' continues the results and is nil on the last page
+	SearchPosts(ctx context.Context, userID string, query string, page models.CursorPage) (posts []models.Post, next *models.Cursor, opStatus status.OperationStatus, err error)
 	Create(ctx context.Context, request *requests.CreateBookmarkRequest) (bookmark models.Bookmark, opStatus status.OperationStatus, err error)
 	AddPost(ctx context.Context, request *requests.AddPostBookmarkRequest, userID string) (opStatus status.OperationStatus, err error)
 	RevokePost(ctx context.Context, request *requests.DeleteAttachedPostRequest, userID string) (opStatus status.OperationStatus, err error)
+	UpdatePost(ctx context.Context, request *requests.UpdatePostRequest, userID string, postID string) (opStatus status.OperationStatus, err error)
+	// IsBookmarked report for every post id whether the user saved it in any collection and when
+	IsBookmarked(ctx context.Context, userID string, postIDs []string) (statuses map[string]models.BookmarkStatus, opStatus status.OperationStatus, err error)
 	Delete(ctx context.Context, bookmarkID string) (opStatus status.OperationStatus, err error)
+	CountBookmarks(ctx context.Context, postIDs []string) (counts map[string]int64, opStatus status.OperationStatus, err error)
+	// FetchBookmarkingUsers list who saved a post, only for the author of the post or a caller holding bookmark:admin.
+	// the author is the user_id the post service sends, without it authors get BookmarkPostOwnerUnknown
+	FetchBookmarkingUsers(ctx context.Context, postID string, limit int64, skip int64) (userIDs []string, total int64, opStatus status.OperationStatus, err error)
+	// FetchTrash fetch soft deleted bookmarks owned by the authenticated user
+	FetchTrash(ctx context.Context, limit int64, skip int64) (bookmarks []models.Bookmark, opStatus status.OperationStatus, err error)
+	Restore(ctx context.Context, bookmarkID string) (opStatus status.OperationStatus, err error)
+
+	FetchCollections(ctx context.Context, userID string) (collections []models.Collection, opStatus status.OperationStatus, err error)
+	CreateCollection(ctx context.Context, request *requests.CreateCollectionRequest, userID string) (collection models.Collection, opStatus status.OperationStatus, err error)
+	RenameCollection(ctx context.Context, request *requests.RenameCollectionRequest, userID string, collectionID string) (opStatus status.OperationStatus, err error)
+	DeleteCollection(ctx context.Context, userID string, collectionID string) (opStatus status.OperationStatus, err error)
 }
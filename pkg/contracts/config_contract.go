@@ -8,7 +8,12 @@ type AppConfig interface {
 	GetAppConfig() map[string]string
 }
 
+type AuthConfig interface {
+	GetAuthConfig() map[string]string
+}
+
 type Config interface {
 	AppConfig
 	DBConfig
+	AuthConfig
 }
@@ -1,6 +1,10 @@
 package contracts
 
-import "go.mongodb.org/mongo-driver/mongo"
+import (
+	"database/sql"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/mongo"
+)
 
 type DBContract interface {
 	DSN() string
@@ -11,3 +15,12 @@ type MongoDBContract interface {
 	GetCollection(collection string) *mongo.Collection
 	DBContract
 }
+
+type SQLDBContract interface {
+	DSN() string
+	GetConnection() *sql.DB
+}
+
+type EmbeddedDBContract interface {
+	GetConnection() *bolt.DB
+}
@@ -0,0 +1,17 @@
+package contracts
+
+import (
+	"context"
+	"io"
+)
+
+// EventHandler handle the payload of one message, what becomes of a message whose handler failed is up to the transport
+type EventHandler func(ctx context.Context, data []byte) error
+
+// EventTransport carry events between services, subjects name the kind of event
+type EventTransport interface {
+	Publish(ctx context.Context, subject string, data []byte) error
+	// Subscribe deliver every message published on 'subject' to 'handler' until the returned subscription is closed
+	Subscribe(subject string, handler EventHandler) (io.Closer, error)
+	Close() error
+}
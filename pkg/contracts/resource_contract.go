@@ -1,6 +1,8 @@
 package contracts
 
+import "golek_bookmark_service/pkg/policy"
+
 type Resource struct {
-	Alias string
-	Name  string
+	Permission policy.Permission
+	Name       string
 }
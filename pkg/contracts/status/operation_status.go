@@ -11,6 +11,10 @@ const (
 	BookmarkUpdateUpserted     OperationStatus = 203
 	BookmarkDeleteFailed       OperationStatus = 300
 	BookmarkDeleteSuccess      OperationStatus = 301
+	BookmarkRestoreFailed      OperationStatus = 302
+	BookmarkRestoreSuccess     OperationStatus = 303
+	BookmarkPurgeFailed        OperationStatus = 304
+	BookmarkPurgeSuccess       OperationStatus = 305
 	OperationUnauthorized      OperationStatus = 500
 	OperationAuthorized        OperationStatus = 501
 	OperationForbidden         OperationStatus = 502
@@ -19,11 +23,30 @@ const (
 	BookmarkPostFailed         OperationStatus = 602
 	BookmarkPostSuccess        OperationStatus = 603
 	BookmarkMatchedNotModified OperationStatus = 604
+	BookmarkPostOwnerUnknown   OperationStatus = 605
 	OperationSuccess           OperationStatus = 700
 	BookmarkDeletePostFailed   OperationStatus = 800
 	BookmarkDeletePostSuccess  OperationStatus = 801
 	BookmarkDuplicationOccurs  OperationStatus = 802
 	BookmarkPostRevokeFailed   OperationStatus = 803
+	BookmarkPostNotExist       OperationStatus = 804
+	BookmarkPostUpdateSuccess  OperationStatus = 805
+	BookmarkPostUpdateFailed   OperationStatus = 806
+	BookmarkSnapshotSuccess    OperationStatus = 807
+	BookmarkSnapshotFailed     OperationStatus = 808
+
+	BookmarkCollectionCreateSuccess OperationStatus = 900
+	BookmarkCollectionCreateFailed  OperationStatus = 901
+	BookmarkCollectionUpdateSuccess OperationStatus = 902
+	BookmarkCollectionUpdateFailed  OperationStatus = 903
+	BookmarkCollectionDeleteSuccess OperationStatus = 904
+	BookmarkCollectionDeleteFailed  OperationStatus = 905
+	BookmarkCollectionNotExist      OperationStatus = 906
+	BookmarkCollectionDuplicated    OperationStatus = 907
+	BookmarkCollectionImmutable     OperationStatus = 908
+
+	BookmarkOutboxSuccess OperationStatus = 1000
+	BookmarkOutboxFailed  OperationStatus = 1001
 )
 
 func Is(status OperationStatus, target OperationStatus) bool {
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"fmt"
+	"golek_bookmark_service/pkg/contracts"
+	"log"
+	"os"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltDatabase is a single file embedded database, only one process can open the file at a time
+type BoltDatabase struct {
+	DbPath     string
+	connection *bolt.DB
+	config     contracts.DBConfig
+}
+
+func NewBolt(config contracts.DBConfig) *BoltDatabase {
+
+	path := config.GetDBConfig()["PATH"]
+	if path == "" {
+		path = "bookmarks.db"
+	}
+
+	return &BoltDatabase{
+		DbPath: path,
+		config: config,
+	}
+}
+
+func (db *BoltDatabase) Prepare() contracts.EmbeddedDBContract {
+
+	//Singleton Connection
+	if db.connection == nil {
+
+		//don't wait forever when another process holds the file lock
+		connection, err := bolt.Open(db.DbPath, 0600, &bolt.Options{Timeout: 5 * time.Second})
+		if err != nil {
+			panic(fmt.Sprintf("opening %s: %v", db.DbPath, err))
+		}
+
+		db.connection = connection
+		log.Println("Connected to the database file", db.DbPath)
+
+	} else {
+		log.Println("Already Connected to the database file", db.DbPath)
+	}
+
+	return db
+}
+
+func (db *BoltDatabase) GetConnection() *bolt.DB {
+	return db.connection
+}
+
+// Backup write a consistent copy of the database to 'path', it is safe while the service is serving requests
+func (db *BoltDatabase) Backup(path string) (size int64, err error) {
+
+	err = db.connection.View(func(tx *bolt.Tx) error {
+		size = tx.Size()
+		return tx.CopyFile(path, 0600)
+	})
+
+	return size, err
+}
+
+// Compact rewrite the database into a fresh file to give back the space freed by deletes, then swap the files.
+// the connection is closed afterwards, it needs exclusive access so run it while the service is stopped
+func (db *BoltDatabase) Compact() (before int64, after int64, err error) {
+
+	compactPath := db.DbPath + ".compact"
+	_ = os.Remove(compactPath)
+
+	dst, err := bolt.Open(compactPath, 0600, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	err = bolt.Compact(dst, db.connection, 64*1024*1024)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(compactPath)
+		return 0, 0, err
+	}
+
+	if err := db.connection.Close(); err != nil {
+		return 0, 0, err
+	}
+	db.connection = nil
+
+	before, after = fileSize(db.DbPath), fileSize(compactPath)
+	if err := os.Rename(compactPath, db.DbPath); err != nil {
+		return 0, 0, err
+	}
+
+	return before, after, nil
+}
+
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"github.com/go-playground/assert/v2"
+	bolt "go.etcd.io/bbolt"
+	"golek_bookmark_service/pkg/config"
+	"path/filepath"
+	"testing"
+)
+
+func TestBoltBackupAndCompact(t *testing.T) {
+
+	dir := t.TempDir()
+	db := NewBolt(&config.Config{Database: map[string]string{"PATH": filepath.Join(dir, "bookmarks.db")}})
+	db.Prepare()
+
+	//fill then empty a bucket so compaction has space to give back
+	err := db.GetConnection().Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucket([]byte("bookmarks"))
+		if err != nil {
+			return err
+		}
+		for i := 0; i < 2000; i++ {
+			if err := bucket.Put([]byte{byte(i >> 8), byte(i)}, make([]byte, 512)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	assert.Equal(t, err, nil)
+	err = db.GetConnection().Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte("bookmarks"))
+		for i := 1; i < 2000; i++ {
+			if err := bucket.Delete([]byte{byte(i >> 8), byte(i)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	assert.Equal(t, err, nil)
+
+	backupPath := filepath.Join(dir, "backup.db")
+	_, err = db.Backup(backupPath)
+	assert.Equal(t, err, nil)
+
+	backup, err := bolt.Open(backupPath, 0600, nil)
+	assert.Equal(t, err, nil)
+	_ = backup.View(func(tx *bolt.Tx) error {
+		assert.Equal(t, tx.Bucket([]byte("bookmarks")).Stats().KeyN, 1)
+		return nil
+	})
+	_ = backup.Close()
+
+	before, after, err := db.Compact()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, after < before, true)
+	assert.Equal(t, db.GetConnection(), (*bolt.DB)(nil))
+
+	//the compacted file replaced the original and keeps the data
+	reopened := NewBolt(&config.Config{Database: map[string]string{"PATH": db.DbPath}})
+	reopened.Prepare()
+	defer reopened.GetConnection().Close()
+	_ = reopened.GetConnection().View(func(tx *bolt.Tx) error {
+		assert.Equal(t, tx.Bucket([]byte("bookmarks")).Get([]byte{0, 0}), make([]byte, 512))
+		return nil
+	})
+}
@@ -1,11 +1,274 @@
 package migrations
 
-import "golek_bookmark_service/pkg/database"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golek_bookmark_service/pkg/database"
+	"log"
+	"os"
+	"sort"
+	"time"
+)
 
+const (
+	// CollectionSchemaMigrations keeps one document per applied migration
+	CollectionSchemaMigrations = "schema_migrations"
+	// CollectionSchemaMigrationsLock holds the single lock document while migrations run
+	CollectionSchemaMigrationsLock = "schema_migrations_lock"
+	lockID                         = "migrations"
+)
+
+var ErrMigrationLocked = errors.New("migrations are locked by another process")
+
+// Migration is a single ordered schema change, Down may be nil for irreversible migrations
 type Migration struct {
-	DB *database.Database
+	Version int64
+	Name    string
+	Up      func(ctx context.Context, db *mongo.Database, bookmarks *mongo.Collection) error
+	Down    func(ctx context.Context, db *mongo.Database, bookmarks *mongo.Collection) error
+}
+
+// Runner is implemented by the migrator of every storage driver that keeps a schema
+type Runner interface {
+	Up(ctx context.Context) ([]MigrationStatus, error)
+	Down(ctx context.Context, steps int) ([]MigrationStatus, error)
+	Status(ctx context.Context) ([]MigrationStatus, error)
+}
+
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+}
+
+type appliedMigration struct {
+	Version   int64     `bson:"_id"`
+	Name      string    `bson:"name"`
+	AppliedAt time.Time `bson:"applied_at"`
+}
+
+type Migrator struct {
+	DB         *database.Database
+	Migrations []Migration
+	// LockTTL bounds how long a crashed process can keep the lock
+	LockTTL time.Duration
+	// LockWait is how long to wait for another process to release the lock
+	LockWait time.Duration
+	owner    string
+}
+
+func New(db *database.Database) *Migrator {
+
+	hostname, _ := os.Hostname()
+
+	migrations := append([]Migration{}, versions...)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return &Migrator{
+		DB:         db,
+		Migrations: migrations,
+		LockTTL:    10 * time.Minute,
+		LockWait:   time.Minute,
+		owner:      fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().UnixNano()),
+	}
+}
+
+// Up apply every pending migration in version order
+func (m *Migrator) Up(ctx context.Context) (applied []MigrationStatus, err error) {
+
+	err = m.withLock(ctx, func() error {
+
+		done, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.Migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+
+			log.Printf("Migrations: applying %d_%s", migration.Version, migration.Name)
+			if err := migration.Up(ctx, m.DB.GetConnection(), m.bookmarks()); err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+
+			timeNow := time.Now()
+			_, err := m.records().InsertOne(ctx, appliedMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: timeNow,
+			})
+			if err != nil {
+				return err
+			}
+
+			applied = append(applied, MigrationStatus{Version: migration.Version, Name: migration.Name, AppliedAt: &timeNow})
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down revert the latest 'steps' applied migrations, latest first
+func (m *Migrator) Down(ctx context.Context, steps int) (reverted []MigrationStatus, err error) {
+
+	err = m.withLock(ctx, func() error {
+
+		done, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.Migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.Migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+
+			if migration.Down == nil {
+				return fmt.Errorf("migration %d_%s is irreversible", migration.Version, migration.Name)
+			}
+
+			log.Printf("Migrations: reverting %d_%s", migration.Version, migration.Name)
+			if err := migration.Down(ctx, m.DB.GetConnection(), m.bookmarks()); err != nil {
+				return fmt.Errorf("reverting migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+
+			_, err := m.records().DeleteOne(ctx, bson.M{"_id": migration.Version})
+			if err != nil {
+				return err
+			}
+
+			reverted = append(reverted, MigrationStatus{Version: migration.Version, Name: migration.Name})
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status list every known migration along with the time it was applied, if it was
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+
+	done, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0)
+	for _, migration := range m.Migrations {
+		s := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if record, ok := done[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			s.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, s)
+	}
+
+	return statuses, nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int64]appliedMigration, error) {
+
+	records, err := m.records().Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	var results []appliedMigration
+	if err := records.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	done := make(map[int64]appliedMigration)
+	for _, r := range results {
+		done[r.Version] = r
+	}
+
+	return done, nil
+}
+
+// withLock run fn while holding the migrations lock, so replicas booting together don't migrate concurrently
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+
+	deadline := time.Now().Add(m.LockWait)
+	for {
+		err := m.acquire(ctx)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, ErrMigrationLocked) || time.Now().After(deadline) {
+			return err
+		}
+
+		log.Println("Migrations: waiting for lock")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+
+	defer func() {
+		_, err := m.DB.GetConnection().Collection(CollectionSchemaMigrationsLock).DeleteOne(context.Background(),
+			bson.M{"_id": lockID, "owner": m.owner})
+		if err != nil {
+			log.Println("Migrations: releasing lock failed >>", err)
+		}
+	}()
+
+	return fn()
+}
+
+func (m *Migrator) acquire(ctx context.Context) error {
+
+	timeNow := time.Now()
+
+	//take the lock when nobody holds it or when the holder let it expire,
+	//a live lock makes the upsert collide on _id
+	_, err := m.DB.GetConnection().Collection(CollectionSchemaMigrationsLock).UpdateOne(ctx,
+		bson.M{"_id": lockID, "expires_at": bson.M{"$lt": timeNow}},
+		bson.M{"$set": bson.M{"owner": m.owner, "expires_at": timeNow.Add(m.LockTTL)}},
+		options.Update().SetUpsert(true))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrMigrationLocked
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (m *Migrator) records() *mongo.Collection {
+	return m.DB.GetConnection().Collection(CollectionSchemaMigrations)
+}
+
+func (m *Migrator) bookmarks() *mongo.Collection {
+	return m.DB.GetCollection(m.DB.DbCollectionBookmarks)
+}
+
+func isIndexNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Name == "IndexNotFound" || cmdErr.Code == 27
+	}
+	return false
 }
 
-func New(db *database.Database) *Migration {
-	return &Migration{DB: db}
+// dropIndex drop an index by name, missing indexes are ignored so migrations stay re-runnable
+func dropIndex(ctx context.Context, coll *mongo.Collection, name string) error {
+	_, err := coll.Indexes().DropOne(ctx, name)
+	if err != nil && !isIndexNotFound(err) {
+		return err
+	}
+	return nil
 }
@@ -0,0 +1,287 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-playground/assert/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golek_bookmark_service/pkg/config"
+	"golek_bookmark_service/pkg/database"
+	"os"
+	"testing"
+	"time"
+)
+
+func versionsOf(statuses []MigrationStatus) []int64 {
+	versions := make([]int64, 0)
+	for _, s := range statuses {
+		versions = append(versions, s.Version)
+	}
+	return versions
+}
+
+func appliedVersions(statuses []MigrationStatus) []int64 {
+	versions := make([]int64, 0)
+	for _, s := range statuses {
+		if s.AppliedAt != nil {
+			versions = append(versions, s.Version)
+		}
+	}
+	return versions
+}
+
+// mongoTestDatabase connect to a database of its own, dropped once the test ends.
+// it is skipped unless TEST_DB_HOST is set
+func mongoTestDatabase(t *testing.T) *database.Database {
+
+	if os.Getenv("TEST_DB_HOST") == "" {
+		t.Skip("TEST_DB_HOST is not set")
+	}
+
+	db := database.New(&config.Config{Database: map[string]string{
+		"USERNAME":             os.Getenv("TEST_DB_USERNAME"),
+		"PASSWORD":             os.Getenv("TEST_DB_PASSWORD"),
+		"HOST":                 os.Getenv("TEST_DB_HOST"),
+		"PORT":                 os.Getenv("TEST_DB_PORT"),
+		"NAME":                 "migrations_" + primitive.NewObjectID().Hex(),
+		"COLLECTION_BOOKMARKS": "bookmarks",
+	}})
+	db.Prepare()
+	t.Cleanup(func() { _ = db.GetConnection().Drop(context.Background()) })
+
+	return db
+}
+
+func TestMigratorUpDown(t *testing.T) {
+
+	ctx := context.Background()
+	m := New(mongoTestDatabase(t))
+	ran := make([]string, 0)
+	step := func(name string) func(ctx context.Context, db *mongo.Database, bookmarks *mongo.Collection) error {
+		return func(ctx context.Context, db *mongo.Database, bookmarks *mongo.Collection) error {
+			ran = append(ran, name)
+			return nil
+		}
+	}
+	m.Migrations = []Migration{
+		{Version: 1, Name: "first", Up: step("up 1"), Down: step("down 1")},
+		{Version: 2, Name: "irreversible", Up: step("up 2")},
+		{Version: 3, Name: "third", Up: step("up 3"), Down: step("down 3")},
+	}
+
+	applied, err := m.Up(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, versionsOf(applied), []int64{1, 2, 3})
+	assert.Equal(t, ran, []string{"up 1", "up 2", "up 3"})
+
+	//applied migrations aren't run again
+	applied, err = m.Up(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(applied), 0)
+
+	reverted, err := m.Down(ctx, 1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, versionsOf(reverted), []int64{3})
+	assert.Equal(t, ran[len(ran)-1], "down 3")
+	statuses, err := m.Status(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, versionsOf(statuses), []int64{1, 2, 3})
+	assert.Equal(t, appliedVersions(statuses), []int64{1, 2})
+
+	//an irreversible migration stops the way down
+	reverted, err = m.Down(ctx, 2)
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, len(reverted), 0)
+
+	//a failing migration isn't recorded
+	m.Migrations = append(m.Migrations, Migration{Version: 4, Name: "failing", Up: func(ctx context.Context, db *mongo.Database, bookmarks *mongo.Collection) error {
+		return errors.New("boom")
+	}})
+	applied, err = m.Up(ctx)
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, versionsOf(applied), []int64{3})
+	statuses, _ = m.Status(ctx)
+	assert.Equal(t, appliedVersions(statuses), []int64{1, 2, 3})
+}
+
+func TestMigratorVersions(t *testing.T) {
+
+	ctx := context.Background()
+	m := New(mongoTestDatabase(t))
+
+	applied, err := m.Up(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(applied), len(versions))
+
+	//the later migrations go back down and up again, the default collections one can't be reverted
+	reverted, err := m.Down(ctx, len(versions))
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, versionsOf(reverted), []int64{5, 4})
+
+	applied, err = m.Up(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, versionsOf(applied), []int64{4, 5})
+}
+
+func TestMigratorLock(t *testing.T) {
+
+	ctx := context.Background()
+	db := mongoTestDatabase(t)
+	locks := db.GetConnection().Collection(CollectionSchemaMigrationsLock)
+
+	m := New(db)
+	m.Migrations = []Migration{{Version: 1, Name: "first", Up: func(ctx context.Context, _ *mongo.Database, _ *mongo.Collection) error {
+		//another replica can't migrate while this one does
+		other := New(db)
+		other.LockWait = 0
+		_, err := other.Up(ctx)
+		if !errors.Is(err, ErrMigrationLocked) {
+			return fmt.Errorf("expected the lock to be held, got %v", err)
+		}
+		return nil
+	}}}
+
+	//a live lock of another process holds the migrations back
+	_, err := locks.InsertOne(ctx, bson.M{"_id": lockID, "owner": "other", "expires_at": time.Now().Add(time.Minute)})
+	assert.Equal(t, err, nil)
+	m.LockWait = 0
+	_, err = m.Up(ctx)
+	assert.Equal(t, errors.Is(err, ErrMigrationLocked), true)
+
+	//an expired one is taken over
+	_, err = locks.UpdateOne(ctx, bson.M{"_id": lockID}, bson.M{"$set": bson.M{"expires_at": time.Now().Add(-time.Second)}})
+	assert.Equal(t, err, nil)
+	applied, err := m.Up(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, versionsOf(applied), []int64{1})
+
+	//the lock is released once done
+	count, err := locks.CountDocuments(ctx, bson.M{})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(0))
+}
+
+// postgresTestDatabase connect to the test server, it is skipped unless TEST_POSTGRES_HOST is set
+func postgresTestDatabase(t *testing.T) *database.PostgresDatabase {
+
+	if os.Getenv("TEST_POSTGRES_HOST") == "" {
+		t.Skip("TEST_POSTGRES_HOST is not set")
+	}
+
+	db := database.NewPostgres(&config.Config{Database: map[string]string{
+		"USERNAME": os.Getenv("TEST_POSTGRES_USERNAME"),
+		"PASSWORD": os.Getenv("TEST_POSTGRES_PASSWORD"),
+		"HOST":     os.Getenv("TEST_POSTGRES_HOST"),
+		"PORT":     os.Getenv("TEST_POSTGRES_PORT"),
+		"NAME":     os.Getenv("TEST_POSTGRES_NAME"),
+	}})
+	db.Prepare()
+	t.Cleanup(func() { _ = db.GetConnection().Close() })
+
+	return db
+}
+
+// testPostgresMigrations create tables of their own under versions far past the real ones,
+// so they don't disturb the schema the repository tests share. they are forgotten once the test ends
+func testPostgresMigrations(t *testing.T, db *database.PostgresDatabase) (migrations []PostgresMigration, table string) {
+
+	table = "migration_test_" + primitive.NewObjectID().Hex()
+	base := time.Now().UnixNano()
+	t.Cleanup(func() {
+		_, _ = db.GetConnection().Exec(`DELETE FROM schema_migrations WHERE version >= $1`, base)
+		_, _ = db.GetConnection().Exec(`DROP TABLE IF EXISTS ` + table + `, ` + table + `_irreversible`)
+	})
+
+	return []PostgresMigration{
+		{Version: base, Name: "create_table", Up: `CREATE TABLE ` + table + ` (id TEXT)`, Down: `DROP TABLE ` + table},
+		{Version: base + 1, Name: "irreversible", Up: `CREATE TABLE ` + table + `_irreversible (id TEXT)`},
+		{Version: base + 2, Name: "add_column", Up: `ALTER TABLE ` + table + ` ADD COLUMN name TEXT`, Down: `ALTER TABLE ` + table + ` DROP COLUMN name`},
+	}, table
+}
+
+func TestPostgresMigratorUpDown(t *testing.T) {
+
+	ctx := context.Background()
+	db := postgresTestDatabase(t)
+	m := NewPostgres(db)
+	migrations, table := testPostgresMigrations(t, db)
+	m.Migrations = migrations
+	base := m.Migrations[0].Version
+
+	applied, err := m.Up(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, versionsOf(applied), []int64{base, base + 1, base + 2})
+	_, err = db.GetConnection().Exec(`SELECT id, name FROM ` + table)
+	assert.Equal(t, err, nil)
+
+	applied, err = m.Up(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(applied), 0)
+
+	reverted, err := m.Down(ctx, 1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, versionsOf(reverted), []int64{base + 2})
+	_, err = db.GetConnection().Exec(`SELECT name FROM ` + table)
+	assert.NotEqual(t, err, nil)
+	statuses, err := m.Status(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, appliedVersions(statuses), []int64{base, base + 1})
+
+	reverted, err = m.Down(ctx, 2)
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, len(reverted), 0)
+
+	//a failing migration rolls back along with its record
+	m.Migrations = append(m.Migrations, PostgresMigration{Version: base + 3, Name: "failing", Up: `CREATE TABLE migration_test_rolled_back (id TEXT); SELECT 1/0`})
+	applied, err = m.Up(ctx)
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, versionsOf(applied), []int64{base + 2})
+	statuses, _ = m.Status(ctx)
+	assert.Equal(t, appliedVersions(statuses), []int64{base, base + 1, base + 2})
+	var exists bool
+	err = db.GetConnection().QueryRow(`SELECT to_regclass('migration_test_rolled_back') IS NOT NULL`).Scan(&exists)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, exists, false)
+}
+
+func TestPostgresMigratorVersions(t *testing.T) {
+
+	//the real migrations are shared with the repository tests, they are only checked to apply
+	_, err := NewPostgres(postgresTestDatabase(t)).Up(context.Background())
+	assert.Equal(t, err, nil)
+}
+
+func TestPostgresMigratorLock(t *testing.T) {
+
+	ctx := context.Background()
+	db := postgresTestDatabase(t)
+	m := NewPostgres(db)
+	m.Migrations, _ = testPostgresMigrations(t, db)
+	m.LockWait = 0
+
+	//another session holding the advisory lock holds the migrations back
+	holder, err := db.GetConnection().Conn(ctx)
+	assert.Equal(t, err, nil)
+	defer holder.Close()
+	_, err = holder.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, postgresLockKey)
+	assert.Equal(t, err, nil)
+
+	_, err = m.Up(ctx)
+	assert.Equal(t, errors.Is(err, ErrMigrationLocked), true)
+
+	_, err = holder.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, postgresLockKey)
+	assert.Equal(t, err, nil)
+	applied, err := m.Up(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(applied), 3)
+
+	//the lock is released once done
+	var locked bool
+	err = holder.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, postgresLockKey).Scan(&locked)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, locked, true)
+	_, _ = holder.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, postgresLockKey)
+}
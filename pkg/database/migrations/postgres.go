@@ -0,0 +1,243 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"golek_bookmark_service/pkg/database"
+	"log"
+	"sort"
+	"time"
+)
+
+// postgresLockKey identifies the advisory lock held while migrations run
+const postgresLockKey int64 = 7_340_117
+
+// PostgresMigration is a single ordered schema change written in SQL, an empty Down marks it irreversible
+type PostgresMigration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type PostgresMigrator struct {
+	DB         *database.PostgresDatabase
+	Migrations []PostgresMigration
+	// LockWait is how long to wait for another process to release the lock
+	LockWait time.Duration
+}
+
+func NewPostgres(db *database.PostgresDatabase) *PostgresMigrator {
+
+	migrations := append([]PostgresMigration{}, postgresVersions...)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return &PostgresMigrator{
+		DB:         db,
+		Migrations: migrations,
+		LockWait:   time.Minute,
+	}
+}
+
+// Up apply every pending migration in version order, each one in its own transaction
+func (m *PostgresMigrator) Up(ctx context.Context) (applied []MigrationStatus, err error) {
+
+	err = m.withLock(ctx, func(conn *sql.Conn) error {
+
+		done, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.Migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+
+			log.Printf("Migrations: applying %d_%s", migration.Version, migration.Name)
+			timeNow := time.Now()
+			err := inTransaction(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx,
+					`INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`,
+					migration.Version, migration.Name, timeNow)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+
+			applied = append(applied, MigrationStatus{Version: migration.Version, Name: migration.Name, AppliedAt: &timeNow})
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down revert the latest 'steps' applied migrations, latest first
+func (m *PostgresMigrator) Down(ctx context.Context, steps int) (reverted []MigrationStatus, err error) {
+
+	err = m.withLock(ctx, func(conn *sql.Conn) error {
+
+		done, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.Migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.Migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s is irreversible", migration.Version, migration.Name)
+			}
+
+			log.Printf("Migrations: reverting %d_%s", migration.Version, migration.Name)
+			err := inTransaction(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("reverting migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+
+			reverted = append(reverted, MigrationStatus{Version: migration.Version, Name: migration.Name})
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status list every known migration along with the time it was applied, if it was
+func (m *PostgresMigrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+
+	conn, err := m.DB.GetConnection().Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := ensureSchemaMigrationsTable(ctx, conn); err != nil {
+		return nil, err
+	}
+
+	done, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0)
+	for _, migration := range m.Migrations {
+		s := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := done[migration.Version]; ok {
+			appliedAt := appliedAt
+			s.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, s)
+	}
+
+	return statuses, nil
+}
+
+func (m *PostgresMigrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	done := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		done[version] = appliedAt
+	}
+
+	return done, rows.Err()
+}
+
+// withLock run fn on a dedicated connection holding a session level advisory lock,
+// so replicas booting together don't migrate concurrently. the lock goes away with the session if the process dies
+func (m *PostgresMigrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+
+	conn, err := m.DB.GetConnection().Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(m.LockWait)
+	for {
+		var locked bool
+		err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, postgresLockKey).Scan(&locked)
+		if err != nil {
+			return err
+		}
+		if locked {
+			break
+		}
+		if time.Now().After(deadline) {
+			return ErrMigrationLocked
+		}
+
+		log.Println("Migrations: waiting for lock")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+
+	defer func() {
+		_, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, postgresLockKey)
+		if err != nil {
+			log.Println("Migrations: releasing lock failed >>", err)
+		}
+	}()
+
+	if err := ensureSchemaMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func ensureSchemaMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    BIGINT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL
+	)`)
+	return err
+}
+
+func inTransaction(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,91 @@
+package migrations
+
+// postgresVersions lists the schema changes of the PostgreSQL storage, ids are ObjectID hex strings
+// so bookmarks keep the same identifiers whichever storage they live in.
+// never edit an applied migration, add a new version instead
+var postgresVersions = []PostgresMigration{
+	{
+		Version: 1,
+		Name:    "create_bookmarks",
+		Up: `
+			CREATE TABLE bookmarks (
+				id         VARCHAR(24) PRIMARY KEY,
+				user_id    TEXT NOT NULL,
+				created_at TIMESTAMPTZ,
+				updated_at TIMESTAMPTZ,
+				deleted_at TIMESTAMPTZ,
+				position   BIGSERIAL
+			);
+			-- one active bookmark per user, trashed ones don't count
+			CREATE UNIQUE INDEX bookmarks_user_id_active_unique ON bookmarks (user_id) WHERE deleted_at IS NULL;
+			CREATE INDEX bookmarks_deleted_at ON bookmarks (deleted_at);`,
+		Down: `DROP TABLE bookmarks;`,
+	},
+	{
+		Version: 2,
+		Name:    "create_bookmark_collections",
+		Up: `
+			CREATE TABLE bookmark_collections (
+				id          VARCHAR(24) PRIMARY KEY,
+				bookmark_id VARCHAR(24) NOT NULL REFERENCES bookmarks (id) ON DELETE CASCADE,
+				name        TEXT NOT NULL,
+				is_default  BOOLEAN NOT NULL DEFAULT FALSE,
+				created_at  TIMESTAMPTZ,
+				updated_at  TIMESTAMPTZ,
+				position    BIGSERIAL
+			);
+			CREATE INDEX bookmark_collections_bookmark_id ON bookmark_collections (bookmark_id);`,
+		Down: `DROP TABLE bookmark_collections;`,
+	},
+	{
+		Version: 3,
+		Name:    "create_bookmark_items",
+		Up: `
+			CREATE TABLE bookmark_items (
+				bookmark_id   VARCHAR(24) NOT NULL REFERENCES bookmarks (id) ON DELETE CASCADE,
+				collection_id VARCHAR(24) NOT NULL REFERENCES bookmark_collections (id) ON DELETE CASCADE,
+				post_id       VARCHAR(24) NOT NULL,
+				added_at      TIMESTAMPTZ NOT NULL,
+				note          TEXT NOT NULL DEFAULT '',
+				tags          TEXT[] NOT NULL DEFAULT '{}',
+				position      BIGSERIAL,
+				-- a post is saved at most once per collection
+				PRIMARY KEY (bookmark_id, collection_id, post_id)
+			);
+			CREATE INDEX bookmark_items_post_id ON bookmark_items (post_id);`,
+		Down: `DROP TABLE bookmark_items;`,
+	},
+	{
+		Version: 4,
+		Name:    "snapshot_and_search_bookmark_items",
+		Up: `
+			ALTER TABLE bookmark_items
+				ADD COLUMN name        TEXT NOT NULL DEFAULT '',
+				ADD COLUMN image_url   TEXT NOT NULL DEFAULT '',
+				ADD COLUMN snapshot_at TIMESTAMPTZ;
+			-- array_to_string isn't immutable on its own, wrapping it lets the document back an index
+			CREATE FUNCTION bookmark_item_document(name TEXT, note TEXT, tags TEXT[]) RETURNS tsvector
+				LANGUAGE sql IMMUTABLE
+				AS $$ SELECT to_tsvector('simple', name || ' ' || note || ' ' || array_to_string(tags, ' ')) $$;
+			CREATE INDEX bookmark_items_search ON bookmark_items USING GIN (bookmark_item_document(name, note, tags));`,
+		Down: `
+			DROP INDEX bookmark_items_search;
+			DROP FUNCTION bookmark_item_document;
+			ALTER TABLE bookmark_items DROP COLUMN name, DROP COLUMN image_url, DROP COLUMN snapshot_at;`,
+	},
+	{
+		Version: 5,
+		Name:    "create_bookmark_outbox",
+		Up: `
+			-- events written along with the change they report, removed once the relay published them
+			CREATE TABLE bookmark_outbox (
+				id         VARCHAR(24) PRIMARY KEY,
+				subject    TEXT NOT NULL,
+				payload    BYTEA NOT NULL,
+				created_at TIMESTAMPTZ NOT NULL,
+				position   BIGSERIAL
+			);
+			CREATE INDEX bookmark_outbox_position ON bookmark_outbox (position);`,
+		Down: `DROP TABLE bookmark_outbox;`,
+	},
+}
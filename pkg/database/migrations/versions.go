@@ -0,0 +1,181 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golek_bookmark_service/pkg/models"
+	"log"
+	"time"
+)
+
+// versions lists every schema migration, append new ones with the next version number
+var versions = []Migration{
+	{
+		Version: 1,
+		Name:    "index_posts_id",
+		Up: func(ctx context.Context, db *mongo.Database, bookmarks *mongo.Collection) error {
+			//the old index pointed to a field that never existed
+			if err := dropIndex(ctx, bookmarks, "courses.id_1"); err != nil {
+				return err
+			}
+			//multikey index on saved post ids, serves "who bookmarked this post" lookups
+			_, err := bookmarks.Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "posts.id", Value: 1}},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database, bookmarks *mongo.Collection) error {
+			return dropIndex(ctx, bookmarks, "posts.id_1")
+		},
+	},
+	{
+		Version: 2,
+		Name:    "unique_active_user_id",
+		Up: func(ctx context.Context, db *mongo.Database, bookmarks *mongo.Collection) error {
+			//a plain unique user id blocks users from owning a new bookmark while the old one sits in the trash
+			if err := dropIndex(ctx, bookmarks, "user_id_1"); err != nil {
+				return err
+			}
+			_, err := bookmarks.Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys: bson.D{{Key: "user_id", Value: 1}},
+					Options: options.Index().
+						SetName("user_id_active_unique").
+						SetUnique(true).
+						SetPartialFilterExpression(bson.M{"deleted_at": bson.M{"$type": "null"}}),
+				},
+				//speed up trash purging
+				{Keys: bson.D{{Key: "deleted_at", Value: 1}}},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database, bookmarks *mongo.Collection) error {
+			if err := dropIndex(ctx, bookmarks, "user_id_active_unique"); err != nil {
+				return err
+			}
+			if err := dropIndex(ctx, bookmarks, "deleted_at_1"); err != nil {
+				return err
+			}
+			_, err := bookmarks.Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "user_id", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			})
+			return err
+		},
+	},
+	{
+		Version: 3,
+		Name:    "default_collections",
+		Up:      migrateDefaultCollections,
+		// posts can't be folded back into a single list once users spread them over collections
+		Down: nil,
+	},
+	{
+		Version: 4,
+		Name:    "text_index_posts",
+		Up: func(ctx context.Context, db *mongo.Database, bookmarks *mongo.Collection) error {
+			//serves saved post search, no language so words are matched as typed without stemming or stop words
+			_, err := bookmarks.Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "posts.name", Value: "text"},
+					{Key: "posts.note", Value: "text"},
+					{Key: "posts.tags", Value: "text"},
+				},
+				Options: options.Index().SetName("posts_text").SetDefaultLanguage("none"),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database, bookmarks *mongo.Collection) error {
+			return dropIndex(ctx, bookmarks, "posts_text")
+		},
+	},
+	{
+		Version: 5,
+		Name:    "create_outbox",
+		Up: func(ctx context.Context, db *mongo.Database, bookmarks *mongo.Collection) error {
+			//collections can't be created inside the transactions writing to them on older servers
+			err := db.CreateCollection(ctx, models.OutboxCollectionName)
+			var commandErr mongo.CommandError
+			if err != nil && !(errors.As(err, &commandErr) && commandErr.Name == "NamespaceExists") {
+				return err
+			}
+			//the relay reads the oldest events first
+			_, err = db.Collection(models.OutboxCollectionName).Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database, bookmarks *mongo.Collection) error {
+			return db.Collection(models.OutboxCollectionName).Drop(ctx)
+		},
+	},
+}
+
+// migrateDefaultCollections move the posts of bookmarks created before collections existed
+// into a default collection
+func migrateDefaultCollections(ctx context.Context, db *mongo.Database, bookmarks *mongo.Collection) error {
+
+	filter := bson.D{{Key: "collections", Value: bson.M{"$exists": false}}}
+	records, err := bookmarks.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+
+	//Close Cursor
+	defer func(records *mongo.Cursor, ctx context.Context) {
+		err := records.Close(ctx)
+		if err != nil {
+			log.Println(err)
+		}
+	}(records, ctx)
+
+	migrated := 0
+	for records.Next(ctx) {
+		var record struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := records.Decode(&record); err != nil {
+			return err
+		}
+
+		timeNow := time.Now()
+		defaultCollection := models.Collection{
+			ID:        primitive.NewObjectID(),
+			Name:      models.DefaultCollectionName,
+			IsDefault: true,
+			UpdatedAt: &timeNow,
+			CreatedAt: &timeNow,
+		}
+
+		//1. add the default collection
+		//2. tag every saved post with the default collection id
+		pipeline := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"collections": bson.A{defaultCollection},
+			"posts": bson.M{"$map": bson.M{
+				"input": bson.M{"$ifNull": bson.A{"$posts", bson.A{}}},
+				"as":    "post",
+				"in":    bson.M{"$mergeObjects": bson.A{"$$post", bson.M{"collection_id": defaultCollection.ID}}},
+			}},
+		}}}}
+
+		_, err := bookmarks.UpdateOne(ctx, bson.D{
+			{Key: "_id", Value: record.ID},
+			{Key: "collections", Value: bson.M{"$exists": false}},
+		}, pipeline)
+		if err != nil {
+			return err
+		}
+		migrated++
+	}
+
+	if err := records.Err(); err != nil {
+		return err
+	}
+
+	log.Printf("Migrations: moved %d bookmarks into default collections", migrated)
+	return nil
+}
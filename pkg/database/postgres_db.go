@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"golek_bookmark_service/pkg/contracts"
+	"log"
+	"net/url"
+
+	_ "github.com/lib/pq"
+)
+
+type PostgresDatabase struct {
+	DbUsername string
+	DBPassword string
+	DbName     string
+	DbHost     string
+	DbPort     string
+	DbSSLMode  string
+	connection *sql.DB
+	config     contracts.DBConfig
+}
+
+func NewPostgres(config contracts.DBConfig) *PostgresDatabase {
+
+	sslMode := config.GetDBConfig()["SSL_MODE"]
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+
+	return &PostgresDatabase{
+		DbUsername: config.GetDBConfig()["USERNAME"],
+		DBPassword: config.GetDBConfig()["PASSWORD"],
+		DbName:     config.GetDBConfig()["NAME"],
+		DbHost:     config.GetDBConfig()["HOST"],
+		DbPort:     config.GetDBConfig()["PORT"],
+		DbSSLMode:  sslMode,
+		config:     config,
+	}
+}
+
+func (db *PostgresDatabase) Prepare() contracts.SQLDBContract {
+
+	//Singleton Connection
+	if db.connection == nil {
+
+		connection, err := sql.Open("postgres", db.DSN())
+		if err != nil {
+			panic(err.Error())
+		}
+
+		log.Println("Pinging PostgreSQL")
+		err = connection.PingContext(context.Background())
+		if err != nil {
+			log.Fatalf("Pinging Error %v", err.Error())
+		}
+
+		db.connection = connection
+		log.Println("Connected to the database PostgreSQL")
+
+	} else {
+		log.Println("Already Connected to the database: PostgreSQL")
+	}
+
+	return db
+}
+
+func (db *PostgresDatabase) DSN() string {
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(db.DbUsername, db.DBPassword),
+		Host:     fmt.Sprintf("%s:%s", db.DbHost, db.DbPort),
+		Path:     db.DbName,
+		RawQuery: url.Values{"sslmode": []string{db.DbSSLMode}}.Encode(),
+	}
+	return dsn.String()
+}
+
+func (db *PostgresDatabase) GetConnection() *sql.DB {
+	return db.connection
+}
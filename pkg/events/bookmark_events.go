@@ -0,0 +1,93 @@
+package events
+
+import (
+	"encoding/json"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golek_bookmark_service/pkg/models"
+	"time"
+)
+
+// subjects bookmark events are published on
+const (
+	BookmarkPostAddedSubject   = "bookmarks.post_added"
+	BookmarkPostRevokedSubject = "bookmarks.post_revoked"
+	BookmarkDeletedSubject     = "bookmarks.deleted"
+)
+
+// BookmarkPostAdded is published once posts are saved in a user's collection, only the posts that weren't saved there yet are listed.
+// events may be delivered more than once, EventID tells the copies apart
+type BookmarkPostAdded struct {
+	EventID      string    `json:"event_id"`
+	UserID       string    `json:"user_id"`
+	CollectionID string    `json:"collection_id"`
+	PostIDs      []string  `json:"post_ids"`
+	OccurredAt   time.Time `json:"occurred_at"`
+}
+
+// BookmarkPostRevoked is published once posts are removed from a user's bookmark, an empty CollectionID
+// means they were revoked from every collection
+type BookmarkPostRevoked struct {
+	EventID      string    `json:"event_id"`
+	UserID       string    `json:"user_id"`
+	CollectionID string    `json:"collection_id,omitempty"`
+	PostIDs      []string  `json:"post_ids"`
+	OccurredAt   time.Time `json:"occurred_at"`
+}
+
+// BookmarkDeleted is published once a bookmark is moved to the trash
+type BookmarkDeleted struct {
+	EventID    string    `json:"event_id"`
+	BookmarkID string    `json:"bookmark_id"`
+	UserID     string    `json:"user_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+func NewBookmarkPostAdded(userID string, collectionID primitive.ObjectID, postIDs []primitive.ObjectID, occurredAt time.Time) models.OutboxEvent {
+	id := primitive.NewObjectID()
+	return outboxEvent(id, BookmarkPostAddedSubject, occurredAt, BookmarkPostAdded{
+		EventID:      id.Hex(),
+		UserID:       userID,
+		CollectionID: collectionID.Hex(),
+		PostIDs:      hexIDs(postIDs),
+		OccurredAt:   occurredAt,
+	})
+}
+
+// NewBookmarkPostRevoked build the event of posts revoked from the collection, a nil 'collectionID' stands for every collection
+func NewBookmarkPostRevoked(userID string, collectionID primitive.ObjectID, postIDs []primitive.ObjectID, occurredAt time.Time) models.OutboxEvent {
+	id := primitive.NewObjectID()
+	event := BookmarkPostRevoked{
+		EventID:    id.Hex(),
+		UserID:     userID,
+		PostIDs:    hexIDs(postIDs),
+		OccurredAt: occurredAt,
+	}
+	if !collectionID.IsZero() {
+		event.CollectionID = collectionID.Hex()
+	}
+	return outboxEvent(id, BookmarkPostRevokedSubject, occurredAt, event)
+}
+
+func NewBookmarkDeleted(bookmarkID primitive.ObjectID, userID string, occurredAt time.Time) models.OutboxEvent {
+	id := primitive.NewObjectID()
+	return outboxEvent(id, BookmarkDeletedSubject, occurredAt, BookmarkDeleted{
+		EventID:    id.Hex(),
+		BookmarkID: bookmarkID.Hex(),
+		UserID:     userID,
+		OccurredAt: occurredAt,
+	})
+}
+
+func outboxEvent(id primitive.ObjectID, subject string, createdAt time.Time, event interface{}) models.OutboxEvent {
+	//the events are plain structs, encoding them can't fail
+	payload, _ := json.Marshal(event)
+	return models.OutboxEvent{ID: id, Subject: subject, Payload: payload, CreatedAt: createdAt}
+}
+
+func hexIDs(ids []primitive.ObjectID) []string {
+	hexIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		hexIDs = append(hexIDs, id.Hex())
+	}
+	return hexIDs
+}
@@ -0,0 +1,45 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"golek_bookmark_service/pkg/contracts"
+	"time"
+)
+
+// transports selected by EVENTS_TRANSPORT
+const (
+	TransportNATS      = "nats"
+	TransportInProcess = "inprocess"
+)
+
+// ErrMalformed marks events a handler can't read, handling them again would fail again so they aren't redelivered
+var ErrMalformed = errors.New("malformed event")
+
+// PostDeletedSubject carries PostDeleted events published by the post service
+const PostDeletedSubject = "posts.deleted"
+
+// PostDeleted is published once a post is removed from the post service
+type PostDeleted struct {
+	PostID    string     `json:"post_id"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// New build the transport named by EVENTS_TRANSPORT, an empty one leaves events off and returns nil
+func New(config contracts.AppConfig) (contracts.EventTransport, error) {
+
+	app := config.GetAppConfig()
+	switch app["EVENTS_TRANSPORT"] {
+	case "":
+		return nil, nil
+	case TransportInProcess:
+		return NewInProcessTransport(), nil
+	case TransportNATS:
+		if app["EVENTS_NATS_URL"] == "" || app["EVENTS_NATS_STREAM"] == "" || app["EVENTS_QUEUE_GROUP"] == "" {
+			return nil, errors.New("the nats transport needs EVENTS_NATS_URL, EVENTS_NATS_STREAM and EVENTS_QUEUE_GROUP")
+		}
+		return NewNATSTransport(app["EVENTS_NATS_URL"], app["EVENTS_NATS_STREAM"], app["EVENTS_QUEUE_GROUP"])
+	}
+
+	return nil, fmt.Errorf("unknown events transport %q, expected nats or inprocess", app["EVENTS_TRANSPORT"])
+}
@@ -0,0 +1,96 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-playground/assert/v2"
+	"github.com/nats-io/nats.go"
+	"testing"
+	"time"
+)
+
+type appConfig map[string]string
+
+func (c appConfig) GetAppConfig() map[string]string {
+	return c
+}
+
+func TestNewPicksTheTransport(t *testing.T) {
+
+	transport, err := New(appConfig{})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, transport, nil)
+
+	transport, err = New(appConfig{"EVENTS_TRANSPORT": "inprocess"})
+	assert.Equal(t, err, nil)
+	_, ok := transport.(*InProcessTransport)
+	assert.Equal(t, ok, true)
+
+	for _, cfg := range []appConfig{
+		{"EVENTS_TRANSPORT": "kafka"},
+		{"EVENTS_TRANSPORT": "nats"},
+		{"EVENTS_TRANSPORT": "nats", "EVENTS_NATS_URL": "nats://127.0.0.1:4222", "EVENTS_QUEUE_GROUP": "bookmarks"},
+		{"EVENTS_TRANSPORT": "nats", "EVENTS_NATS_URL": "nats://127.0.0.1:4222", "EVENTS_NATS_STREAM": "POSTS"},
+	} {
+		_, err := New(cfg)
+		assert.NotEqual(t, err, nil)
+	}
+}
+
+func TestInProcessTransportDeliversToSubscribers(t *testing.T) {
+
+	ctx := context.Background()
+	transport := NewInProcessTransport()
+
+	received := make([]string, 0)
+	subscribe := func(name string) {
+		_, err := transport.Subscribe(PostDeletedSubject, func(ctx context.Context, data []byte) error {
+			received = append(received, name+":"+string(data))
+			return nil
+		})
+		assert.Equal(t, err, nil)
+	}
+	subscribe("first")
+	subscribe("second")
+
+	assert.Equal(t, transport.Publish(ctx, PostDeletedSubject, []byte("a")), nil)
+	assert.Equal(t, errors.Is(transport.Publish(ctx, "posts.created", []byte("b")), ErrNoSubscribers), true)
+	assert.Equal(t, received, []string{"first:a", "second:a"})
+
+	assert.Equal(t, transport.Close(), nil)
+	assert.NotEqual(t, transport.Publish(ctx, PostDeletedSubject, []byte("c")), nil)
+	assert.Equal(t, len(received), 2)
+}
+
+// settlement records how a message was settled
+type settlement []string
+
+func (s *settlement) Ack(opts ...nats.AckOpt) error {
+	*s = append(*s, "ack")
+	return nil
+}
+
+func (s *settlement) NakWithDelay(delay time.Duration, opts ...nats.AckOpt) error {
+	*s = append(*s, fmt.Sprint("nak ", delay))
+	return nil
+}
+
+func (s *settlement) Term(opts ...nats.AckOpt) error {
+	*s = append(*s, "term")
+	return nil
+}
+
+func TestSettleAcksOnlyHandledMessages(t *testing.T) {
+
+	msg := &settlement{}
+	settle(msg, PostDeletedSubject, nil)
+	settle(msg, PostDeletedSubject, errors.New("database down"))
+	settle(msg, PostDeletedSubject, fmt.Errorf("%w: PostDeleted: unexpected end of JSON input", ErrMalformed))
+
+	assert.Equal(t, *msg, settlement{"ack", "nak 5s", "term"})
+}
+
+func TestConsumerName(t *testing.T) {
+	assert.Equal(t, consumerName("bookmarks", PostDeletedSubject), "bookmarks_posts_deleted")
+}
@@ -0,0 +1,96 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golek_bookmark_service/pkg/contracts"
+	"io"
+	"sync"
+)
+
+// InProcessTransport deliver messages to the subscribers of the same process, synchronously and in subscription order.
+// it stands in for a broker in tests and single instance setups, the first handler error is returned to the publisher.
+// a message nobody subscribed to isn't delivered anywhere, publishing it fails so that it's kept and published again
+type InProcessTransport struct {
+	mu          sync.RWMutex
+	subscribers map[string][]*inProcessSubscription
+	closed      bool
+}
+
+// ErrNoSubscribers is returned by the in-process transport for messages nobody would receive
+var ErrNoSubscribers = errors.New("no subscribers")
+
+type inProcessSubscription struct {
+	transport *InProcessTransport
+	subject   string
+	handler   contracts.EventHandler
+}
+
+func NewInProcessTransport() *InProcessTransport {
+	return &InProcessTransport{subscribers: make(map[string][]*inProcessSubscription)}
+}
+
+func (t *InProcessTransport) Publish(ctx context.Context, subject string, data []byte) error {
+
+	t.mu.RLock()
+	if t.closed {
+		t.mu.RUnlock()
+		return errors.New("events transport closed")
+	}
+	subscribers := append([]*inProcessSubscription{}, t.subscribers[subject]...)
+	t.mu.RUnlock()
+
+	if len(subscribers) == 0 {
+		return fmt.Errorf("%w on %s", ErrNoSubscribers, subject)
+	}
+
+	var firstErr error
+	for _, s := range subscribers {
+		//every subscriber gets its own copy, like over the wire
+		if err := s.handler(ctx, append([]byte{}, data...)); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func (t *InProcessTransport) Subscribe(subject string, handler contracts.EventHandler) (io.Closer, error) {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return nil, errors.New("events transport closed")
+	}
+
+	s := &inProcessSubscription{transport: t, subject: subject, handler: handler}
+	t.subscribers[subject] = append(t.subscribers[subject], s)
+	return s, nil
+}
+
+func (t *InProcessTransport) Close() error {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.closed = true
+	t.subscribers = make(map[string][]*inProcessSubscription)
+	return nil
+}
+
+func (s *inProcessSubscription) Close() error {
+
+	s.transport.mu.Lock()
+	defer s.transport.mu.Unlock()
+
+	subscribers := s.transport.subscribers[s.subject]
+	for i, other := range subscribers {
+		if other == s {
+			s.transport.subscribers[s.subject] = append(subscribers[:i:i], subscribers[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"github.com/nats-io/nats.go"
+	"golek_bookmark_service/pkg/contracts"
+	"io"
+	"log"
+	"strings"
+	"time"
+)
+
+// handleTimeout bounds the handling of one message received from NATS
+const handleTimeout = 30 * time.Second
+
+// ackWait is how long JetStream waits for the ack of a message before redelivering it, longer than any handling
+const ackWait = handleTimeout + 10*time.Second
+
+// publishTimeout bounds the wait for the stream to ack a published message
+const publishTimeout = 10 * time.Second
+
+// redeliveryDelay spaces out the deliveries of a message whose handler failed
+const redeliveryDelay = 5 * time.Second
+
+// NATSTransport publish events to JetStream streams and consume them through JetStream durable consumers of Stream.
+// a message is acked once its handler succeeded and redelivered otherwise, malformed ones are dropped.
+// subscribers of the same queue group share the consumer, each message reaching a single instance of the service
+type NATSTransport struct {
+	Conn       *nats.Conn
+	JetStream  nats.JetStreamContext
+	Stream     string
+	QueueGroup string
+}
+
+// NewNATSTransport connect to the NATS servers at 'url', reconnecting for as long as the service runs.
+// 'stream' is the JetStream stream holding the subjects the service subscribes to
+func NewNATSTransport(url string, stream string, queueGroup string) (*NATSTransport, error) {
+
+	conn, err := nats.Connect(url,
+		nats.Name("bookmark-service"),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			log.Println("Events: NATS disconnected >>", err)
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			log.Println("Events: NATS reconnected to", conn.ConnectedUrl())
+		}))
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	log.Println("Events: NATS connected to", conn.ConnectedUrl())
+
+	return &NATSTransport{Conn: conn, JetStream: js, Stream: stream, QueueGroup: queueGroup}, nil
+}
+
+// Publish store the message in the JetStream stream holding 'subject'. it only succeeds once the stream acked it,
+// a subject no stream holds, a full stream or a leader change fail the publish and leave the event to be published again
+func (t *NATSTransport) Publish(ctx context.Context, subject string, data []byte) error {
+
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+
+	_, err := t.JetStream.Publish(subject, data, nats.Context(ctx))
+	return err
+}
+
+// Subscribe bind to the durable consumer of 'subject', creating it on first use. the consumer outlives
+// the subscription, messages published while no instance runs are delivered once one subscribes again
+func (t *NATSTransport) Subscribe(subject string, handler contracts.EventHandler) (io.Closer, error) {
+
+	durable := consumerName(t.QueueGroup, subject)
+	if err := t.ensureConsumer(subject, durable); err != nil {
+		return nil, err
+	}
+
+	handle := func(msg *nats.Msg) {
+		ctx, cancel := context.WithTimeout(context.Background(), handleTimeout)
+		defer cancel()
+
+		settle(msg, msg.Subject, handler(ctx, msg.Data))
+	}
+
+	subscription, err := t.JetStream.QueueSubscribe(subject, t.QueueGroup, handle, nats.Bind(t.Stream, durable), nats.ManualAck())
+	if err != nil {
+		return nil, err
+	}
+
+	return natsSubscription{subscription}, nil
+}
+
+// ensureConsumer create the durable push consumer when it doesn't exist yet
+func (t *NATSTransport) ensureConsumer(subject string, durable string) error {
+
+	_, err := t.JetStream.ConsumerInfo(t.Stream, durable)
+	if !errors.Is(err, nats.ErrConsumerNotFound) {
+		return err
+	}
+
+	_, err = t.JetStream.AddConsumer(t.Stream, &nats.ConsumerConfig{
+		Durable:        durable,
+		DeliverSubject: nats.NewInbox(),
+		DeliverGroup:   t.QueueGroup,
+		DeliverPolicy:  nats.DeliverAllPolicy,
+		FilterSubject:  subject,
+		AckPolicy:      nats.AckExplicitPolicy,
+		AckWait:        ackWait,
+	})
+	return err
+}
+
+func (t *NATSTransport) Close() error {
+	return t.Conn.Drain()
+}
+
+// acker is the part of a JetStream message that settles it
+type acker interface {
+	Ack(opts ...nats.AckOpt) error
+	NakWithDelay(delay time.Duration, opts ...nats.AckOpt) error
+	Term(opts ...nats.AckOpt) error
+}
+
+// settle ack a handled message, drop a malformed one and have any other failure redelivered
+func settle(msg acker, subject string, err error) {
+
+	var settleErr error
+	switch {
+	case err == nil:
+		settleErr = msg.Ack()
+	case errors.Is(err, ErrMalformed):
+		log.Printf("Events: dropping %s >> %v", subject, err)
+		settleErr = msg.Term()
+	default:
+		log.Printf("Events: handling %s failed, it will be redelivered >> %v", subject, err)
+		settleErr = msg.NakWithDelay(redeliveryDelay)
+	}
+
+	if settleErr != nil {
+		log.Printf("Events: settling %s failed >> %v", subject, settleErr)
+	}
+}
+
+// consumerName name the durable consumer of a queue group for a subject, consumer names can't hold dots
+func consumerName(queueGroup string, subject string) string {
+	return queueGroup + "_" + strings.ReplaceAll(subject, ".", "_")
+}
+
+// natsSubscription stop the deliveries on Close, the durable consumer it's bound to stays
+type natsSubscription struct {
+	*nats.Subscription
+}
+
+func (s natsSubscription) Close() error {
+	return s.Drain()
+}
@@ -1,8 +1,9 @@
 package controllers
 
 import (
-	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golek_bookmark_service/pkg/contracts"
 	"golek_bookmark_service/pkg/contracts/status"
 	"golek_bookmark_service/pkg/http/requests"
@@ -12,6 +13,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type BookmarkHandler struct {
@@ -25,27 +27,29 @@ func (h *BookmarkHandler) Fetch(c *gin.Context) {
 		excludedField = strings.Split(c.Query("exclude"), ",")
 	}
 
-	page, ok := c.GetQuery("page")
-	if page == "" || !ok {
-		page = "1"
-	} else if page == "0" {
-		page = "1"
-	}
-
-	qPage, err2 := strconv.ParseInt(page, 10, 64)
-	if err2 != nil {
+	filter, err := bookmarkFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	paginate := models.Pagination{
-		Page:    qPage,
-		PerPage: 25,
+	page, err := cursorPage(c, filter.SortKey())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	limit, skip := paginate.GetPagination()
-	bookmarks, _, err := h.BookmarkUsecase.Fetch(c.Request.Context(), excludedField, limit, skip)
+	bookmarks, total, next, opStatus, err := h.BookmarkUsecase.Fetch(c.Request.Context(), filter, excludedField, page)
 
 	if err != nil {
+		if status.Is(opStatus, status.OperationUnauthorized) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.OperationForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
@@ -53,8 +57,9 @@ func (h *BookmarkHandler) Fetch(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, responses.HttpPaginationResponse{
-		PerPage: paginate.PerPage,
-		Page:    paginate.Page,
+		PerPage:    page.PerPage,
+		Total:      &total,
+		NextCursor: encodeCursor(next),
 		HttpResponse: responses.HttpResponse{
 			Data:       bookmarks,
 			StatusCode: http.StatusOK,
@@ -63,6 +68,91 @@ func (h *BookmarkHandler) Fetch(c *gin.Context) {
 	return
 }
 
+const (
+	defaultPerPage = 25
+	maxPerPage     = 100
+)
+
+// perPage read the page size asked by the client, defaulting to defaultPerPage
+func perPage(c *gin.Context) (int64, error) {
+
+	value := c.Query("per_page")
+	if value == "" {
+		return defaultPerPage, nil
+	}
+
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size < 1 || size > maxPerPage {
+		return 0, errors.New("invalid per_page, expected a number from 1 to " + strconv.Itoa(maxPerPage))
+	}
+
+	return size, nil
+}
+
+// cursorPage read per_page along with the opaque cursor of the previous response,
+// a cursor only continues the ordering it was issued for
+func cursorPage(c *gin.Context, sort string) (page models.CursorPage, err error) {
+
+	page.PerPage, err = perPage(c)
+	if err != nil {
+		return page, err
+	}
+
+	page.After, err = models.DecodeCursor(c.Query("cursor"))
+	if err != nil {
+		return page, err
+	}
+	if page.After != nil && page.After.Sort != sort {
+		return page, errors.New("cursor doesn't match the requested sort")
+	}
+
+	return page, nil
+}
+
+func encodeCursor(cursor *models.Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+	return cursor.Encode()
+}
+
+// bookmarkFilter read the listing filters, dates are RFC 3339 and sort is a field optionally prefixed by '-'
+func bookmarkFilter(c *gin.Context) (filter models.BookmarkFilter, err error) {
+
+	filter.UserID = c.Query("user_id")
+
+	filter.PostID = c.Query("post_id")
+	if filter.PostID != "" && !primitive.IsValidObjectID(filter.PostID) {
+		return filter, errors.New("invalid post_id")
+	}
+
+	dates := map[string]**time.Time{
+		"created_from": &filter.CreatedFrom,
+		"created_to":   &filter.CreatedTo,
+		"updated_from": &filter.UpdatedFrom,
+		"updated_to":   &filter.UpdatedTo,
+	}
+	for name, field := range dates {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, errors.New("invalid " + name + ", expected an RFC 3339 date")
+		}
+		*field = &date
+	}
+
+	var ok bool
+	filter.SortBy, filter.SortDescending, ok = models.ParseSort(c.Query("sort"))
+	if !ok {
+		return filter, errors.New("invalid sort, expected one of created_at, updated_at or user_id optionally prefixed by '-'")
+	}
+
+	return filter, nil
+}
+
 func (h BookmarkHandler) FetchById(c *gin.Context) {
 
 	bookmark, opStatus, err := h.BookmarkUsecase.FetchById(c.Request.Context(), c.Param("id"), []string{})
@@ -72,6 +162,14 @@ func (h BookmarkHandler) FetchById(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if status.Is(opStatus, status.OperationUnauthorized) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.OperationForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -80,52 +178,290 @@ func (h BookmarkHandler) FetchById(c *gin.Context) {
 }
 
 func (h BookmarkHandler) FetchByUserID(c *gin.Context) {
-	bookmark, opStatus, err := h.BookmarkUsecase.FetchByUserId(c.Request.Context(), c.Param("user_id"), []string{})
+
+	filter := models.PostFilter{
+		Tag:    strings.TrimSpace(c.Query("tag")),
+		Search: strings.TrimSpace(c.Query("q")),
+	}
+
+	var ok bool
+	filter.SortBy, filter.SortDescending, ok = models.ParsePostSort(c.Query("sort"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sort, expected added_at or name optionally prefixed by '-'"})
+		return
+	}
+
+	page, err := cursorPage(c, filter.SortKey())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bookmark, next, opStatus, err := h.BookmarkUsecase.FetchByUserId(c.Request.Context(), c.Param("user_id"), []string{}, filter, page)
 	if err != nil {
 		//return 404 not found
 		if status.Is(opStatus, status.BookmarkNotExist) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if status.Is(opStatus, status.OperationUnauthorized) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.OperationForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, bookmark)
+
+	//the saved posts of the bookmark are paged
+	c.JSON(http.StatusOK, responses.HttpPaginationResponse{
+		PerPage:    page.PerPage,
+		NextCursor: encodeCursor(next),
+		HttpResponse: responses.HttpResponse{
+			Data:       bookmark,
+			StatusCode: http.StatusOK,
+		},
+	})
 }
 
-func (h BookmarkHandler) Create(c *gin.Context) {
+// SearchPosts look up the user's saved posts by the words of their name, note or tags, every word of q must match
+func (h BookmarkHandler) SearchPosts(c *gin.Context) {
+
+	query := strings.TrimSpace(c.Query("q"))
+	if len(models.SearchTerms(query)) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+
+	//results come oldest saved first
+	page, err := cursorPage(c, models.PostFilter{}.SortKey())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	posts, next, opStatus, err := h.BookmarkUsecase.SearchPosts(c.Request.Context(), c.Param("user_id"), query, page)
+	if err != nil {
+		//return 404 not found
+		if status.Is(opStatus, status.BookmarkNotExist) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.OperationUnauthorized) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.OperationForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.HttpPaginationResponse{
+		PerPage:    page.PerPage,
+		NextCursor: encodeCursor(next),
+		HttpResponse: responses.HttpResponse{
+			Data:       posts,
+			StatusCode: http.StatusOK,
+		},
+	})
+}
+
+func (h BookmarkHandler) IsBookmarked(c *gin.Context) {
+
+	postIDs := make([]string, 0)
+	for _, id := range strings.Split(c.Query("post_ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			postIDs = append(postIDs, id)
+		}
+	}
+
+	if len(postIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "post_ids is required"})
+		return
+	}
+	if len(postIDs) > requests.MaxLookupPostIDs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many post_ids, maximum is " + strconv.Itoa(requests.MaxLookupPostIDs)})
+		return
+	}
+
+	statuses, opStatus, err := h.BookmarkUsecase.IsBookmarked(c.Request.Context(), c.Param("user_id"), postIDs)
+	if err != nil {
+		if status.Is(opStatus, status.OperationUnauthorized) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.OperationForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.HttpResponse{
+		Data:       statuses,
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h BookmarkHandler) CountBookmarks(c *gin.Context) {
+
+	postIDs := make([]string, 0)
+	for _, id := range strings.Split(c.Query("post_ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			postIDs = append(postIDs, id)
+		}
+	}
+
+	if len(postIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "post_ids is required"})
+		return
+	}
+	if len(postIDs) > requests.MaxLookupPostIDs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many post_ids, maximum is " + strconv.Itoa(requests.MaxLookupPostIDs)})
+		return
+	}
+
+	counts, opStatus, err := h.BookmarkUsecase.CountBookmarks(c.Request.Context(), postIDs)
+	if err != nil {
+		if status.Is(opStatus, status.OperationUnauthorized) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.OperationForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-	val, _ := c.Get("authenticatedRequest")
-	authContext := context.WithValue(context.Background(), "authenticatedRequest", val)
+	c.JSON(http.StatusOK, responses.HttpResponse{
+		Data:       counts,
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h BookmarkHandler) FetchBookmarkingUsers(c *gin.Context) {
+
+	page, ok := c.GetQuery("page")
+	if page == "" || !ok {
+		page = "1"
+	} else if page == "0" {
+		page = "1"
+	}
+
+	qPage, err := strconv.ParseInt(page, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page"})
+		return
+	}
+
+	size, err := perPage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	paginate := models.Pagination{
+		Page:    qPage,
+		PerPage: size,
+	}
+
+	limit, skip := paginate.GetPagination()
+	userIDs, total, opStatus, err := h.BookmarkUsecase.FetchBookmarkingUsers(c.Request.Context(), c.Param("post_id"), limit, skip)
+	if err != nil {
+		if status.Is(opStatus, status.OperationUnauthorized) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.OperationForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.BookmarkPostOwnerUnknown) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.HttpPaginationResponse{
+		PerPage: paginate.PerPage,
+		Page:    paginate.Page,
+		Total:   &total,
+		HttpResponse: responses.HttpResponse{
+			Data:       userIDs,
+			StatusCode: http.StatusOK,
+		},
+	})
+}
+
+func (h BookmarkHandler) Create(c *gin.Context) {
 
 	var createRequest requests.CreateBookmarkRequest
 
 	err := c.ShouldBindJSON(&createRequest)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	bookmark, opStatus, err := h.BookmarkUsecase.Create(authContext, &createRequest)
+	bookmark, opStatus, err := h.BookmarkUsecase.Create(c.Request.Context(), &createRequest)
 	if err != nil {
 		if status.Is(opStatus, status.BookmarkDuplicationOccurs) {
-			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			c.JSON(http.StatusConflict, gin.H{"error": "user already owns a bookmark"})
 			return
 		}
 		if status.Is(opStatus, status.OperationUnauthorized) {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
+		if status.Is(opStatus, status.OperationForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		log.Println("BOOKMARK HANDLER: Create", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, bookmark)
+	c.JSON(http.StatusCreated, bookmark)
 	return
 }
 
-func (h BookmarkHandler) AddPost(c *gin.Context) {
+func (h BookmarkHandler) Delete(c *gin.Context) {
 
-	val, _ := c.Get("authenticatedRequest")
-	authContext := context.WithValue(context.Background(), "authenticatedRequest", val)
+	opStatus, err := h.BookmarkUsecase.Delete(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		//return 404 not found
+		if status.Is(opStatus, status.BookmarkNotExist) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
+			return
+		}
+		if status.Is(opStatus, status.OperationUnauthorized) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.OperationForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		log.Println("BOOKMARK HANDLER: Delete", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h BookmarkHandler) AddPost(c *gin.Context) {
 
 	var addPostReq requests.AddPostBookmarkRequest
 
@@ -136,7 +472,7 @@ func (h BookmarkHandler) AddPost(c *gin.Context) {
 		return
 	}
 
-	opStatus, err := h.BookmarkUsecase.AddPost(authContext, &addPostReq, c.Param("user_id"))
+	opStatus, err := h.BookmarkUsecase.AddPost(c.Request.Context(), &addPostReq, c.Param("user_id"))
 	if err != nil || status.Is(opStatus, status.BookmarkPostFailed) {
 		//return 404 not found
 		if status.Is(opStatus, status.BookmarkNotExist) {
@@ -144,6 +480,10 @@ func (h BookmarkHandler) AddPost(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
 			return
 		}
+		if status.Is(opStatus, status.BookmarkCollectionNotExist) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		if status.Is(opStatus, status.OperationUnauthorized) {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
@@ -164,9 +504,6 @@ func (h BookmarkHandler) AddPost(c *gin.Context) {
 
 func (h BookmarkHandler) RevokePost(c *gin.Context) {
 
-	val, _ := c.Get("authenticatedRequest")
-	authContext := context.WithValue(context.Background(), "authenticatedRequest", val)
-
 	var revokePostReq requests.DeleteAttachedPostRequest
 
 	err := c.ShouldBindJSON(&revokePostReq)
@@ -175,7 +512,7 @@ func (h BookmarkHandler) RevokePost(c *gin.Context) {
 		return
 	}
 
-	opStatus, err := h.BookmarkUsecase.RevokePost(authContext, &revokePostReq, c.Param("user_id"))
+	opStatus, err := h.BookmarkUsecase.RevokePost(c.Request.Context(), &revokePostReq, c.Param("user_id"))
 	if err != nil || status.Is(opStatus, status.BookmarkPostFailed) {
 		//return 404 not found
 		if status.Is(opStatus, status.BookmarkNotExist) {
@@ -183,6 +520,10 @@ func (h BookmarkHandler) RevokePost(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
 			return
 		}
+		if status.Is(opStatus, status.BookmarkCollectionNotExist) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		if status.Is(opStatus, status.OperationUnauthorized) {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
@@ -198,3 +539,237 @@ func (h BookmarkHandler) RevokePost(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "success"})
 	return
 }
+
+func (h BookmarkHandler) UpdatePost(c *gin.Context) {
+
+	var updatePostReq requests.UpdatePostRequest
+
+	err := c.ShouldBindJSON(&updatePostReq)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	opStatus, err := h.BookmarkUsecase.UpdatePost(c.Request.Context(), &updatePostReq, c.Param("user_id"), c.Param("post_id"))
+	if err != nil {
+		//return 404 not found
+		if status.Is(opStatus, status.BookmarkNotExist) || status.Is(opStatus, status.BookmarkPostNotExist) || status.Is(opStatus, status.BookmarkCollectionNotExist) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.OperationUnauthorized) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.OperationForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		log.Println("BOOKMARK HANDLER: UpdatePost", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "success"})
+}
+
+func (h BookmarkHandler) FetchTrash(c *gin.Context) {
+
+	page, ok := c.GetQuery("page")
+	if page == "" || !ok {
+		page = "1"
+	} else if page == "0" {
+		page = "1"
+	}
+
+	qPage, err := strconv.ParseInt(page, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page"})
+		return
+	}
+
+	size, err := perPage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	paginate := models.Pagination{
+		Page:    qPage,
+		PerPage: size,
+	}
+
+	limit, skip := paginate.GetPagination()
+	bookmarks, opStatus, err := h.BookmarkUsecase.FetchTrash(c.Request.Context(), limit, skip)
+	if err != nil {
+		if status.Is(opStatus, status.OperationUnauthorized) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.HttpPaginationResponse{
+		PerPage: paginate.PerPage,
+		Page:    paginate.Page,
+		HttpResponse: responses.HttpResponse{
+			Data:       bookmarks,
+			StatusCode: http.StatusOK,
+		},
+	})
+}
+
+func (h BookmarkHandler) Restore(c *gin.Context) {
+
+	opStatus, err := h.BookmarkUsecase.Restore(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		//return 404 not found
+		if status.Is(opStatus, status.BookmarkNotExist) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.BookmarkDuplicationOccurs) {
+			c.JSON(http.StatusConflict, gin.H{"error": "user already owns an active bookmark"})
+			return
+		}
+		if status.Is(opStatus, status.OperationUnauthorized) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.OperationForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		log.Println("BOOKMARK HANDLER: Restore", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "success"})
+}
+
+func (h BookmarkHandler) FetchCollections(c *gin.Context) {
+
+	collections, opStatus, err := h.BookmarkUsecase.FetchCollections(c.Request.Context(), c.Param("user_id"))
+	if err != nil {
+		//return 404 not found
+		if status.Is(opStatus, status.BookmarkNotExist) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.OperationUnauthorized) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.OperationForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.HttpResponse{
+		Data:       collections,
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h BookmarkHandler) CreateCollection(c *gin.Context) {
+
+	var createReq requests.CreateCollectionRequest
+
+	err := c.ShouldBindJSON(&createReq)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection, opStatus, err := h.BookmarkUsecase.CreateCollection(c.Request.Context(), &createReq, c.Param("user_id"))
+	if err != nil {
+		if status.Is(opStatus, status.BookmarkCollectionDuplicated) || status.Is(opStatus, status.BookmarkDuplicationOccurs) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.OperationUnauthorized) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.OperationForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		log.Println("BOOKMARK HANDLER: CreateCollection", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, collection)
+}
+
+func (h BookmarkHandler) RenameCollection(c *gin.Context) {
+
+	var renameReq requests.RenameCollectionRequest
+
+	err := c.ShouldBindJSON(&renameReq)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	opStatus, err := h.BookmarkUsecase.RenameCollection(c.Request.Context(), &renameReq, c.Param("user_id"), c.Param("collection_id"))
+	if err != nil {
+		//return 404 not found
+		if status.Is(opStatus, status.BookmarkNotExist) || status.Is(opStatus, status.BookmarkCollectionNotExist) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.BookmarkCollectionDuplicated) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.OperationUnauthorized) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.OperationForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		log.Println("BOOKMARK HANDLER: RenameCollection", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "success"})
+}
+
+func (h BookmarkHandler) DeleteCollection(c *gin.Context) {
+
+	opStatus, err := h.BookmarkUsecase.DeleteCollection(c.Request.Context(), c.Param("user_id"), c.Param("collection_id"))
+	if err != nil {
+		//return 404 not found
+		if status.Is(opStatus, status.BookmarkNotExist) || status.Is(opStatus, status.BookmarkCollectionNotExist) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.BookmarkCollectionImmutable) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.OperationUnauthorized) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if status.Is(opStatus, status.OperationForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		log.Println("BOOKMARK HANDLER: DeleteCollection", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "success"})
+}
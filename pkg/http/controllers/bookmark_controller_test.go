@@ -0,0 +1,353 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"golek_bookmark_service/pkg/contracts"
+	"golek_bookmark_service/pkg/contracts/status"
+	"golek_bookmark_service/pkg/http/middleware"
+	"golek_bookmark_service/pkg/http/requests"
+	"golek_bookmark_service/pkg/models"
+	"golek_bookmark_service/pkg/policy"
+	"golek_bookmark_service/pkg/repositories"
+	"golek_bookmark_service/pkg/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBookmarkUsecase stubs the usecase methods under test, calling any other method panics
+type fakeBookmarkUsecase struct {
+	contracts.BookmarkUsecase
+	create    func(ctx context.Context, request *requests.CreateBookmarkRequest) (models.Bookmark, status.OperationStatus, error)
+	delete    func(ctx context.Context, bookmarkID string) (status.OperationStatus, error)
+	fetchById func(ctx context.Context, id string, exclude []string) (models.Bookmark, status.OperationStatus, error)
+	fetch     func(ctx context.Context, filter models.BookmarkFilter, exclude []string, page models.CursorPage) ([]models.Bookmark, int64, *models.Cursor, status.OperationStatus, error)
+
+	fetchByUserId func(ctx context.Context, userID string, exclude []string, filter models.PostFilter, page models.CursorPage) (models.Bookmark, *models.Cursor, status.OperationStatus, error)
+	searchPosts   func(ctx context.Context, userID string, query string, page models.CursorPage) ([]models.Post, *models.Cursor, status.OperationStatus, error)
+}
+
+func (f fakeBookmarkUsecase) SearchPosts(ctx context.Context, userID string, query string, page models.CursorPage) ([]models.Post, *models.Cursor, status.OperationStatus, error) {
+	return f.searchPosts(ctx, userID, query, page)
+}
+
+func (f fakeBookmarkUsecase) FetchByUserId(ctx context.Context, userID string, exclude []string, filter models.PostFilter, page models.CursorPage) (models.Bookmark, *models.Cursor, status.OperationStatus, error) {
+	return f.fetchByUserId(ctx, userID, exclude, filter, page)
+}
+
+func (f fakeBookmarkUsecase) Fetch(ctx context.Context, filter models.BookmarkFilter, exclude []string, page models.CursorPage) ([]models.Bookmark, int64, *models.Cursor, status.OperationStatus, error) {
+	return f.fetch(ctx, filter, exclude, page)
+}
+
+func (f fakeBookmarkUsecase) Create(ctx context.Context, request *requests.CreateBookmarkRequest) (models.Bookmark, status.OperationStatus, error) {
+	return f.create(ctx, request)
+}
+
+func (f fakeBookmarkUsecase) Delete(ctx context.Context, bookmarkID string) (status.OperationStatus, error) {
+	return f.delete(ctx, bookmarkID)
+}
+
+func (f fakeBookmarkUsecase) FetchById(ctx context.Context, id string, exclude []string) (models.Bookmark, status.OperationStatus, error) {
+	return f.fetchById(ctx, id, exclude)
+}
+
+func setupRouter(usecase contracts.BookmarkUsecase) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	SetupHandler(engine, &usecase, middleware.ValidateRequestHeaderMiddleware)
+	return engine
+}
+
+func performRequest(engine *gin.Engine, method string, path string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-Id", "user-1")
+	req.Header.Set("X-User-Role", "user")
+	req.Header.Set("X-User-Permission", "bookmark:read,bookmark:write")
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestCreateBookmark(t *testing.T) {
+
+	body := `{"user_id":"user-1","posts":[{"id":"6300988647b1637e7974b3d9"}]}`
+
+	cases := []struct {
+		name     string
+		opStatus status.OperationStatus
+		err      error
+		expected int
+	}{
+		{"created", status.BookmarkCreateSuccess, nil, http.StatusCreated},
+		{"duplicated", status.BookmarkDuplicationOccurs, errors.New("duplicate key"), http.StatusConflict},
+		{"not the owner", status.OperationForbidden, errors.New("forbidden"), http.StatusForbidden},
+		{"missing permission", status.OperationUnauthorized, errors.New("unauthorized"), http.StatusUnauthorized},
+		{"database error", status.BookmarkCreateFailed, errors.New("database down"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			engine := setupRouter(fakeBookmarkUsecase{
+				create: func(ctx context.Context, request *requests.CreateBookmarkRequest) (models.Bookmark, status.OperationStatus, error) {
+					authenticated, _ := middleware.Authenticated(ctx)
+					assert.Equal(t, authenticated.UserID, "user-1")
+					assert.Equal(t, request.UserID, "user-1")
+					return models.Bookmark{UserID: request.UserID}, tc.opStatus, tc.err
+				},
+			})
+
+			recorder := performRequest(engine, http.MethodPost, "/api/bookmark/create", body)
+			assert.Equal(t, recorder.Code, tc.expected)
+		})
+	}
+}
+
+func TestCreateBookmarkInvalidBody(t *testing.T) {
+
+	called := false
+	engine := setupRouter(fakeBookmarkUsecase{
+		create: func(ctx context.Context, request *requests.CreateBookmarkRequest) (models.Bookmark, status.OperationStatus, error) {
+			called = true
+			return models.Bookmark{}, status.BookmarkCreateSuccess, nil
+		},
+	})
+
+	recorder := performRequest(engine, http.MethodPost, "/api/bookmark/create", `{"posts":[]}`)
+	assert.Equal(t, recorder.Code, http.StatusBadRequest)
+	assert.Equal(t, called, false)
+}
+
+func TestDeleteBookmark(t *testing.T) {
+
+	cases := []struct {
+		name     string
+		opStatus status.OperationStatus
+		err      error
+		expected int
+	}{
+		{"deleted", status.BookmarkDeleteSuccess, nil, http.StatusNoContent},
+		{"not found", status.BookmarkNotExist, errors.New("not found"), http.StatusNotFound},
+		{"not the owner", status.OperationForbidden, errors.New("forbidden"), http.StatusForbidden},
+		{"missing permission", status.OperationUnauthorized, errors.New("unauthorized"), http.StatusUnauthorized},
+		{"database error", status.BookmarkDeleteFailed, errors.New("database down"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			engine := setupRouter(fakeBookmarkUsecase{
+				delete: func(ctx context.Context, bookmarkID string) (status.OperationStatus, error) {
+					assert.Equal(t, bookmarkID, "6300988647b1637e7974b3d9")
+					return tc.opStatus, tc.err
+				},
+			})
+
+			recorder := performRequest(engine, http.MethodDelete, "/api/bookmark/6300988647b1637e7974b3d9", "")
+			assert.Equal(t, recorder.Code, tc.expected)
+		})
+	}
+}
+
+func TestDeleteBookmarkMalformedID(t *testing.T) {
+
+	repo := repositories.NewBookmarkMemoryRepository()
+	engine := setupRouter(usecase.NewBookmarkUsecase(repo, nil, policy.Default(), usecase.ShowMissingPosts))
+
+	recorder := performRequest(engine, http.MethodDelete, "/api/bookmark/not-an-id", "")
+	assert.Equal(t, recorder.Code, http.StatusNotFound)
+}
+
+func TestFetchBookmarksFilters(t *testing.T) {
+
+	var received models.BookmarkFilter
+	engine := setupRouter(fakeBookmarkUsecase{
+		fetch: func(ctx context.Context, filter models.BookmarkFilter, exclude []string, page models.CursorPage) ([]models.Bookmark, int64, *models.Cursor, status.OperationStatus, error) {
+			received = filter
+			return []models.Bookmark{}, 40, nil, status.OperationSuccess, nil
+		},
+	})
+
+	recorder := performRequest(engine, http.MethodGet,
+		"/api/bookmark/?user_id=user-2&post_id=6300988647b1637e7974b3d9&created_from=2022-09-01T00:00:00Z&sort=-updated_at", "")
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, strings.Contains(recorder.Body.String(), `"total":40`), true)
+
+	createdFrom := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, received, models.BookmarkFilter{
+		UserID:         "user-2",
+		PostID:         "6300988647b1637e7974b3d9",
+		CreatedFrom:    &createdFrom,
+		SortBy:         models.SortByUpdatedAt,
+		SortDescending: true,
+	})
+
+	for _, query := range []string{"sort=name", "post_id=nope", "updated_to=yesterday"} {
+		recorder = performRequest(engine, http.MethodGet, "/api/bookmark/?"+query, "")
+		assert.Equal(t, recorder.Code, http.StatusBadRequest)
+	}
+}
+
+func TestFetchBookmarksCursor(t *testing.T) {
+
+	var received models.CursorPage
+	next := models.Cursor{Sort: "-created_at", ID: "6300988647b1637e7974b3d9"}
+	engine := setupRouter(fakeBookmarkUsecase{
+		fetch: func(ctx context.Context, filter models.BookmarkFilter, exclude []string, page models.CursorPage) ([]models.Bookmark, int64, *models.Cursor, status.OperationStatus, error) {
+			received = page
+			return []models.Bookmark{}, 40, &next, status.OperationSuccess, nil
+		},
+	})
+
+	recorder := performRequest(engine, http.MethodGet, "/api/bookmark/?sort=-created_at", "")
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, received, models.CursorPage{PerPage: 25})
+	assert.Equal(t, strings.Contains(recorder.Body.String(), `"next_cursor":"`+next.Encode()+`"`), true)
+
+	//the cursor given back continues the listing
+	recorder = performRequest(engine, http.MethodGet, "/api/bookmark/?sort=-created_at&per_page=100&cursor="+next.Encode(), "")
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, received, models.CursorPage{After: &next, PerPage: 100})
+
+	for _, query := range []string{
+		"per_page=0", "per_page=101", "per_page=ten",
+		"cursor=garbage",
+		"sort=user_id&cursor=" + next.Encode(),
+	} {
+		recorder = performRequest(engine, http.MethodGet, "/api/bookmark/?"+query, "")
+		assert.Equal(t, recorder.Code, http.StatusBadRequest)
+	}
+}
+
+func TestFetchSavedPostsFilters(t *testing.T) {
+
+	var received models.PostFilter
+	var receivedPage models.CursorPage
+	engine := setupRouter(fakeBookmarkUsecase{
+		fetchByUserId: func(ctx context.Context, userID string, exclude []string, filter models.PostFilter, page models.CursorPage) (models.Bookmark, *models.Cursor, status.OperationStatus, error) {
+			received, receivedPage = filter, page
+			return models.Bookmark{UserID: userID}, nil, status.OperationSuccess, nil
+		},
+	})
+
+	recorder := performRequest(engine, http.MethodGet, "/api/bookmark/u/user-1?tag=go&q=+intro+&sort=-name&per_page=5", "")
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, received, models.PostFilter{Tag: "go", Search: "intro", SortBy: models.PostSortByName, SortDescending: true})
+	assert.Equal(t, receivedPage, models.CursorPage{PerPage: 5})
+
+	recorder = performRequest(engine, http.MethodGet, "/api/bookmark/u/user-1", "")
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, received, models.PostFilter{SortBy: models.PostSortByAddedAt})
+
+	cursor := models.Cursor{Sort: "added_at", ID: "6300988647b1637e7974b3d9"}
+	for _, query := range []string{"sort=title", "sort=-", "sort=name&cursor=" + cursor.Encode()} {
+		recorder = performRequest(engine, http.MethodGet, "/api/bookmark/u/user-1?"+query, "")
+		assert.Equal(t, recorder.Code, http.StatusBadRequest)
+	}
+}
+
+func TestSearchSavedPosts(t *testing.T) {
+
+	var receivedQuery string
+	engine := setupRouter(fakeBookmarkUsecase{
+		searchPosts: func(ctx context.Context, userID string, query string, page models.CursorPage) ([]models.Post, *models.Cursor, status.OperationStatus, error) {
+			receivedQuery = query
+			if userID != "user-1" {
+				return nil, nil, status.OperationForbidden, errors.New("you are not the owner")
+			}
+			return []models.Post{}, &models.Cursor{Sort: "added_at", ID: "6300988647b1637e7974b3d9"}, status.OperationSuccess, nil
+		},
+	})
+
+	recorder := performRequest(engine, http.MethodGet, "/api/bookmark/u/user-1/search?q=+learning+go+&per_page=5", "")
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, receivedQuery, "learning go")
+	assert.Equal(t, strings.Contains(recorder.Body.String(), `"next_cursor"`), true)
+
+	for _, query := range []string{"", "q=+", "q=--", "q=go&sort=name&cursor=bad"} {
+		recorder = performRequest(engine, http.MethodGet, "/api/bookmark/u/user-1/search?"+query, "")
+		assert.Equal(t, recorder.Code, http.StatusBadRequest)
+	}
+
+	recorder = performRequest(engine, http.MethodGet, "/api/bookmark/u/user-2/search?q=go", "")
+	assert.Equal(t, recorder.Code, http.StatusForbidden)
+}
+
+func TestDeleteBookmarkWithoutHeaders(t *testing.T) {
+
+	engine := setupRouter(fakeBookmarkUsecase{})
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/bookmark/6300988647b1637e7974b3d9", nil)
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+
+	assert.Equal(t, recorder.Code, http.StatusBadRequest)
+}
+
+// TestConcurrentRequestsKeepTheirCaller is meant to run under -race, every request must only ever see its own caller
+func TestConcurrentRequestsKeepTheirCaller(t *testing.T) {
+
+	engine := setupRouter(fakeBookmarkUsecase{
+		fetchById: func(ctx context.Context, id string, exclude []string) (models.Bookmark, status.OperationStatus, error) {
+			authenticated, ok := middleware.Authenticated(ctx)
+			if !ok {
+				return models.Bookmark{}, status.OperationUnauthorized, errors.New("request isn't authenticated")
+			}
+			//give the other requests time to overwrite a shared caller
+			time.Sleep(time.Millisecond)
+			return models.Bookmark{UserID: authenticated.UserID}, status.OperationSuccess, nil
+		},
+	})
+
+	var wg sync.WaitGroup
+	mismatches := make(chan string, 200)
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func(userID string) {
+			defer wg.Done()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/bookmark/6300988647b1637e7974b3d9", nil)
+			req.Header.Set("X-User-Id", userID)
+			req.Header.Set("X-User-Role", "user")
+			recorder := httptest.NewRecorder()
+			engine.ServeHTTP(recorder, req)
+
+			if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), `"`+userID+`"`) {
+				mismatches <- userID + ": " + recorder.Body.String()
+			}
+		}("user-" + strconv.Itoa(i))
+	}
+	wg.Wait()
+	close(mismatches)
+
+	for mismatch := range mismatches {
+		t.Error("request served with another caller", mismatch)
+	}
+}
+
+func TestHandlersKeepRequestCancellation(t *testing.T) {
+
+	var usecaseErr error
+	engine := setupRouter(fakeBookmarkUsecase{
+		fetchById: func(ctx context.Context, id string, exclude []string) (models.Bookmark, status.OperationStatus, error) {
+			usecaseErr = ctx.Err()
+			return models.Bookmark{}, status.OperationSuccess, nil
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "/api/bookmark/6300988647b1637e7974b3d9", nil).WithContext(ctx)
+	req.Header.Set("X-User-Id", "user-1")
+	req.Header.Set("X-User-Role", "user")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, usecaseErr, context.Canceled)
+}
@@ -3,12 +3,11 @@ package controllers
 import (
 	"github.com/gin-gonic/gin"
 	"golek_bookmark_service/pkg/contracts"
-	"golek_bookmark_service/pkg/http/middleware"
 	"golek_bookmark_service/pkg/http/responses"
 	"net/http"
 )
 
-func SetupHandler(router *gin.Engine, bookmarkUsecase *contracts.BookmarkUsecase) {
+func SetupHandler(router *gin.Engine, bookmarkUsecase *contracts.BookmarkUsecase, authMiddleware gin.HandlerFunc) {
 	bookmarkHandler := BookmarkHandler{BookmarkUsecase: *bookmarkUsecase}
 
 	router.NoRoute(func(c *gin.Context) {
@@ -28,12 +27,24 @@ func SetupHandler(router *gin.Engine, bookmarkUsecase *contracts.BookmarkUsecase
 	})
 
 	bRoute := router.Group("/api/bookmark/")
-	bRoute.Use(middleware.ValidateRequestHeaderMiddleware)
+	bRoute.Use(authMiddleware)
 	bRoute.GET("/", bookmarkHandler.Fetch)
+	bRoute.GET("/trash", bookmarkHandler.FetchTrash)
+	bRoute.POST("/trash/:id/restore", bookmarkHandler.Restore)
 	bRoute.GET("/:id", bookmarkHandler.FetchById)
 	bRoute.GET("/u/:user_id", bookmarkHandler.FetchByUserID)
-	//bRoute.POST("/create", bookmarkHandler.Create)
+	bRoute.GET("/u/:user_id/bookmarked", bookmarkHandler.IsBookmarked)
+	bRoute.GET("/u/:user_id/search", bookmarkHandler.SearchPosts)
+	bRoute.GET("/posts/count", bookmarkHandler.CountBookmarks)
+	bRoute.GET("/posts/:post_id/users", bookmarkHandler.FetchBookmarkingUsers)
+	bRoute.POST("/create", bookmarkHandler.Create)
+	bRoute.DELETE("/:id", bookmarkHandler.Delete)
 	bRoute.DELETE("/course/:user_id", bookmarkHandler.RevokePost)
 	bRoute.PATCH("/course/:user_id", bookmarkHandler.AddPost)
+	bRoute.PATCH("/u/:user_id/posts/:post_id", bookmarkHandler.UpdatePost)
+	bRoute.GET("/u/:user_id/collections", bookmarkHandler.FetchCollections)
+	bRoute.POST("/u/:user_id/collections", bookmarkHandler.CreateCollection)
+	bRoute.PATCH("/u/:user_id/collections/:collection_id", bookmarkHandler.RenameCollection)
+	bRoute.DELETE("/u/:user_id/collections/:collection_id", bookmarkHandler.DeleteCollection)
 
 }
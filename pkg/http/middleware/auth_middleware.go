@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"golek_bookmark_service/pkg/contracts"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	AuthModeJWT    = "jwt"
+	AuthModeHeader = "header"
+)
+
+var (
+	ErrMissingToken     = errors.New("missing bearer token")
+	ErrUntrustedGateway = errors.New("request didn't come through a trusted gateway")
+)
+
+// tokenClaims are the claims read from a verified token, 'sub' is the user id
+type tokenClaims struct {
+	Role        string `json:"role"`
+	Permissions string `json:"permissions"`
+	jwt.RegisteredClaims
+}
+
+// Authenticator resolve the caller of a request, either from a verified JWT
+// or, in header mode, from the X-User-* headers a trusted gateway has set
+type Authenticator struct {
+	Mode            string
+	algorithm       string
+	secret          []byte
+	keys            map[string]*rsa.PublicKey
+	audience        string
+	issuer          string
+	trustedGateways []*net.IPNet
+}
+
+func NewAuthenticator(config contracts.AuthConfig) (*Authenticator, error) {
+
+	cfg := config.GetAuthConfig()
+	a := &Authenticator{
+		Mode:      cfg["MODE"],
+		algorithm: cfg["JWT_ALGORITHM"],
+		audience:  cfg["JWT_AUDIENCE"],
+		issuer:    cfg["JWT_ISSUER"],
+	}
+	if a.Mode == "" {
+		a.Mode = AuthModeJWT
+	}
+	if a.algorithm == "" {
+		a.algorithm = jwt.SigningMethodHS256.Alg()
+	}
+
+	switch a.Mode {
+	case AuthModeHeader:
+		for _, cidr := range strings.Split(cfg["TRUSTED_GATEWAYS"], ",") {
+			if cidr = strings.TrimSpace(cidr); cidr == "" {
+				continue
+			}
+			_, network, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted gateway %q: %w", cidr, err)
+			}
+			a.trustedGateways = append(a.trustedGateways, network)
+		}
+		//without gateways anyone reaching the service could impersonate any user, admins included
+		if len(a.trustedGateways) == 0 {
+			return nil, errors.New("header mode needs AUTH_TRUSTED_GATEWAYS")
+		}
+
+	case AuthModeJWT:
+		switch a.algorithm {
+		case jwt.SigningMethodHS256.Alg():
+			if cfg["JWT_SECRET"] == "" {
+				return nil, errors.New("HS256 needs AUTH_JWT_SECRET")
+			}
+			a.secret = []byte(cfg["JWT_SECRET"])
+		case jwt.SigningMethodRS256.Alg():
+			if cfg["JWKS_FILE"] == "" {
+				return nil, errors.New("RS256 needs AUTH_JWKS_FILE")
+			}
+			keys, err := loadJWKS(cfg["JWKS_FILE"])
+			if err != nil {
+				return nil, err
+			}
+			a.keys = keys
+		default:
+			return nil, fmt.Errorf("unsupported JWT algorithm %q", a.algorithm)
+		}
+		if a.audience == "" {
+			return nil, errors.New("jwt mode needs AUTH_JWT_AUDIENCE")
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown AUTH_MODE %q", a.Mode)
+	}
+
+	return a, nil
+}
+
+// Middleware authenticate every request of the route group, failing requests are aborted
+func (a *Authenticator) Middleware() gin.HandlerFunc {
+
+	if a.Mode == AuthModeHeader {
+		return func(c *gin.Context) {
+			if !a.TrustedPeer(c.Request.RemoteAddr) {
+				log.Println("Request from untrusted address", c.Request.RemoteAddr)
+				c.JSON(http.StatusForbidden, gin.H{"error": ErrUntrustedGateway.Error()})
+				c.Abort()
+				return
+			}
+			ValidateRequestHeaderMiddleware(c)
+		}
+	}
+
+	return func(c *gin.Context) {
+		authenticated, err := a.VerifyToken(BearerToken(c.Request.Header.Get("Authorization")))
+		if err != nil {
+			log.Println("Request Token is Invalid >>", err)
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Request Token is Invalid"})
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(WithAuthenticated(c.Request.Context(), authenticated))
+		c.Next()
+	}
+}
+
+// VerifyToken check the signature, expiry, audience and issuer of a token and map its claims
+func (a *Authenticator) VerifyToken(token string) (*AuthenticatedRequest, error) {
+
+	if token == "" {
+		return nil, ErrMissingToken
+	}
+
+	claims := &tokenClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{a.algorithm}))
+	if _, err := parser.ParseWithClaims(token, claims, a.key); err != nil {
+		return nil, err
+	}
+
+	//the parser accepts tokens without an expiry, we don't
+	if !claims.VerifyExpiresAt(time.Now(), true) {
+		return nil, errors.New("token has no expiry or is expired")
+	}
+	if !claims.VerifyAudience(a.audience, true) {
+		return nil, errors.New("token audience mismatch")
+	}
+	if a.issuer != "" && !claims.VerifyIssuer(a.issuer, true) {
+		return nil, errors.New("token issuer mismatch")
+	}
+	//the permissions claim is optional, it only adds to the grants of the role
+	if claims.Subject == "" || claims.Role == "" {
+		return nil, errors.New("token lacks sub or role")
+	}
+
+	return &AuthenticatedRequest{
+		UserID:      claims.Subject,
+		Role:        claims.Role,
+		Permissions: claims.Permissions,
+	}, nil
+}
+
+// TrustedPeer report whether the direct peer may set identity headers, no peer is trusted when no gateway is configured
+func (a *Authenticator) TrustedPeer(remoteAddr string) bool {
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range a.trustedGateways {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Authenticator) key(token *jwt.Token) (interface{}, error) {
+
+	if a.secret != nil {
+		return a.secret, nil
+	}
+
+	//tokens without a key id are accepted when the set holds a single key
+	kid, _ := token.Header["kid"].(string)
+	if key, ok := a.keys[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(a.keys) == 1 {
+		for _, key := range a.keys {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+// BearerToken extract the token of an "Authorization: Bearer <token>" value, empty when there is none
+func BearerToken(header string) string {
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type authConfig map[string]string
+
+func (c authConfig) GetAuthConfig() map[string]string {
+	return c
+}
+
+func signHS256(t *testing.T, secret string, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":         "user-1",
+		"role":        "user",
+		"permissions": "bookmark:read,bookmark:write",
+		"aud":         "bookmarks",
+		"exp":         time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestVerifyTokenHS256(t *testing.T) {
+
+	authenticator, err := NewAuthenticator(authConfig{"JWT_SECRET": "secret", "JWT_AUDIENCE": "bookmarks"})
+	assert.Equal(t, err, nil)
+
+	authenticated, err := authenticator.VerifyToken(signHS256(t, "secret", validClaims()))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, *authenticated, AuthenticatedRequest{UserID: "user-1", Role: "user", Permissions: "bookmark:read,bookmark:write"})
+
+	cases := []struct {
+		name   string
+		secret string
+		change func(claims jwt.MapClaims)
+	}{
+		{"wrong secret", "other", func(claims jwt.MapClaims) {}},
+		{"expired", "secret", func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() }},
+		{"without expiry", "secret", func(claims jwt.MapClaims) { delete(claims, "exp") }},
+		{"other audience", "secret", func(claims jwt.MapClaims) { claims["aud"] = "payments" }},
+		{"without subject", "secret", func(claims jwt.MapClaims) { delete(claims, "sub") }},
+		{"without role", "secret", func(claims jwt.MapClaims) { delete(claims, "role") }},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			claims := validClaims()
+			tc.change(claims)
+			_, err := authenticator.VerifyToken(signHS256(t, tc.secret, claims))
+			assert.NotEqual(t, err, nil)
+		})
+	}
+
+	//unsigned tokens never pass
+	unsigned, _ := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	_, err = authenticator.VerifyToken(unsigned)
+	assert.NotEqual(t, err, nil)
+}
+
+func TestVerifyTokenRS256WithJWKS(t *testing.T) {
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Equal(t, err, nil)
+
+	jwks, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": "key-1",
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	assert.Equal(t, os.WriteFile(path, jwks, 0600), nil)
+
+	authenticator, err := NewAuthenticator(authConfig{"JWT_ALGORITHM": "RS256", "JWKS_FILE": path, "JWT_AUDIENCE": "bookmarks"})
+	assert.Equal(t, err, nil)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, validClaims())
+	token.Header["kid"] = "key-1"
+	signed, _ := token.SignedString(key)
+
+	authenticated, err := authenticator.VerifyToken(signed)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, authenticated.UserID, "user-1")
+
+	//an HS256 token can't be forged with the public key as its secret
+	_, err = authenticator.VerifyToken(signHS256(t, string(jwks), validClaims()))
+	assert.NotEqual(t, err, nil)
+
+	token.Header["kid"] = "unknown"
+	signed, _ = token.SignedString(key)
+	_, err = authenticator.VerifyToken(signed)
+	assert.NotEqual(t, err, nil)
+}
+
+func TestNewAuthenticatorRejectsIncompleteConfig(t *testing.T) {
+
+	for _, cfg := range []authConfig{
+		{"JWT_AUDIENCE": "bookmarks"},
+		{"JWT_SECRET": "secret"},
+		{"JWT_ALGORITHM": "RS256", "JWT_AUDIENCE": "bookmarks"},
+		{"JWT_ALGORITHM": "none", "JWT_SECRET": "secret", "JWT_AUDIENCE": "bookmarks"},
+		{"MODE": "header", "TRUSTED_GATEWAYS": "not-a-cidr"},
+		//header mode never trusts every peer
+		{"MODE": "header"},
+		{"MODE": "header", "TRUSTED_GATEWAYS": " , "},
+		{"MODE": "anonymous"},
+	} {
+		_, err := NewAuthenticator(cfg)
+		assert.NotEqual(t, err, nil)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+	serve := func(authenticator *Authenticator, remoteAddr string, headers map[string]string) int {
+		engine := gin.New()
+		engine.GET("/", authenticator.Middleware(), func(c *gin.Context) {
+			authenticated, _ := Authenticated(c.Request.Context())
+			c.JSON(http.StatusOK, authenticated)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	jwtAuthenticator, _ := NewAuthenticator(authConfig{"JWT_SECRET": "secret", "JWT_AUDIENCE": "bookmarks"})
+	identity := map[string]string{"X-User-Id": "admin", "X-User-Role": "admin", "X-User-Permission": "bookmark:read,bookmark:write"}
+
+	assert.Equal(t, serve(jwtAuthenticator, "10.0.0.1:1234", map[string]string{
+		"Authorization": "Bearer " + signHS256(t, "secret", validClaims()),
+	}), http.StatusOK)
+	//identity headers mean nothing in jwt mode
+	assert.Equal(t, serve(jwtAuthenticator, "10.0.0.1:1234", identity), http.StatusUnauthorized)
+
+	headerAuthenticator, _ := NewAuthenticator(authConfig{"MODE": "header", "TRUSTED_GATEWAYS": "10.0.0.0/24"})
+	assert.Equal(t, serve(headerAuthenticator, "10.0.0.1:1234", identity), http.StatusOK)
+	assert.Equal(t, serve(headerAuthenticator, "192.168.1.5:1234", identity), http.StatusForbidden)
+	assert.Equal(t, serve(headerAuthenticator, "10.0.0.1:1234", map[string]string{}), http.StatusBadRequest)
+
+	//an authenticator built without gateways trusts nobody
+	assert.Equal(t, (&Authenticator{Mode: AuthModeHeader}).TrustedPeer("10.0.0.1:1234"), false)
+}
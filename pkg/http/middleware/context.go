@@ -0,0 +1,17 @@
+package middleware
+
+import "context"
+
+// authenticatedKey is unexported so no other package can read or overwrite the caller identity
+type authenticatedKey struct{}
+
+// WithAuthenticated return a copy of ctx carrying the authenticated caller
+func WithAuthenticated(ctx context.Context, authenticated *AuthenticatedRequest) context.Context {
+	return context.WithValue(ctx, authenticatedKey{}, authenticated)
+}
+
+// Authenticated return the caller stored by WithAuthenticated, ok is false for anonymous contexts
+func Authenticated(ctx context.Context) (authenticated *AuthenticatedRequest, ok bool) {
+	authenticated, ok = ctx.Value(authenticatedKey{}).(*AuthenticatedRequest)
+	return authenticated, ok && authenticated != nil
+}
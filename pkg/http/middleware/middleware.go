@@ -12,25 +12,25 @@ type AuthenticatedRequest struct {
 	Permissions string
 }
 
-var authenticated *AuthenticatedRequest
-
 func ValidateRequestHeaderMiddleware(c *gin.Context) {
 
 	userPermission := c.Request.Header.Get("X-User-Permission")
 	userRole := c.Request.Header.Get("X-User-Role")
 	userId := c.Request.Header.Get("X-User-Id")
 
-	if userId != "" && userRole != "" && userPermission != "" {
+	//permissions are optional, the role already grants them
+	if userId != "" && userRole != "" {
 
 		log.Println("Request Header is Valid")
 
-		authenticated = &AuthenticatedRequest{
+		authenticated := &AuthenticatedRequest{
 			Permissions: userPermission,
 			UserID:      userId,
 			Role:        userRole,
 		}
 
-		c.Set("authenticatedRequest", authenticated)
+		//scoped to this request, the handler and usecase read it back from c.Request.Context()
+		c.Request = c.Request.WithContext(WithAuthenticated(c.Request.Context(), authenticated))
 		c.Next()
 
 	} else {
@@ -1,20 +1,41 @@
 package requests
 
+// MaxLookupPostIDs caps how many post ids a single bookmarked lookup may ask for, over HTTP and gRPC
+const MaxLookupPostIDs = 100
+
 type CreateBookmarkRequest struct {
 	UserID string `json:"user_id" binding:"required"`
 	Posts  []Post `json:"posts" binding:"required,dive"`
 }
 
 type AddPostBookmarkRequest struct {
-	UserID string `json:"user_id" binding:"required"`
-	Posts  []Post `json:"posts" binding:"required,dive"`
+	UserID       string `json:"user_id" binding:"required"`
+	CollectionID string `json:"collection_id"`
+	Posts        []Post `json:"posts" binding:"required,dive"`
 }
 
 type DeleteAttachedPostRequest struct {
-	UserID string `json:"user_id" binding:"required"`
-	Posts  []Post `json:"posts" binding:"required,dive"`
+	UserID       string `json:"user_id" binding:"required"`
+	CollectionID string `json:"collection_id"`
+	Posts        []Post `json:"posts" binding:"required,dive"`
 }
 
 type Post struct {
 	ID string `json:"id" binding:"required"`
 }
+
+// UpdatePostRequest edit the metadata of a saved post, omitted fields stay untouched
+// and an empty 'tags' array clears every tag
+type UpdatePostRequest struct {
+	CollectionID string   `json:"collection_id"`
+	Note         *string  `json:"note" binding:"omitempty,max=500"`
+	Tags         []string `json:"tags" binding:"omitempty,max=20,dive,min=1,max=32"`
+}
+
+type CreateCollectionRequest struct {
+	Name string `json:"name" binding:"required,max=64"`
+}
+
+type RenameCollectionRequest struct {
+	Name string `json:"name" binding:"required,max=64"`
+}
@@ -7,7 +7,10 @@ type HttpResponse struct {
 }
 
 type HttpPaginationResponse struct {
-	PerPage int64 `json:"per_page"`
-	Page    int64 `json:"page"`
+	PerPage int64  `json:"per_page"`
+	Page    int64  `json:"page,omitempty"`
+	Total   *int64 `json:"total,omitempty"`
+	// NextCursor is passed back as 'cursor' to get the following page, empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
 	HttpResponse
 }
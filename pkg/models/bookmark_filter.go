@@ -0,0 +1,64 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// fields a bookmark listing can be sorted by, an empty SortBy sorts by id which follows creation order
+const (
+	SortByCreatedAt = "created_at"
+	SortByUpdatedAt = "updated_at"
+	SortByUserID    = "user_id"
+)
+
+// BookmarkFilter narrows a bookmark listing, zero values match every bookmark.
+// date bounds are inclusive
+type BookmarkFilter struct {
+	UserID         string
+	PostID         string
+	CreatedFrom    *time.Time
+	CreatedTo      *time.Time
+	UpdatedFrom    *time.Time
+	UpdatedTo      *time.Time
+	SortBy         string
+	SortDescending bool
+}
+
+// ParseSort read a sort like "created_at" or "-updated_at", a leading '-' sorts descending.
+// ok is false for fields listings can't be sorted by
+func ParseSort(sort string) (field string, descending bool, ok bool) {
+
+	field = strings.TrimPrefix(sort, "-")
+	descending = field != sort
+
+	switch field {
+	case "", SortByCreatedAt, SortByUpdatedAt, SortByUserID:
+		return field, descending && field != "", true
+	}
+
+	return "", false, false
+}
+
+// Match report whether the bookmark passes every criteria of the filter
+func (f BookmarkFilter) Match(b Bookmark) bool {
+
+	if f.UserID != "" && b.UserID != f.UserID {
+		return false
+	}
+	if f.PostID != "" && !b.HasPost(f.PostID, "") {
+		return false
+	}
+
+	return inRange(b.CreatedAt, f.CreatedFrom, f.CreatedTo) && inRange(b.UpdatedAt, f.UpdatedFrom, f.UpdatedTo)
+}
+
+func inRange(t *time.Time, from *time.Time, to *time.Time) bool {
+	if from == nil && to == nil {
+		return true
+	}
+	if t == nil {
+		return false
+	}
+	return (from == nil || !t.Before(*from)) && (to == nil || !t.After(*to))
+}
@@ -5,17 +5,90 @@ import (
 	"time"
 )
 
+// DefaultCollectionName is the name given to the collection every bookmark starts with,
+// posts saved without a collection id end up here
+const DefaultCollectionName = "Saved"
+
 type Bookmark struct {
-	ID        primitive.ObjectID `json:"id" bson:"_id"`
-	UserID    string             `json:"user_id" bson:"user_id"`
-	Posts     []Post             `json:"posts" bson:"posts"`
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	UserID      string             `json:"user_id" bson:"user_id"`
+	Collections []Collection       `json:"collections" bson:"collections"`
+	Posts       []Post             `json:"posts" bson:"posts"`
+	UpdatedAt   *time.Time         `json:"updated_at,omitempty" bson:"updated_at"`
+	CreatedAt   *time.Time         `json:"created_at,omitempty" bson:"created_at"`
+	DeletedAt   *time.Time         `json:"deleted_at,omitempty" bson:"deleted_at"`
+}
+
+// DefaultCollection returns the collection flagged as default, ok is false for bookmarks
+// that have not been migrated yet
+func (b Bookmark) DefaultCollection() (collection Collection, ok bool) {
+	for _, c := range b.Collections {
+		if c.IsDefault {
+			return c, true
+		}
+	}
+	return Collection{}, false
+}
+
+// HasPost check whether the post is saved in the collection, an empty 'collectionID' matches any collection
+func (b Bookmark) HasPost(postID string, collectionID string) bool {
+	for _, p := range b.Posts {
+		if p.ID.Hex() == postID && (collectionID == "" || p.CollectionID.Hex() == collectionID) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindCollection look up a collection by its hex id
+func (b Bookmark) FindCollection(collectionID string) (collection Collection, ok bool) {
+	for _, c := range b.Collections {
+		if c.ID.Hex() == collectionID {
+			return c, true
+		}
+	}
+	return Collection{}, false
+}
+
+type Collection struct {
+	ID        primitive.ObjectID `json:"id" bson:"id"`
+	Name      string             `json:"name" bson:"name"`
+	IsDefault bool               `json:"is_default" bson:"is_default"`
+	PostCount int                `json:"post_count" bson:"-"`
 	UpdatedAt *time.Time         `json:"updated_at,omitempty" bson:"updated_at"`
 	CreatedAt *time.Time         `json:"created_at,omitempty" bson:"created_at"`
-	DeletedAt *time.Time         `json:"deleted_at,omitempty" bson:"deleted_at"`
 }
 
+// Post is a saved post, Name and ImageUrl snapshot the post service details as of SnapshotAt
+// so saved posts still read and search when the post service is down. OwnerID, the author of the post,
+// is only set on posts returned by the post service
 type Post struct {
-	ID       primitive.ObjectID `json:"id" bson:"id"`
-	Name     string             `json:"name,omitempty" bson:"-"`
-	ImageUrl string             `json:"image_url,omitempty" bson:"-"`
+	ID           primitive.ObjectID `json:"id" bson:"id"`
+	CollectionID primitive.ObjectID `json:"collection_id" bson:"collection_id"`
+	AddedAt      *time.Time         `json:"added_at,omitempty" bson:"added_at"`
+	Note         string             `json:"note,omitempty" bson:"note"`
+	Tags         []string           `json:"tags,omitempty" bson:"tags"`
+	Name         string             `json:"name,omitempty" bson:"name,omitempty"`
+	ImageUrl     string             `json:"image_url,omitempty" bson:"image_url,omitempty"`
+	SnapshotAt   *time.Time         `json:"snapshot_at,omitempty" bson:"snapshot_at,omitempty"`
+	Availability PostAvailability   `json:"availability,omitempty" bson:"-"`
+	OwnerID      string             `json:"-" bson:"-"`
+}
+
+// PostAvailability tells how a saved post stands with the post service as of the read that returned it, it isn't stored
+type PostAvailability string
+
+const (
+	// PostAvailable posts were found, their details are current
+	PostAvailable PostAvailability = "ok"
+	// PostDeleted posts were reported deleted by the post service
+	PostDeleted PostAvailability = "deleted"
+	// PostUnavailable posts couldn't be looked up or were left out of the answer, their details are the stored snapshot if any
+	PostUnavailable PostAvailability = "unavailable"
+)
+
+// BookmarkStatus tells whether a single post is saved by the user, SavedAt holds the earliest save across collections
+type BookmarkStatus struct {
+	Bookmarked bool       `json:"bookmarked"`
+	SavedAt    *time.Time `json:"saved_at,omitempty"`
 }
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks the last item of a page, the next page starts right after it.
+// clients only ever see it through Encode, its fields aren't part of the API
+type Cursor struct {
+	// Sort is the ordering the cursor was issued for, it can't continue a listing sorted otherwise
+	Sort         string     `json:"o,omitempty"`
+	ID           string     `json:"i"`
+	CollectionID string     `json:"c,omitempty"`
+	Time         *time.Time `json:"t,omitempty"`
+	Text         string     `json:"s,omitempty"`
+}
+
+// CursorPage ask for at most PerPage items following After, a nil After starts from the first item
+// and a zero PerPage means no limit
+type CursorPage struct {
+	After   *Cursor
+	PerPage int64
+}
+
+func (c Cursor) Encode() string {
+	encoded, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// DecodeCursor read a cursor given by Encode, an empty string is no cursor at all
+func DecodeCursor(encoded string) (*Cursor, error) {
+
+	if encoded == "" {
+		return nil, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(decoded, &cursor); err != nil || !primitive.IsValidObjectID(cursor.ID) {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// SortKey name the ordering of the filter the way ParseSort reads it
+func (f BookmarkFilter) SortKey() string {
+	if f.SortDescending {
+		return "-" + f.SortBy
+	}
+	return f.SortBy
+}
+
+// CursorOf return the cursor continuing a listing right after 'b'
+func (f BookmarkFilter) CursorOf(b Bookmark) Cursor {
+
+	cursor := Cursor{Sort: f.SortKey(), ID: b.ID.Hex()}
+	switch f.SortBy {
+	case SortByCreatedAt:
+		cursor.Time = b.CreatedAt
+	case SortByUpdatedAt:
+		cursor.Time = b.UpdatedAt
+	case SortByUserID:
+		cursor.Text = b.UserID
+	}
+
+	return cursor
+}
+
+// Less order bookmarks by the filter's sort then by id, a missing date sorts before any date
+// like a null does in Mongo
+func (f BookmarkFilter) Less(a Bookmark, b Bookmark) bool {
+	return f.compare(a, f.CursorOf(b)) < 0
+}
+
+// After report whether 'b' comes after the cursor in the filter's ordering
+func (f BookmarkFilter) After(b Bookmark, cursor Cursor) bool {
+	return f.compare(b, cursor) > 0
+}
+
+func (f BookmarkFilter) compare(b Bookmark, cursor Cursor) int {
+
+	order := 0
+	switch f.SortBy {
+	case SortByCreatedAt:
+		order = compareTimes(b.CreatedAt, cursor.Time)
+	case SortByUpdatedAt:
+		order = compareTimes(b.UpdatedAt, cursor.Time)
+	case SortByUserID:
+		order = strings.Compare(b.UserID, cursor.Text)
+	}
+	if f.SortDescending {
+		order = -order
+	}
+	if order != 0 {
+		return order
+	}
+
+	//ties always go by ascending id, hex ids compare like the ObjectIDs they encode
+	return strings.Compare(b.ID.Hex(), cursor.ID)
+}
+
+func compareTimes(a *time.Time, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	case a.Before(*b):
+		return -1
+	case a.After(*b):
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// OutboxCollectionName is the Mongo collection events wait in until the relay publishes them
+const OutboxCollectionName = "bookmark_outbox"
+
+// OutboxEvent is a domain event written in the same transaction as the change it reports,
+// it stays in the outbox until published on Subject
+type OutboxEvent struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	Subject   string             `json:"subject" bson:"subject"`
+	Payload   []byte             `json:"payload" bson:"payload"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
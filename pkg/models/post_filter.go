@@ -0,0 +1,114 @@
+package models
+
+import (
+	"strings"
+)
+
+// fields saved posts can be sorted by, names come from the post service
+const (
+	PostSortByAddedAt = "added_at"
+	PostSortByName    = "name"
+)
+
+// PostFilter narrows the saved posts of a bookmark, zero values keep every post oldest saved first.
+// Tag and Search match case insensitively, Search looks into the post name
+type PostFilter struct {
+	Tag            string
+	Search         string
+	SortBy         string
+	SortDescending bool
+}
+
+// ParsePostSort read a sort like "added_at" or "-name", a leading '-' sorts descending.
+// an empty sort is "added_at", ok is false for fields posts can't be sorted by
+func ParsePostSort(sort string) (field string, descending bool, ok bool) {
+
+	field = strings.TrimPrefix(sort, "-")
+	descending = field != sort
+
+	switch field {
+	case "":
+		return PostSortByAddedAt, false, !descending
+	case PostSortByAddedAt, PostSortByName:
+		return field, descending, true
+	}
+
+	return "", false, false
+}
+
+// NeedsDetails tells whether matching or sorting relies on the details from the post service
+func (f PostFilter) NeedsDetails() bool {
+	return f.Search != "" || f.SortBy == PostSortByName
+}
+
+// SortKey name the ordering of the filter the way ParsePostSort reads it
+func (f PostFilter) SortKey() string {
+	sortBy := f.SortBy
+	if sortBy == "" {
+		sortBy = PostSortByAddedAt
+	}
+	if f.SortDescending {
+		return "-" + sortBy
+	}
+	return sortBy
+}
+
+// Match report whether the post passes every criteria of the filter
+func (f PostFilter) Match(p Post) bool {
+
+	if f.Tag != "" {
+		tagged := false
+		for _, tag := range p.Tags {
+			if strings.EqualFold(tag, f.Tag) {
+				tagged = true
+				break
+			}
+		}
+		if !tagged {
+			return false
+		}
+	}
+
+	return f.Search == "" || strings.Contains(strings.ToLower(p.Name), strings.ToLower(f.Search))
+}
+
+// CursorOf return the cursor continuing saved posts right after 'p'
+func (f PostFilter) CursorOf(p Post) Cursor {
+	cursor := Cursor{Sort: f.SortKey(), ID: p.ID.Hex(), CollectionID: p.CollectionID.Hex(), Time: p.AddedAt}
+	if f.SortBy == PostSortByName {
+		cursor.Text = p.Name
+	}
+	return cursor
+}
+
+// Less order posts by the filter's sort, then by the time they were added, post id and collection id
+func (f PostFilter) Less(a Post, b Post) bool {
+	return f.compare(a, f.CursorOf(b)) < 0
+}
+
+// After report whether 'p' comes after the cursor in the filter's ordering
+func (f PostFilter) After(p Post, cursor Cursor) bool {
+	return f.compare(p, cursor) > 0
+}
+
+func (f PostFilter) compare(p Post, cursor Cursor) int {
+
+	order := 0
+	if f.SortBy == PostSortByName {
+		order = strings.Compare(strings.ToLower(p.Name), strings.ToLower(cursor.Text))
+	}
+	if order == 0 {
+		order = compareTimes(p.AddedAt, cursor.Time)
+	}
+	if f.SortDescending {
+		order = -order
+	}
+	if order != 0 {
+		return order
+	}
+
+	if order := strings.Compare(p.ID.Hex(), cursor.ID); order != 0 {
+		return order
+	}
+	return strings.Compare(p.CollectionID.Hex(), cursor.CollectionID)
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SearchTerms split a search query into lowercase words, words are runs of letters and digits
+// the way the text indexes of Mongo and Postgres tokenize them
+func SearchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// MatchTerms report whether every term is a whole word of the post name snapshot, note or tags.
+// no terms match no post
+func (p Post) MatchTerms(terms []string) bool {
+
+	if len(terms) == 0 {
+		return false
+	}
+
+	words := make(map[string]bool)
+	for _, word := range SearchTerms(p.Name + " " + p.Note + " " + strings.Join(p.Tags, " ")) {
+		words[word] = true
+	}
+
+	for _, term := range terms {
+		if !words[term] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,213 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.21.4
+// source: bookmark.proto
+
+package __
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// BookmarkServiceClient is the client API for BookmarkService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BookmarkServiceClient interface {
+	FetchByUserId(ctx context.Context, in *BookmarkUserID, opts ...grpc.CallOption) (*Bookmark, error)
+	AddPost(ctx context.Context, in *BookmarkPostsRequest, opts ...grpc.CallOption) (*BookmarkPostsResponse, error)
+	RevokePost(ctx context.Context, in *BookmarkPostsRequest, opts ...grpc.CallOption) (*BookmarkPostsResponse, error)
+	IsBookmarked(ctx context.Context, in *IsBookmarkedRequest, opts ...grpc.CallOption) (*IsBookmarkedResponse, error)
+}
+
+type bookmarkServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBookmarkServiceClient(cc grpc.ClientConnInterface) BookmarkServiceClient {
+	return &bookmarkServiceClient{cc}
+}
+
+func (c *bookmarkServiceClient) FetchByUserId(ctx context.Context, in *BookmarkUserID, opts ...grpc.CallOption) (*Bookmark, error) {
+	out := new(Bookmark)
+	err := c.cc.Invoke(ctx, "/model.BookmarkService/FetchByUserId", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookmarkServiceClient) AddPost(ctx context.Context, in *BookmarkPostsRequest, opts ...grpc.CallOption) (*BookmarkPostsResponse, error) {
+	out := new(BookmarkPostsResponse)
+	err := c.cc.Invoke(ctx, "/model.BookmarkService/AddPost", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookmarkServiceClient) RevokePost(ctx context.Context, in *BookmarkPostsRequest, opts ...grpc.CallOption) (*BookmarkPostsResponse, error) {
+	out := new(BookmarkPostsResponse)
+	err := c.cc.Invoke(ctx, "/model.BookmarkService/RevokePost", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookmarkServiceClient) IsBookmarked(ctx context.Context, in *IsBookmarkedRequest, opts ...grpc.CallOption) (*IsBookmarkedResponse, error) {
+	out := new(IsBookmarkedResponse)
+	err := c.cc.Invoke(ctx, "/model.BookmarkService/IsBookmarked", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BookmarkServiceServer is the server API for BookmarkService service.
+// All implementations must embed UnimplementedBookmarkServiceServer
+// for forward compatibility
+type BookmarkServiceServer interface {
+	FetchByUserId(context.Context, *BookmarkUserID) (*Bookmark, error)
+	AddPost(context.Context, *BookmarkPostsRequest) (*BookmarkPostsResponse, error)
+	RevokePost(context.Context, *BookmarkPostsRequest) (*BookmarkPostsResponse, error)
+	IsBookmarked(context.Context, *IsBookmarkedRequest) (*IsBookmarkedResponse, error)
+	mustEmbedUnimplementedBookmarkServiceServer()
+}
+
+// UnimplementedBookmarkServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedBookmarkServiceServer struct {
+}
+
+func (UnimplementedBookmarkServiceServer) FetchByUserId(context.Context, *BookmarkUserID) (*Bookmark, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchByUserId not implemented")
+}
+func (UnimplementedBookmarkServiceServer) AddPost(context.Context, *BookmarkPostsRequest) (*BookmarkPostsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddPost not implemented")
+}
+func (UnimplementedBookmarkServiceServer) RevokePost(context.Context, *BookmarkPostsRequest) (*BookmarkPostsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokePost not implemented")
+}
+func (UnimplementedBookmarkServiceServer) IsBookmarked(context.Context, *IsBookmarkedRequest) (*IsBookmarkedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IsBookmarked not implemented")
+}
+func (UnimplementedBookmarkServiceServer) mustEmbedUnimplementedBookmarkServiceServer() {}
+
+// UnsafeBookmarkServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BookmarkServiceServer will
+// result in compilation errors.
+type UnsafeBookmarkServiceServer interface {
+	mustEmbedUnimplementedBookmarkServiceServer()
+}
+
+func RegisterBookmarkServiceServer(s grpc.ServiceRegistrar, srv BookmarkServiceServer) {
+	s.RegisterService(&BookmarkService_ServiceDesc, srv)
+}
+
+func _BookmarkService_FetchByUserId_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BookmarkUserID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookmarkServiceServer).FetchByUserId(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/model.BookmarkService/FetchByUserId",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookmarkServiceServer).FetchByUserId(ctx, req.(*BookmarkUserID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookmarkService_AddPost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BookmarkPostsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookmarkServiceServer).AddPost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/model.BookmarkService/AddPost",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookmarkServiceServer).AddPost(ctx, req.(*BookmarkPostsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookmarkService_RevokePost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BookmarkPostsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookmarkServiceServer).RevokePost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/model.BookmarkService/RevokePost",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookmarkServiceServer).RevokePost(ctx, req.(*BookmarkPostsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookmarkService_IsBookmarked_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IsBookmarkedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookmarkServiceServer).IsBookmarked(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/model.BookmarkService/IsBookmarked",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookmarkServiceServer).IsBookmarked(ctx, req.(*IsBookmarkedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// BookmarkService_ServiceDesc is the grpc.ServiceDesc for BookmarkService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BookmarkService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "model.BookmarkService",
+	HandlerType: (*BookmarkServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "FetchByUserId",
+			Handler:    _BookmarkService_FetchByUserId_Handler,
+		},
+		{
+			MethodName: "AddPost",
+			Handler:    _BookmarkService_AddPost_Handler,
+		},
+		{
+			MethodName: "RevokePost",
+			Handler:    _BookmarkService_RevokePost_Handler,
+		},
+		{
+			MethodName: "IsBookmarked",
+			Handler:    _BookmarkService_IsBookmarked_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "bookmark.proto",
+}
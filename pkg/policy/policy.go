@@ -0,0 +1,107 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Permission string
+
+const (
+	// PermissionRead lets a caller read their own bookmarks
+	PermissionRead Permission = "bookmark:read"
+	// PermissionWrite lets a caller change their own bookmarks
+	PermissionWrite Permission = "bookmark:write"
+	// PermissionAdmin lets a caller act on any user's bookmarks, it overrides owner checks
+	// and implies every other permission
+	PermissionAdmin Permission = "bookmark:admin"
+)
+
+var knownPermissions = map[Permission]bool{
+	PermissionRead:  true,
+	PermissionWrite: true,
+	PermissionAdmin: true,
+}
+
+// Policy grants permissions to roles, a caller holds the permissions of their role
+// plus the named permissions carried by their credentials
+type Policy struct {
+	grants map[string]map[Permission]bool
+}
+
+func New(grants map[string][]Permission) *Policy {
+	p := &Policy{grants: make(map[string]map[Permission]bool)}
+	for role, permissions := range grants {
+		p.grants[role] = make(map[Permission]bool)
+		for _, permission := range permissions {
+			p.grants[role][permission] = true
+		}
+	}
+	return p
+}
+
+// Default is used when no mapping is configured
+func Default() *Policy {
+	return New(map[string][]Permission{
+		"user":  {PermissionRead, PermissionWrite},
+		"admin": {PermissionRead, PermissionWrite, PermissionAdmin},
+	})
+}
+
+// Parse read a role mapping like "user=bookmark:read,bookmark:write;admin=bookmark:admin",
+// an empty spec gives the Default policy
+func Parse(spec string) (*Policy, error) {
+
+	if strings.TrimSpace(spec) == "" {
+		return Default(), nil
+	}
+
+	grants := make(map[string][]Permission)
+	for _, entry := range strings.Split(spec, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		role, permissions, ok := strings.Cut(entry, "=")
+		role = strings.TrimSpace(role)
+		if !ok || role == "" {
+			return nil, fmt.Errorf("invalid role mapping %q, expected role=permission,...", entry)
+		}
+
+		grants[role] = make([]Permission, 0)
+		for _, permission := range strings.Split(permissions, ",") {
+			permission := Permission(strings.TrimSpace(permission))
+			if permission == "" {
+				continue
+			}
+			if !knownPermissions[permission] {
+				return nil, fmt.Errorf("unknown permission %q for role %q", permission, role)
+			}
+			grants[role] = append(grants[role], permission)
+		}
+	}
+
+	return New(grants), nil
+}
+
+// Can report whether a caller with 'role' and the credential 'permissions' holds 'permission',
+// holding PermissionAdmin is enough for any of them.
+// credential permissions are whole names separated by commas or spaces, anything else grants nothing
+func (p *Policy) Can(role string, permissions string, permission Permission) bool {
+	return p.holds(role, permissions, permission) || p.holds(role, permissions, PermissionAdmin)
+}
+
+func (p *Policy) holds(role string, permissions string, permission Permission) bool {
+
+	if p.grants[role][permission] {
+		return true
+	}
+
+	for _, granted := range strings.FieldsFunc(permissions, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if Permission(granted) == permission {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,51 @@
+package policy
+
+import (
+	"github.com/go-playground/assert/v2"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+
+	p, err := Parse("user=bookmark:read; admin=bookmark:read,bookmark:write,bookmark:admin")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, p.Can("user", "", PermissionRead), true)
+	assert.Equal(t, p.Can("user", "", PermissionWrite), false)
+	assert.Equal(t, p.Can("admin", "", PermissionAdmin), true)
+	assert.Equal(t, p.Can("guest", "", PermissionRead), false)
+
+	p, err = Parse("")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, p.Can("user", "", PermissionWrite), true)
+	assert.Equal(t, p.Can("user", "", PermissionAdmin), false)
+
+	_, err = Parse("user=bookmark:delete")
+	assert.NotEqual(t, err, nil)
+
+	_, err = Parse("bookmark:read")
+	assert.NotEqual(t, err, nil)
+}
+
+func TestCanMatchesWholePermissionNames(t *testing.T) {
+
+	p := New(nil)
+
+	assert.Equal(t, p.Can("", "bookmark:read,bookmark:write", PermissionWrite), true)
+	assert.Equal(t, p.Can("", "bookmark:read bookmark:admin", PermissionAdmin), true)
+
+	//the old single letter aliases and substrings grant nothing
+	assert.Equal(t, p.Can("", "crud", PermissionWrite), false)
+	assert.Equal(t, p.Can("", "bookmark:readwrite", PermissionRead), false)
+	assert.Equal(t, p.Can("", "bookmark:adminx", PermissionRead), false)
+}
+
+func TestAdminImpliesEveryPermission(t *testing.T) {
+
+	p, err := Parse("user=bookmark:read,bookmark:write;admin=bookmark:admin")
+	assert.Equal(t, err, nil)
+	for _, permission := range []Permission{PermissionRead, PermissionWrite, PermissionAdmin} {
+		assert.Equal(t, p.Can("admin", "", permission), true)
+		assert.Equal(t, p.Can("", "bookmark:admin", permission), true)
+	}
+	assert.Equal(t, p.Can("user", "", PermissionAdmin), false)
+}
@@ -0,0 +1,854 @@
+package repositories
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golek_bookmark_service/pkg/contracts"
+	"golek_bookmark_service/pkg/contracts/status"
+	"golek_bookmark_service/pkg/events"
+	"golek_bookmark_service/pkg/models"
+	"log"
+	"sort"
+	"time"
+)
+
+var (
+	// bucketBookmarks holds bson encoded bookmarks keyed by an insertion sequence, the order Mongo returns documents in
+	bucketBookmarks = []byte("bookmarks")
+	// bucketBookmarkIDs maps a bookmark id to its key in bucketBookmarks
+	bucketBookmarkIDs = []byte("bookmark_ids")
+	// bucketActiveUsers maps a user id to the id of their active bookmark, it plays the unique index on active user ids
+	bucketActiveUsers = []byte("active_users")
+	// bucketOutbox holds bson encoded events waiting to be published, keyed by a sequence in the order they were written
+	bucketOutbox = []byte("outbox")
+)
+
+// BookmarkBoltRepository keeps bookmarks in an embedded bbolt file, it mirrors BookmarkRepository semantics:
+// one active bookmark per user, posts deduplicated per collection and soft deleted bookmarks hidden from reads
+type BookmarkBoltRepository struct {
+	DB *bolt.DB
+}
+
+func (d BookmarkBoltRepository) Fetch(ctx context.Context, filter models.BookmarkFilter, exclude []string, limit int64, after *models.Cursor) (bookmarks []models.Bookmark, total int64, opStatus status.OperationStatus, err error) {
+
+	bookmarks = make([]models.Bookmark, 0)
+	err = d.DB.View(func(tx *bolt.Tx) error {
+		return d.each(tx, func(b *models.Bookmark) {
+			if b.DeletedAt != nil || !filter.Match(*b) {
+				return
+			}
+			total++
+			if after == nil || filter.After(*b, *after) {
+				bookmarks = append(bookmarks, *b)
+			}
+		})
+	})
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY FETCH: ", err.Error())
+		return nil, 0, status.BookmarkFetchingFailed, err
+	}
+	sortBookmarks(bookmarks, filter)
+
+	bookmarks = paginate(bookmarks, limit, 0)
+	for i := range bookmarks {
+		bookmarks[i] = excludeFields(bookmarks[i], exclude)
+	}
+
+	return bookmarks, total, status.OperationSuccess, nil
+}
+
+func (d BookmarkBoltRepository) FetchById(ctx context.Context, id string, exclude []string) (bookmark models.Bookmark, opStatus status.OperationStatus, err error) {
+
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		return bookmark, status.BookmarkNotExist, err
+	}
+
+	var b *models.Bookmark
+	err = d.DB.View(func(tx *bolt.Tx) error {
+		b, _, err = d.get(tx, id)
+		return err
+	})
+	if err != nil {
+		return bookmark, status.BookmarkFetchingFailed, err
+	}
+	if b == nil || b.DeletedAt != nil {
+		return bookmark, status.BookmarkNotExist, errNoDocuments
+	}
+
+	return excludeFields(*b, exclude), status.OperationSuccess, nil
+}
+
+func (d BookmarkBoltRepository) FetchByUserId(ctx context.Context, userID string, exclude []string) (bookmark models.Bookmark, opStatus status.OperationStatus, err error) {
+
+	var b *models.Bookmark
+	err = d.DB.View(func(tx *bolt.Tx) error {
+		b, _, err = d.activeByUser(tx, userID)
+		return err
+	})
+	if err != nil {
+		return bookmark, status.BookmarkFetchingFailed, err
+	}
+	if b == nil {
+		return bookmark, status.BookmarkNotExist, errNoDocuments
+	}
+
+	return excludeFields(*b, exclude), status.OperationSuccess, nil
+}
+
+func (d BookmarkBoltRepository) FetchSavedPosts(ctx context.Context, userID string, postIDs []string) (posts []models.Post, opStatus status.OperationStatus, err error) {
+
+	bookmark, opStatus, err := d.FetchByUserId(ctx, userID, []string{})
+	if err != nil {
+		if opStatus == status.BookmarkNotExist {
+			return nil, status.BookmarkNotExist, errors.New("document not matched")
+		}
+		return nil, opStatus, err
+	}
+
+	wanted := objectIDSet(postIDs)
+	posts = make([]models.Post, 0)
+	for _, p := range bookmark.Posts {
+		if wanted[p.ID] {
+			posts = append(posts, p)
+		}
+	}
+
+	return posts, status.OperationSuccess, nil
+}
+
+func (d BookmarkBoltRepository) SearchPosts(ctx context.Context, userID string, query string) (posts []models.Post, opStatus status.OperationStatus, err error) {
+
+	bookmark, opStatus, err := d.FetchByUserId(ctx, userID, []string{})
+	if err != nil {
+		return nil, opStatus, err
+	}
+
+	return searchPosts(&bookmark, models.SearchTerms(query)), status.OperationSuccess, nil
+}
+
+func (d BookmarkBoltRepository) RefreshSnapshots(ctx context.Context, snapshots []models.Post) (opStatus status.OperationStatus, err error) {
+
+	byID := snapshotsByID(snapshots)
+	timeNow := time.Now()
+
+	err = d.DB.Update(func(tx *bolt.Tx) error {
+
+		//collect first, writing while iterating a cursor isn't allowed
+		keys := make([][]byte, 0)
+		refreshed := make([]*models.Bookmark, 0)
+		err := d.eachWithKey(tx, func(key []byte, b *models.Bookmark) {
+			if refreshSnapshots(b, byID, timeNow) {
+				keys = append(keys, key)
+				refreshed = append(refreshed, b)
+			}
+		})
+		if err != nil {
+			return err
+		}
+
+		for i, b := range refreshed {
+			//only posts change, the active user index stays as it is
+			if err := d.put(tx, keys[i], b, b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY REFRESH SNAPSHOTS: ", err.Error())
+		return status.BookmarkSnapshotFailed, err
+	}
+
+	return status.BookmarkSnapshotSuccess, nil
+}
+
+func (d BookmarkBoltRepository) PrunePosts(ctx context.Context, postIDs []string) (opStatus status.OperationStatus, err error) {
+
+	pruned := objectIDSet(postIDs)
+
+	err = d.DB.Update(func(tx *bolt.Tx) error {
+
+		//collect first, writing while iterating a cursor isn't allowed
+		keys := make([][]byte, 0)
+		changed := make([]*models.Bookmark, 0)
+		revoked := make([][]primitive.ObjectID, 0)
+		err := d.eachWithKey(tx, func(key []byte, b *models.Bookmark) {
+			if removed := removePosts(b, primitive.NilObjectID, pruned); len(removed) > 0 {
+				keys = append(keys, key)
+				changed = append(changed, b)
+				revoked = append(revoked, removed)
+			}
+		})
+		if err != nil {
+			return err
+		}
+
+		timeNow := time.Now()
+		for i, b := range changed {
+			if err := d.put(tx, keys[i], b, b); err != nil {
+				return err
+			}
+			//trashed bookmarks lose the posts too, their removal was reported when they were deleted
+			if b.DeletedAt != nil {
+				continue
+			}
+			if err := d.appendOutbox(tx, events.NewBookmarkPostRevoked(b.UserID, primitive.NilObjectID, revoked[i], timeNow)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY PRUNE POSTS: ", err.Error())
+		return status.BookmarkDeletePostFailed, err
+	}
+
+	return status.BookmarkDeletePostSuccess, nil
+}
+
+func (d BookmarkBoltRepository) FetchOutbox(ctx context.Context, limit int64) (outbox []models.OutboxEvent, opStatus status.OperationStatus, err error) {
+
+	outbox = make([]models.OutboxEvent, 0)
+	err = d.DB.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketOutbox).Cursor()
+		for key, document := c.First(); key != nil && (limit <= 0 || int64(len(outbox)) < limit); key, document = c.Next() {
+			var event models.OutboxEvent
+			if err := bson.Unmarshal(document, &event); err != nil {
+				return err
+			}
+			outbox = append(outbox, event)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY FETCH OUTBOX: ", err.Error())
+		return nil, status.BookmarkOutboxFailed, err
+	}
+
+	return outbox, status.BookmarkOutboxSuccess, nil
+}
+
+func (d BookmarkBoltRepository) RemoveOutboxEvents(ctx context.Context, eventIDs []string) (opStatus status.OperationStatus, err error) {
+
+	removed := objectIDSet(eventIDs)
+
+	err = d.DB.Update(func(tx *bolt.Tx) error {
+
+		//collect first, deleting while iterating a cursor skips keys
+		keys := make([][]byte, 0)
+		c := tx.Bucket(bucketOutbox).Cursor()
+		for key, document := c.First(); key != nil && len(keys) < len(removed); key, document = c.Next() {
+			var event models.OutboxEvent
+			if err := bson.Unmarshal(document, &event); err != nil {
+				return err
+			}
+			if removed[event.ID] {
+				keys = append(keys, append([]byte{}, key...))
+			}
+		}
+
+		for _, key := range keys {
+			if err := tx.Bucket(bucketOutbox).Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY REMOVE OUTBOX EVENTS: ", err.Error())
+		return status.BookmarkOutboxFailed, err
+	}
+
+	return status.BookmarkOutboxSuccess, nil
+}
+
+func (d BookmarkBoltRepository) CountByPosts(ctx context.Context, postIDs []string) (counts map[string]int64, opStatus status.OperationStatus, err error) {
+
+	wanted := objectIDSet(postIDs)
+	counts = make(map[string]int64)
+
+	err = d.DB.View(func(tx *bolt.Tx) error {
+		return d.each(tx, func(b *models.Bookmark) {
+			if b.DeletedAt != nil {
+				return
+			}
+			//a post saved in several collections counts once per bookmark
+			counted := make(map[primitive.ObjectID]bool)
+			for _, p := range b.Posts {
+				if wanted[p.ID] && !counted[p.ID] {
+					counted[p.ID] = true
+					counts[p.ID.Hex()]++
+				}
+			}
+		})
+	})
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY COUNT BY POSTS: ", err.Error())
+		return nil, status.BookmarkFetchingFailed, err
+	}
+
+	return counts, status.OperationSuccess, nil
+}
+
+func (d BookmarkBoltRepository) FetchUsersByPost(ctx context.Context, postID string, limit int64, skip int64) (userIDs []string, total int64, opStatus status.OperationStatus, err error) {
+
+	postObjID, err := primitive.ObjectIDFromHex(postID)
+	if err != nil {
+		return nil, 0, status.BookmarkFetchingFailed, err
+	}
+
+	matched := make([]models.Bookmark, 0)
+	err = d.DB.View(func(tx *bolt.Tx) error {
+		return d.each(tx, func(b *models.Bookmark) {
+			if b.DeletedAt != nil {
+				return
+			}
+			for _, p := range b.Posts {
+				if p.ID == postObjID {
+					matched = append(matched, *b)
+					return
+				}
+			}
+		})
+	})
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY FETCH USERS BY POST: ", err.Error())
+		return nil, 0, status.BookmarkFetchingFailed, err
+	}
+
+	//sorted by _id like the Mongo implementation
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID.Hex() < matched[j].ID.Hex() })
+
+	userIDs = make([]string, 0)
+	for _, b := range matched {
+		userIDs = append(userIDs, b.UserID)
+	}
+
+	return paginate(userIDs, limit, skip), int64(len(matched)), status.OperationSuccess, nil
+}
+
+func (d BookmarkBoltRepository) Create(ctx context.Context, bookmark *models.Bookmark) (bookmarkID primitive.ObjectID, opStatus status.OperationStatus, err error) {
+
+	stored := copyBookmark(*bookmark)
+	if stored.ID.IsZero() {
+		stored.ID = d.GenerateModelID()
+	}
+	if stored.Posts == nil {
+		stored.Posts = make([]models.Post, 0)
+	}
+
+	err = d.DB.Update(func(tx *bolt.Tx) error {
+
+		//same guarantees as the unique index on active user ids and on _id
+		if tx.Bucket(bucketBookmarkIDs).Get([]byte(stored.ID.Hex())) != nil {
+			return errDuplicateKey
+		}
+		if stored.DeletedAt == nil && tx.Bucket(bucketActiveUsers).Get([]byte(stored.UserID)) != nil {
+			return errDuplicateKey
+		}
+
+		sequence, err := tx.Bucket(bucketBookmarks).NextSequence()
+		if err != nil {
+			return err
+		}
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, sequence)
+
+		if err := tx.Bucket(bucketBookmarkIDs).Put([]byte(stored.ID.Hex()), key); err != nil {
+			return err
+		}
+
+		if err := d.put(tx, key, &stored, nil); err != nil {
+			return err
+		}
+		for _, event := range createdEvents(stored, time.Now()) {
+			if err := d.appendOutbox(tx, event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY CREATE: ", err.Error())
+		if errors.Is(err, errDuplicateKey) {
+			return primitive.NilObjectID, status.BookmarkDuplicationOccurs, err
+		}
+		return primitive.NilObjectID, status.BookmarkCreateFailed, err
+	}
+
+	return stored.ID, status.BookmarkCreateSuccess, nil
+}
+
+func (d BookmarkBoltRepository) Update(ctx context.Context, bookmark *models.Bookmark, bookmarkID string) (opStatus status.OperationStatus, err error) {
+
+	objectID, err := primitive.ObjectIDFromHex(bookmarkID)
+	if err != nil {
+		return status.BookmarkUpdateFailed, err
+	}
+
+	stored := copyBookmark(*bookmark)
+	stored.ID = objectID
+
+	err = d.DB.Update(func(tx *bolt.Tx) error {
+		previous, key, err := d.get(tx, bookmarkID)
+		if err != nil {
+			return err
+		}
+		if previous == nil {
+			return errNoDocuments
+		}
+		return d.put(tx, key, &stored, previous)
+	})
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY UPDATE: ", err.Error())
+		if errors.Is(err, errNoDocuments) {
+			return status.BookmarkNotExist, errors.New("document not matched")
+		}
+		if errors.Is(err, errDuplicateKey) {
+			return status.BookmarkDuplicationOccurs, err
+		}
+		return status.BookmarkUpdateFailed, err
+	}
+
+	return status.BookmarkUpdateSuccess, nil
+}
+
+func (d BookmarkBoltRepository) Delete(ctx context.Context, bookmarkID string) (opStatus status.OperationStatus, err error) {
+
+	if _, err := primitive.ObjectIDFromHex(bookmarkID); err != nil {
+		return status.BookmarkDeleteFailed, err
+	}
+
+	err = d.DB.Update(func(tx *bolt.Tx) error {
+		b, key, err := d.get(tx, bookmarkID)
+		if err != nil {
+			return err
+		}
+		if b == nil || b.DeletedAt != nil {
+			return errNoDocuments
+		}
+
+		previous := copyBookmark(*b)
+		timeNow := time.Now()
+		b.DeletedAt = &timeNow
+		b.UpdatedAt = &timeNow
+
+		if err := d.put(tx, key, b, &previous); err != nil {
+			return err
+		}
+		return d.appendOutbox(tx, events.NewBookmarkDeleted(b.ID, b.UserID, timeNow))
+	})
+	if err != nil {
+		if errors.Is(err, errNoDocuments) {
+			return status.BookmarkNotExist, errors.New("document not matched")
+		}
+		log.Println("BOOKMARK REPOSITORY DELETE: ", err.Error())
+		return status.BookmarkDeleteFailed, err
+	}
+
+	return status.BookmarkDeleteSuccess, nil
+}
+
+func (d BookmarkBoltRepository) FetchTrashed(ctx context.Context, userID string, limit int64, skip int64) (bookmarks []models.Bookmark, opStatus status.OperationStatus, err error) {
+
+	bookmarks = make([]models.Bookmark, 0)
+	err = d.DB.View(func(tx *bolt.Tx) error {
+		return d.each(tx, func(b *models.Bookmark) {
+			if b.UserID == userID && b.DeletedAt != nil {
+				bookmarks = append(bookmarks, *b)
+			}
+		})
+	})
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY FETCH TRASHED: ", err.Error())
+		return nil, status.BookmarkFetchingFailed, err
+	}
+
+	//latest deleted first
+	sort.SliceStable(bookmarks, func(i, j int) bool { return bookmarks[i].DeletedAt.After(*bookmarks[j].DeletedAt) })
+
+	return paginate(bookmarks, limit, skip), status.OperationSuccess, nil
+}
+
+func (d BookmarkBoltRepository) FetchTrashedById(ctx context.Context, userID string, bookmarkID string) (bookmark models.Bookmark, opStatus status.OperationStatus, err error) {
+
+	if _, err := primitive.ObjectIDFromHex(bookmarkID); err != nil {
+		return bookmark, status.BookmarkNotExist, err
+	}
+
+	var b *models.Bookmark
+	err = d.DB.View(func(tx *bolt.Tx) error {
+		b, _, err = d.get(tx, bookmarkID)
+		return err
+	})
+	if err != nil {
+		return bookmark, status.BookmarkFetchingFailed, err
+	}
+	if b == nil || b.UserID != userID || b.DeletedAt == nil {
+		return bookmark, status.BookmarkNotExist, errNoDocuments
+	}
+
+	return *b, status.OperationSuccess, nil
+}
+
+func (d BookmarkBoltRepository) Restore(ctx context.Context, bookmarkID string) (opStatus status.OperationStatus, err error) {
+
+	if _, err := primitive.ObjectIDFromHex(bookmarkID); err != nil {
+		return status.BookmarkRestoreFailed, err
+	}
+
+	err = d.DB.Update(func(tx *bolt.Tx) error {
+		b, key, err := d.get(tx, bookmarkID)
+		if err != nil {
+			return err
+		}
+		if b == nil || b.DeletedAt == nil {
+			return errNoDocuments
+		}
+
+		previous := copyBookmark(*b)
+		timeNow := time.Now()
+		b.DeletedAt = nil
+		b.UpdatedAt = &timeNow
+
+		//put refuses it when the user already owns another active bookmark
+		return d.put(tx, key, b, &previous)
+	})
+	if err != nil {
+		if errors.Is(err, errNoDocuments) {
+			return status.BookmarkNotExist, errors.New("document not matched")
+		}
+		if errors.Is(err, errDuplicateKey) {
+			return status.BookmarkDuplicationOccurs, err
+		}
+		log.Println("BOOKMARK REPOSITORY RESTORE: ", err.Error())
+		return status.BookmarkRestoreFailed, err
+	}
+
+	return status.BookmarkRestoreSuccess, nil
+}
+
+func (d BookmarkBoltRepository) Purge(ctx context.Context, deletedBefore time.Time) (purged int64, opStatus status.OperationStatus, err error) {
+
+	err = d.DB.Update(func(tx *bolt.Tx) error {
+
+		//collect first, deleting while iterating a cursor skips keys
+		keys := make([][]byte, 0)
+		ids := make([][]byte, 0)
+		err := d.eachWithKey(tx, func(key []byte, b *models.Bookmark) {
+			if b.DeletedAt != nil && b.DeletedAt.Before(deletedBefore) {
+				keys = append(keys, key)
+				ids = append(ids, []byte(b.ID.Hex()))
+			}
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := range keys {
+			if err := tx.Bucket(bucketBookmarks).Delete(keys[i]); err != nil {
+				return err
+			}
+			if err := tx.Bucket(bucketBookmarkIDs).Delete(ids[i]); err != nil {
+				return err
+			}
+		}
+
+		purged = int64(len(keys))
+		return nil
+	})
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY PURGE: ", err.Error())
+		return 0, status.BookmarkPurgeFailed, err
+	}
+
+	return purged, status.BookmarkPurgeSuccess, nil
+}
+
+func (d BookmarkBoltRepository) AddPost(ctx context.Context, userID string, collectionID string, postIDs []string) (opStatus status.OperationStatus, err error) {
+
+	collectionObjID, err := primitive.ObjectIDFromHex(collectionID)
+	if err != nil {
+		return status.BookmarkCollectionNotExist, err
+	}
+
+	return d.modifyActiveTx(userID, status.BookmarkPostSuccess, status.BookmarkPostFailed, func(tx *bolt.Tx, b *models.Bookmark) (status.OperationStatus, error) {
+		if !hasCollection(b, collectionObjID) {
+			return status.BookmarkNotExist, errors.New("document not matched")
+		}
+		timeNow := time.Now()
+		if added := appendPosts(b, collectionObjID, objectIDs(postIDs), timeNow); len(added) > 0 {
+			if err := d.appendOutbox(tx, events.NewBookmarkPostAdded(userID, collectionObjID, added, timeNow)); err != nil {
+				return status.BookmarkPostFailed, err
+			}
+		}
+		return status.BookmarkPostSuccess, nil
+	})
+}
+
+func (d BookmarkBoltRepository) UpdatePost(ctx context.Context, userID string, collectionID string, postID string, note *string, tags []string) (opStatus status.OperationStatus, err error) {
+
+	postObjID, err := primitive.ObjectIDFromHex(postID)
+	if err != nil {
+		return status.BookmarkPostNotExist, err
+	}
+
+	var collectionObjID primitive.ObjectID
+	if collectionID != "" {
+		collectionObjID, err = primitive.ObjectIDFromHex(collectionID)
+		if err != nil {
+			return status.BookmarkCollectionNotExist, err
+		}
+	}
+
+	opStatus, err = d.modifyActive(userID, status.BookmarkPostUpdateSuccess, status.BookmarkPostUpdateFailed, func(b *models.Bookmark) (status.OperationStatus, error) {
+		if !annotatePosts(b, collectionObjID, postObjID, note, tags) {
+			return status.BookmarkPostNotExist, errors.New("post not matched")
+		}
+		timeNow := time.Now()
+		b.UpdatedAt = &timeNow
+		return status.BookmarkPostUpdateSuccess, nil
+	})
+	if opStatus == status.BookmarkNotExist {
+		return status.BookmarkPostNotExist, errors.New("post not matched")
+	}
+
+	return opStatus, err
+}
+
+func (d BookmarkBoltRepository) RevokePost(ctx context.Context, userID string, collectionID string, postIDs []string) (opStatus status.OperationStatus, err error) {
+
+	var collectionObjID primitive.ObjectID
+	if collectionID != "" {
+		collectionObjID, err = primitive.ObjectIDFromHex(collectionID)
+		if err != nil {
+			return status.BookmarkCollectionNotExist, err
+		}
+	}
+
+	return d.modifyActiveTx(userID, status.BookmarkDeletePostSuccess, status.BookmarkDeletePostFailed, func(tx *bolt.Tx, b *models.Bookmark) (status.OperationStatus, error) {
+		if removed := removePosts(b, collectionObjID, objectIDSet(postIDs)); len(removed) > 0 {
+			if err := d.appendOutbox(tx, events.NewBookmarkPostRevoked(userID, collectionObjID, removed, time.Now())); err != nil {
+				return status.BookmarkDeletePostFailed, err
+			}
+		}
+		return status.BookmarkDeletePostSuccess, nil
+	})
+}
+
+func (d BookmarkBoltRepository) CreateCollection(ctx context.Context, userID string, collection *models.Collection) (opStatus status.OperationStatus, err error) {
+	return d.modifyActive(userID, status.BookmarkCollectionCreateSuccess, status.BookmarkCollectionCreateFailed, func(b *models.Bookmark) (status.OperationStatus, error) {
+		b.Collections = append(b.Collections, *collection)
+		return status.BookmarkCollectionCreateSuccess, nil
+	})
+}
+
+func (d BookmarkBoltRepository) RenameCollection(ctx context.Context, userID string, collectionID string, name string) (opStatus status.OperationStatus, err error) {
+
+	collectionObjID, err := primitive.ObjectIDFromHex(collectionID)
+	if err != nil {
+		return status.BookmarkCollectionNotExist, err
+	}
+
+	opStatus, err = d.modifyActive(userID, status.BookmarkCollectionUpdateSuccess, status.BookmarkCollectionUpdateFailed, func(b *models.Bookmark) (status.OperationStatus, error) {
+		if !renameCollection(b, collectionObjID, name, time.Now()) {
+			return status.BookmarkCollectionNotExist, errors.New("collection not matched")
+		}
+		return status.BookmarkCollectionUpdateSuccess, nil
+	})
+	if opStatus == status.BookmarkNotExist {
+		return status.BookmarkCollectionNotExist, errors.New("collection not matched")
+	}
+
+	return opStatus, err
+}
+
+func (d BookmarkBoltRepository) DeleteCollection(ctx context.Context, userID string, collectionID string) (opStatus status.OperationStatus, err error) {
+
+	collectionObjID, err := primitive.ObjectIDFromHex(collectionID)
+	if err != nil {
+		return status.BookmarkCollectionNotExist, err
+	}
+
+	opStatus, err = d.modifyActiveTx(userID, status.BookmarkCollectionDeleteSuccess, status.BookmarkCollectionDeleteFailed, func(tx *bolt.Tx, b *models.Bookmark) (status.OperationStatus, error) {
+		//the default collection can never be removed
+		removed, ok := removeCollection(b, collectionObjID)
+		if !ok {
+			return status.BookmarkCollectionNotExist, errors.New("collection not matched")
+		}
+		if len(removed) > 0 {
+			if err := d.appendOutbox(tx, events.NewBookmarkPostRevoked(userID, collectionObjID, removed, time.Now())); err != nil {
+				return status.BookmarkCollectionDeleteFailed, err
+			}
+		}
+		return status.BookmarkCollectionDeleteSuccess, nil
+	})
+	if opStatus == status.BookmarkNotExist {
+		return status.BookmarkCollectionNotExist, errors.New("collection not matched")
+	}
+
+	return opStatus, err
+}
+
+func (d BookmarkBoltRepository) GenerateModelID() primitive.ObjectID {
+	return primitive.NewObjectID()
+}
+
+func (d BookmarkBoltRepository) GenerateObjectIDFromString(id string) primitive.ObjectID {
+	hex, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID
+	}
+	return hex
+}
+
+// modifyActive load the active bookmark of the user, apply fn and store the result in a single transaction.
+// nothing is written when fn fails
+func (d BookmarkBoltRepository) modifyActive(userID string, success status.OperationStatus, failure status.OperationStatus, fn func(b *models.Bookmark) (status.OperationStatus, error)) (opStatus status.OperationStatus, err error) {
+	return d.modifyActiveTx(userID, success, failure, func(tx *bolt.Tx, b *models.Bookmark) (status.OperationStatus, error) {
+		return fn(b)
+	})
+}
+
+// modifyActiveTx is modifyActive handing 'fn' the transaction as well, for changes writing more than the bookmark
+func (d BookmarkBoltRepository) modifyActiveTx(userID string, success status.OperationStatus, failure status.OperationStatus, fn func(tx *bolt.Tx, b *models.Bookmark) (status.OperationStatus, error)) (opStatus status.OperationStatus, err error) {
+
+	opStatus = success
+	err = d.DB.Update(func(tx *bolt.Tx) error {
+		b, key, err := d.activeByUser(tx, userID)
+		if err != nil {
+			opStatus = failure
+			return err
+		}
+		if b == nil {
+			opStatus = status.BookmarkNotExist
+			return errors.New("document not matched")
+		}
+
+		previous := copyBookmark(*b)
+		if opStatus, err = fn(tx, b); err != nil {
+			return err
+		}
+
+		if err := d.put(tx, key, b, &previous); err != nil {
+			opStatus = failure
+			return err
+		}
+		return nil
+	})
+
+	return opStatus, err
+}
+
+// appendOutbox write the event behind the ones already waiting
+func (d BookmarkBoltRepository) appendOutbox(tx *bolt.Tx, event models.OutboxEvent) error {
+
+	outbox := tx.Bucket(bucketOutbox)
+	sequence, err := outbox.NextSequence()
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, sequence)
+
+	document, err := bson.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return outbox.Put(key, document)
+}
+
+// get load a bookmark by id whether it is deleted or not, a missing bookmark is returned as nil
+func (d BookmarkBoltRepository) get(tx *bolt.Tx, id string) (*models.Bookmark, []byte, error) {
+
+	key := tx.Bucket(bucketBookmarkIDs).Get([]byte(id))
+	if key == nil {
+		return nil, nil, nil
+	}
+	//keys are only valid for the life of the transaction, callers may keep them around for put
+	key = append([]byte{}, key...)
+
+	var b models.Bookmark
+	if err := bson.Unmarshal(tx.Bucket(bucketBookmarks).Get(key), &b); err != nil {
+		return nil, nil, err
+	}
+
+	return &b, key, nil
+}
+
+func (d BookmarkBoltRepository) activeByUser(tx *bolt.Tx, userID string) (*models.Bookmark, []byte, error) {
+
+	id := tx.Bucket(bucketActiveUsers).Get([]byte(userID))
+	if id == nil {
+		return nil, nil, nil
+	}
+
+	return d.get(tx, string(id))
+}
+
+// put store the bookmark under key and keep the active user index in sync with 'previous', the stored state before the change
+func (d BookmarkBoltRepository) put(tx *bolt.Tx, key []byte, b *models.Bookmark, previous *models.Bookmark) error {
+
+	activeUsers := tx.Bucket(bucketActiveUsers)
+	id := []byte(b.ID.Hex())
+
+	if previous != nil && previous.DeletedAt == nil {
+		if err := activeUsers.Delete([]byte(previous.UserID)); err != nil {
+			return err
+		}
+	}
+
+	if b.DeletedAt == nil {
+		if owner := activeUsers.Get([]byte(b.UserID)); owner != nil && string(owner) != string(id) {
+			return errDuplicateKey
+		}
+		if err := activeUsers.Put([]byte(b.UserID), id); err != nil {
+			return err
+		}
+	}
+
+	document, err := bson.Marshal(b)
+	if err != nil {
+		return err
+	}
+
+	return tx.Bucket(bucketBookmarks).Put(key, document)
+}
+
+// each decode every stored bookmark in insertion order
+func (d BookmarkBoltRepository) each(tx *bolt.Tx, fn func(b *models.Bookmark)) error {
+	return d.eachWithKey(tx, func(key []byte, b *models.Bookmark) { fn(b) })
+}
+
+func (d BookmarkBoltRepository) eachWithKey(tx *bolt.Tx, fn func(key []byte, b *models.Bookmark)) error {
+	return tx.Bucket(bucketBookmarks).ForEach(func(key, value []byte) error {
+		var b models.Bookmark
+		if err := bson.Unmarshal(value, &b); err != nil {
+			return err
+		}
+		fn(append([]byte{}, key...), &b)
+		return nil
+	})
+}
+
+func NewBookmarkBoltRepository(db *bolt.DB) contracts.BookmarksRepository {
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{bucketBookmarks, bucketBookmarkIDs, bucketActiveUsers, bucketOutbox} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return &BookmarkBoltRepository{DB: db}
+}
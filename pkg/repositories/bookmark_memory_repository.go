@@ -0,0 +1,570 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golek_bookmark_service/pkg/contracts"
+	"golek_bookmark_service/pkg/contracts/status"
+	"golek_bookmark_service/pkg/events"
+	"golek_bookmark_service/pkg/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BookmarkMemoryRepository keeps bookmarks in process memory, it mirrors BookmarkRepository semantics:
+// one active bookmark per user, posts deduplicated per collection and soft deleted bookmarks hidden from reads
+type BookmarkMemoryRepository struct {
+	mu        sync.RWMutex
+	bookmarks map[primitive.ObjectID]*models.Bookmark
+	// order keeps insertion order, the natural order Mongo returns documents in
+	order []primitive.ObjectID
+	// outbox holds the events waiting to be published, written under the same lock as the change they report
+	outbox []models.OutboxEvent
+}
+
+func (d *BookmarkMemoryRepository) Fetch(ctx context.Context, filter models.BookmarkFilter, exclude []string, limit int64, after *models.Cursor) (bookmarks []models.Bookmark, total int64, opStatus status.OperationStatus, err error) {
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	bookmarks = make([]models.Bookmark, 0)
+	for _, b := range d.active() {
+		if !filter.Match(*b) {
+			continue
+		}
+		total++
+		if after == nil || filter.After(*b, *after) {
+			bookmarks = append(bookmarks, copyBookmark(*b))
+		}
+	}
+	sortBookmarks(bookmarks, filter)
+
+	bookmarks = paginate(bookmarks, limit, 0)
+	for i := range bookmarks {
+		bookmarks[i] = excludeFields(bookmarks[i], exclude)
+	}
+
+	return bookmarks, total, status.OperationSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) FetchById(ctx context.Context, id string, exclude []string) (bookmark models.Bookmark, opStatus status.OperationStatus, err error) {
+
+	modelID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return bookmark, status.BookmarkNotExist, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	b, ok := d.bookmarks[modelID]
+	if !ok || b.DeletedAt != nil {
+		return bookmark, status.BookmarkNotExist, errNoDocuments
+	}
+
+	return excludeFields(copyBookmark(*b), exclude), status.OperationSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) FetchByUserId(ctx context.Context, userID string, exclude []string) (bookmark models.Bookmark, opStatus status.OperationStatus, err error) {
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	b := d.activeByUser(userID)
+	if b == nil {
+		return bookmark, status.BookmarkNotExist, errNoDocuments
+	}
+
+	return excludeFields(copyBookmark(*b), exclude), status.OperationSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) FetchSavedPosts(ctx context.Context, userID string, postIDs []string) (posts []models.Post, opStatus status.OperationStatus, err error) {
+
+	wanted := objectIDSet(postIDs)
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	b := d.activeByUser(userID)
+	if b == nil {
+		return nil, status.BookmarkNotExist, errors.New("document not matched")
+	}
+
+	posts = make([]models.Post, 0)
+	for _, p := range b.Posts {
+		if wanted[p.ID] {
+			posts = append(posts, copyPost(p))
+		}
+	}
+
+	return posts, status.OperationSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) SearchPosts(ctx context.Context, userID string, query string) (posts []models.Post, opStatus status.OperationStatus, err error) {
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	b := d.activeByUser(userID)
+	if b == nil {
+		return nil, status.BookmarkNotExist, errNoDocuments
+	}
+
+	return searchPosts(b, models.SearchTerms(query)), status.OperationSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) RefreshSnapshots(ctx context.Context, snapshots []models.Post) (opStatus status.OperationStatus, err error) {
+
+	byID := snapshotsByID(snapshots)
+	timeNow := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	//trashed bookmarks are refreshed too, they come back up to date once restored
+	for _, b := range d.bookmarks {
+		refreshSnapshots(b, byID, timeNow)
+	}
+
+	return status.BookmarkSnapshotSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) PrunePosts(ctx context.Context, postIDs []string) (opStatus status.OperationStatus, err error) {
+
+	pruned := objectIDSet(postIDs)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	//trashed bookmarks lose the posts too, their removal was reported when they were deleted
+	timeNow := time.Now()
+	for _, id := range d.order {
+		b := d.bookmarks[id]
+		if removed := removePosts(b, primitive.NilObjectID, pruned); len(removed) > 0 && b.DeletedAt == nil {
+			d.outbox = append(d.outbox, events.NewBookmarkPostRevoked(b.UserID, primitive.NilObjectID, removed, timeNow))
+		}
+	}
+
+	return status.BookmarkDeletePostSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) FetchOutbox(ctx context.Context, limit int64) (outbox []models.OutboxEvent, opStatus status.OperationStatus, err error) {
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	outbox = d.outbox
+	if limit > 0 && int64(len(outbox)) > limit {
+		outbox = outbox[:limit]
+	}
+
+	return append([]models.OutboxEvent{}, outbox...), status.BookmarkOutboxSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) RemoveOutboxEvents(ctx context.Context, eventIDs []string) (opStatus status.OperationStatus, err error) {
+
+	removed := objectIDSet(eventIDs)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	kept := make([]models.OutboxEvent, 0, len(d.outbox))
+	for _, event := range d.outbox {
+		if !removed[event.ID] {
+			kept = append(kept, event)
+		}
+	}
+	d.outbox = kept
+
+	return status.BookmarkOutboxSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) CountByPosts(ctx context.Context, postIDs []string) (counts map[string]int64, opStatus status.OperationStatus, err error) {
+
+	wanted := objectIDSet(postIDs)
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	counts = make(map[string]int64)
+	for _, b := range d.active() {
+		//a post saved in several collections counts once per bookmark
+		counted := make(map[primitive.ObjectID]bool)
+		for _, p := range b.Posts {
+			if wanted[p.ID] && !counted[p.ID] {
+				counted[p.ID] = true
+				counts[p.ID.Hex()]++
+			}
+		}
+	}
+
+	return counts, status.OperationSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) FetchUsersByPost(ctx context.Context, postID string, limit int64, skip int64) (userIDs []string, total int64, opStatus status.OperationStatus, err error) {
+
+	postObjID, err := primitive.ObjectIDFromHex(postID)
+	if err != nil {
+		return nil, 0, status.BookmarkFetchingFailed, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	matched := make([]*models.Bookmark, 0)
+	for _, b := range d.active() {
+		for _, p := range b.Posts {
+			if p.ID == postObjID {
+				matched = append(matched, b)
+				break
+			}
+		}
+	}
+
+	//sorted by _id like the Mongo implementation
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID.Hex() < matched[j].ID.Hex() })
+
+	userIDs = make([]string, 0)
+	for _, b := range matched {
+		userIDs = append(userIDs, b.UserID)
+	}
+
+	return paginate(userIDs, limit, skip), int64(len(matched)), status.OperationSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) Create(ctx context.Context, bookmark *models.Bookmark) (bookmarkID primitive.ObjectID, opStatus status.OperationStatus, err error) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	//same guarantees as the unique index on active user ids and on _id
+	if _, ok := d.bookmarks[bookmark.ID]; ok {
+		return primitive.NilObjectID, status.BookmarkDuplicationOccurs, errDuplicateKey
+	}
+	if bookmark.DeletedAt == nil && d.activeByUser(bookmark.UserID) != nil {
+		return primitive.NilObjectID, status.BookmarkDuplicationOccurs, errDuplicateKey
+	}
+
+	stored := copyBookmark(*bookmark)
+	if stored.ID.IsZero() {
+		stored.ID = primitive.NewObjectID()
+	}
+	if stored.Posts == nil {
+		stored.Posts = make([]models.Post, 0)
+	}
+
+	d.bookmarks[stored.ID] = &stored
+	d.order = append(d.order, stored.ID)
+	d.outbox = append(d.outbox, createdEvents(stored, time.Now())...)
+
+	return stored.ID, status.BookmarkCreateSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) Update(ctx context.Context, bookmark *models.Bookmark, bookmarkID string) (opStatus status.OperationStatus, err error) {
+
+	objectId, err := primitive.ObjectIDFromHex(bookmarkID)
+	if err != nil {
+		return status.BookmarkUpdateFailed, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.bookmarks[objectId]; !ok {
+		return status.BookmarkNotExist, errors.New("document not matched")
+	}
+
+	stored := copyBookmark(*bookmark)
+	stored.ID = objectId
+	d.bookmarks[objectId] = &stored
+
+	return status.BookmarkUpdateSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) Delete(ctx context.Context, bookmarkID string) (opStatus status.OperationStatus, err error) {
+
+	objectID, err := primitive.ObjectIDFromHex(bookmarkID)
+	if err != nil {
+		return status.BookmarkDeleteFailed, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	b, ok := d.bookmarks[objectID]
+	if !ok || b.DeletedAt != nil {
+		return status.BookmarkNotExist, errors.New("document not matched")
+	}
+
+	timeNow := time.Now()
+	b.DeletedAt = &timeNow
+	b.UpdatedAt = &timeNow
+	d.outbox = append(d.outbox, events.NewBookmarkDeleted(b.ID, b.UserID, timeNow))
+
+	return status.BookmarkDeleteSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) FetchTrashed(ctx context.Context, userID string, limit int64, skip int64) (bookmarks []models.Bookmark, opStatus status.OperationStatus, err error) {
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	bookmarks = make([]models.Bookmark, 0)
+	for _, id := range d.order {
+		if b := d.bookmarks[id]; b.UserID == userID && b.DeletedAt != nil {
+			bookmarks = append(bookmarks, copyBookmark(*b))
+		}
+	}
+
+	//latest deleted first
+	sort.SliceStable(bookmarks, func(i, j int) bool { return bookmarks[i].DeletedAt.After(*bookmarks[j].DeletedAt) })
+
+	return paginate(bookmarks, limit, skip), status.OperationSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) FetchTrashedById(ctx context.Context, userID string, bookmarkID string) (bookmark models.Bookmark, opStatus status.OperationStatus, err error) {
+
+	modelID, err := primitive.ObjectIDFromHex(bookmarkID)
+	if err != nil {
+		return bookmark, status.BookmarkNotExist, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	b, ok := d.bookmarks[modelID]
+	if !ok || b.UserID != userID || b.DeletedAt == nil {
+		return bookmark, status.BookmarkNotExist, errNoDocuments
+	}
+
+	return copyBookmark(*b), status.OperationSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) Restore(ctx context.Context, bookmarkID string) (opStatus status.OperationStatus, err error) {
+
+	objectID, err := primitive.ObjectIDFromHex(bookmarkID)
+	if err != nil {
+		return status.BookmarkRestoreFailed, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	b, ok := d.bookmarks[objectID]
+	if !ok || b.DeletedAt == nil {
+		return status.BookmarkNotExist, errors.New("document not matched")
+	}
+
+	//the user already owns another active bookmark
+	if d.activeByUser(b.UserID) != nil {
+		return status.BookmarkDuplicationOccurs, errDuplicateKey
+	}
+
+	timeNow := time.Now()
+	b.DeletedAt = nil
+	b.UpdatedAt = &timeNow
+
+	return status.BookmarkRestoreSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) Purge(ctx context.Context, deletedBefore time.Time) (purged int64, opStatus status.OperationStatus, err error) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	kept := make([]primitive.ObjectID, 0, len(d.order))
+	for _, id := range d.order {
+		if b := d.bookmarks[id]; b.DeletedAt != nil && b.DeletedAt.Before(deletedBefore) {
+			delete(d.bookmarks, id)
+			purged++
+			continue
+		}
+		kept = append(kept, id)
+	}
+	d.order = kept
+
+	return purged, status.BookmarkPurgeSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) AddPost(ctx context.Context, userID string, collectionID string, postIDs []string) (opStatus status.OperationStatus, err error) {
+
+	collectionObjID, err := primitive.ObjectIDFromHex(collectionID)
+	if err != nil {
+		return status.BookmarkCollectionNotExist, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	b := d.activeByUser(userID)
+	if b == nil || !hasCollection(b, collectionObjID) {
+		return status.BookmarkNotExist, errors.New("document not matched")
+	}
+
+	timeNow := time.Now()
+	if added := appendPosts(b, collectionObjID, objectIDs(postIDs), timeNow); len(added) > 0 {
+		d.outbox = append(d.outbox, events.NewBookmarkPostAdded(userID, collectionObjID, added, timeNow))
+	}
+
+	return status.BookmarkPostSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) UpdatePost(ctx context.Context, userID string, collectionID string, postID string, note *string, tags []string) (opStatus status.OperationStatus, err error) {
+
+	postObjID, err := primitive.ObjectIDFromHex(postID)
+	if err != nil {
+		return status.BookmarkPostNotExist, err
+	}
+
+	var collectionObjID primitive.ObjectID
+	if collectionID != "" {
+		collectionObjID, err = primitive.ObjectIDFromHex(collectionID)
+		if err != nil {
+			return status.BookmarkCollectionNotExist, err
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	b := d.activeByUser(userID)
+	if b == nil {
+		return status.BookmarkPostNotExist, errors.New("post not matched")
+	}
+
+	if !annotatePosts(b, collectionObjID, postObjID, note, tags) {
+		return status.BookmarkPostNotExist, errors.New("post not matched")
+	}
+
+	timeNow := time.Now()
+	b.UpdatedAt = &timeNow
+
+	return status.BookmarkPostUpdateSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) RevokePost(ctx context.Context, userID string, collectionID string, postIDs []string) (opStatus status.OperationStatus, err error) {
+
+	var collectionObjID primitive.ObjectID
+	if collectionID != "" {
+		collectionObjID, err = primitive.ObjectIDFromHex(collectionID)
+		if err != nil {
+			return status.BookmarkCollectionNotExist, err
+		}
+	}
+
+	revoked := objectIDSet(postIDs)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	b := d.activeByUser(userID)
+	if b == nil {
+		return status.BookmarkNotExist, errors.New("document not matched")
+	}
+
+	if removed := removePosts(b, collectionObjID, revoked); len(removed) > 0 {
+		d.outbox = append(d.outbox, events.NewBookmarkPostRevoked(userID, collectionObjID, removed, time.Now()))
+	}
+
+	return status.BookmarkDeletePostSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) CreateCollection(ctx context.Context, userID string, collection *models.Collection) (opStatus status.OperationStatus, err error) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	b := d.activeByUser(userID)
+	if b == nil {
+		return status.BookmarkNotExist, errors.New("document not matched")
+	}
+
+	b.Collections = append(b.Collections, *collection)
+
+	return status.BookmarkCollectionCreateSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) RenameCollection(ctx context.Context, userID string, collectionID string, name string) (opStatus status.OperationStatus, err error) {
+
+	collectionObjID, err := primitive.ObjectIDFromHex(collectionID)
+	if err != nil {
+		return status.BookmarkCollectionNotExist, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if b := d.activeByUser(userID); b != nil && renameCollection(b, collectionObjID, name, time.Now()) {
+		return status.BookmarkCollectionUpdateSuccess, nil
+	}
+
+	return status.BookmarkCollectionNotExist, errors.New("collection not matched")
+}
+
+func (d *BookmarkMemoryRepository) DeleteCollection(ctx context.Context, userID string, collectionID string) (opStatus status.OperationStatus, err error) {
+
+	collectionObjID, err := primitive.ObjectIDFromHex(collectionID)
+	if err != nil {
+		return status.BookmarkCollectionNotExist, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	//the default collection can never be removed
+	b := d.activeByUser(userID)
+	if b == nil {
+		return status.BookmarkCollectionNotExist, errors.New("collection not matched")
+	}
+	removed, ok := removeCollection(b, collectionObjID)
+	if !ok {
+		return status.BookmarkCollectionNotExist, errors.New("collection not matched")
+	}
+	if len(removed) > 0 {
+		d.outbox = append(d.outbox, events.NewBookmarkPostRevoked(userID, collectionObjID, removed, time.Now()))
+	}
+
+	return status.BookmarkCollectionDeleteSuccess, nil
+}
+
+func (d *BookmarkMemoryRepository) GenerateModelID() primitive.ObjectID {
+	return primitive.NewObjectID()
+}
+
+func (d *BookmarkMemoryRepository) GenerateObjectIDFromString(id string) primitive.ObjectID {
+	hex, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID
+	}
+	return hex
+}
+
+// active list bookmarks that are not soft deleted in insertion order, the caller must hold the lock
+func (d *BookmarkMemoryRepository) active() []*models.Bookmark {
+	active := make([]*models.Bookmark, 0)
+	for _, id := range d.order {
+		if b := d.bookmarks[id]; b.DeletedAt == nil {
+			active = append(active, b)
+		}
+	}
+	return active
+}
+
+// activeByUser the caller must hold the lock
+func (d *BookmarkMemoryRepository) activeByUser(userID string) *models.Bookmark {
+	for _, id := range d.order {
+		if b := d.bookmarks[id]; b.UserID == userID && b.DeletedAt == nil {
+			return b
+		}
+	}
+	return nil
+}
+
+func NewBookmarkMemoryRepository() contracts.BookmarksRepository {
+	return &BookmarkMemoryRepository{
+		bookmarks: make(map[primitive.ObjectID]*models.Bookmark),
+		order:     make([]primitive.ObjectID, 0),
+	}
+}
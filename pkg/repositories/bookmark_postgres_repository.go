@@ -0,0 +1,1069 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golek_bookmark_service/pkg/contracts"
+	"golek_bookmark_service/pkg/contracts/status"
+	"golek_bookmark_service/pkg/events"
+	"golek_bookmark_service/pkg/models"
+	"log"
+	"strings"
+	"time"
+)
+
+// BookmarkPostgresRepository stores bookmarks in the bookmarks, bookmark_collections and bookmark_items tables,
+// it mirrors BookmarkRepository semantics: one active bookmark per user, posts deduplicated per collection
+// and soft deleted bookmarks hidden from reads
+type BookmarkPostgresRepository struct {
+	DB *sql.DB
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+const bookmarkColumns = `id, user_id, created_at, updated_at, deleted_at`
+
+// postColumns are the bookmark_items columns scanPost reads
+const postColumns = `post_id, collection_id, added_at, note, tags, name, image_url, snapshot_at`
+
+// postgresSortColumns whitelist what ORDER BY may be built from along with the type of their cursor value
+var postgresSortColumns = map[string][2]string{
+	"":                     {"", ""},
+	models.SortByCreatedAt: {"created_at", "timestamptz"},
+	models.SortByUpdatedAt: {"updated_at", "timestamptz"},
+	models.SortByUserID:    {"user_id", "text"},
+}
+
+func (d BookmarkPostgresRepository) Fetch(ctx context.Context, filter models.BookmarkFilter, exclude []string, limit int64, after *models.Cursor) (bookmarks []models.Bookmark, total int64, opStatus status.OperationStatus, err error) {
+
+	sortColumn, ok := postgresSortColumns[filter.SortBy]
+	if !ok {
+		return nil, 0, status.BookmarkFetchingFailed, fmt.Errorf("bookmarks can't be sorted by %q", filter.SortBy)
+	}
+
+	const matching = `FROM bookmarks WHERE deleted_at IS NULL
+		AND ($1::text = '' OR user_id = $1::text)
+		AND ($2::text = '' OR EXISTS (SELECT 1 FROM bookmark_items i WHERE i.bookmark_id = bookmarks.id AND i.post_id = $2::text))
+		AND ($3::timestamptz IS NULL OR created_at >= $3::timestamptz)
+		AND ($4::timestamptz IS NULL OR created_at <= $4::timestamptz)
+		AND ($5::timestamptz IS NULL OR updated_at >= $5::timestamptz)
+		AND ($6::timestamptz IS NULL OR updated_at <= $6::timestamptz)`
+	args := []interface{}{filter.UserID, filter.PostID,
+		filter.CreatedFrom, filter.CreatedTo, filter.UpdatedFrom, filter.UpdatedTo}
+
+	//the total ignores the cursor, it counts the whole listing
+	err = d.DB.QueryRowContext(ctx, `SELECT COUNT(*) `+matching, args...).Scan(&total)
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY FETCH: ", err.Error())
+		return nil, 0, status.BookmarkFetchingFailed, err
+	}
+
+	//ties go by ascending id like the other storages, nulls sort first ascending and last descending like in Mongo
+	order := "id"
+	if column := sortColumn[0]; column != "" {
+		if filter.SortDescending {
+			order = column + " DESC NULLS LAST, id"
+		} else {
+			order = column + " ASC NULLS FIRST, id"
+		}
+	}
+
+	query := `SELECT ` + bookmarkColumns + ` ` + matching
+	if after != nil {
+		var condition string
+		condition, args = postgresAfterCursor(filter, sortColumn, *after, args)
+		query += ` AND ` + condition
+	}
+	query += fmt.Sprintf(` ORDER BY %s LIMIT $%d`, order, len(args)+1)
+
+	bookmarks, err = d.queryBookmarks(ctx, d.DB, query, append(args, sqlLimit(limit))...)
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY FETCH: ", err.Error())
+		return nil, 0, status.BookmarkFetchingFailed, err
+	}
+
+	for i := range bookmarks {
+		bookmarks[i] = excludeFields(bookmarks[i], exclude)
+	}
+
+	return bookmarks, total, status.OperationSuccess, nil
+}
+
+// postgresAfterCursor build the condition matching what comes after the cursor, see models.BookmarkFilter.After.
+// it binds the cursor values after 'args'
+func postgresAfterCursor(filter models.BookmarkFilter, sortColumn [2]string, after models.Cursor, args []interface{}) (string, []interface{}) {
+
+	args = append(args, after.ID)
+	id := fmt.Sprintf("id > $%d::text", len(args))
+
+	column, valueType := sortColumn[0], sortColumn[1]
+	if column == "" {
+		return id, args
+	}
+
+	var value interface{}
+	if filter.SortBy == models.SortByUserID {
+		value = after.Text
+	} else if after.Time != nil {
+		value = *after.Time
+	}
+
+	if value == nil {
+		if filter.SortDescending {
+			return fmt.Sprintf("(%s IS NULL AND %s)", column, id), args
+		}
+		return fmt.Sprintf("(%s IS NOT NULL OR %s)", column, id), args
+	}
+
+	args = append(args, value)
+	placeholder := fmt.Sprintf("$%d::%s", len(args), valueType)
+	if filter.SortDescending {
+		return fmt.Sprintf("(%[1]s < %[2]s OR %[1]s IS NULL OR (%[1]s = %[2]s AND %[3]s))", column, placeholder, id), args
+	}
+	return fmt.Sprintf("(%[1]s > %[2]s OR (%[1]s = %[2]s AND %[3]s))", column, placeholder, id), args
+}
+
+func (d BookmarkPostgresRepository) FetchById(ctx context.Context, id string, exclude []string) (bookmark models.Bookmark, opStatus status.OperationStatus, err error) {
+
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		return bookmark, status.BookmarkNotExist, err
+	}
+
+	return d.fetchOne(ctx, exclude, `SELECT `+bookmarkColumns+` FROM bookmarks
+		WHERE id = $1 AND deleted_at IS NULL`, id)
+}
+
+func (d BookmarkPostgresRepository) FetchByUserId(ctx context.Context, userID string, exclude []string) (bookmark models.Bookmark, opStatus status.OperationStatus, err error) {
+	return d.fetchOne(ctx, exclude, `SELECT `+bookmarkColumns+` FROM bookmarks
+		WHERE user_id = $1 AND deleted_at IS NULL`, userID)
+}
+
+func (d BookmarkPostgresRepository) FetchSavedPosts(ctx context.Context, userID string, postIDs []string) (posts []models.Post, opStatus status.OperationStatus, err error) {
+
+	bookmarkID, err := d.activeBookmarkID(ctx, d.DB, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.BookmarkNotExist, errors.New("document not matched")
+		}
+		return nil, status.BookmarkFetchingFailed, err
+	}
+
+	rows, err := d.DB.QueryContext(ctx, `SELECT `+postColumns+` FROM bookmark_items
+		WHERE bookmark_id = $1 AND post_id = ANY($2) ORDER BY position`, bookmarkID, pq.Array(d.hexIDs(postIDs)))
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY FETCH SAVED POSTS: ", err.Error())
+		return nil, status.BookmarkFetchingFailed, err
+	}
+	defer rows.Close()
+
+	posts = make([]models.Post, 0)
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, status.BookmarkFetchingFailed, err
+		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, status.BookmarkFetchingFailed, err
+	}
+
+	return posts, status.OperationSuccess, nil
+}
+
+func (d BookmarkPostgresRepository) SearchPosts(ctx context.Context, userID string, query string) (posts []models.Post, opStatus status.OperationStatus, err error) {
+
+	bookmarkID, err := d.activeBookmarkID(ctx, d.DB, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.BookmarkNotExist, errors.New("document not matched")
+		}
+		return nil, status.BookmarkFetchingFailed, err
+	}
+
+	//the index narrows items down, the parser still splits some words unlike SearchTerms so matches get checked again
+	terms := models.SearchTerms(query)
+	rows, err := d.DB.QueryContext(ctx, `SELECT `+postColumns+` FROM bookmark_items
+		WHERE bookmark_id = $1 AND bookmark_item_document(name, note, tags) @@ plainto_tsquery('simple', $2)
+		ORDER BY position`, bookmarkID, strings.Join(terms, " "))
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY SEARCH POSTS: ", err.Error())
+		return nil, status.BookmarkFetchingFailed, err
+	}
+	defer rows.Close()
+
+	posts = make([]models.Post, 0)
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, status.BookmarkFetchingFailed, err
+		}
+		if post.MatchTerms(terms) {
+			posts = append(posts, post)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, status.BookmarkFetchingFailed, err
+	}
+
+	return posts, status.OperationSuccess, nil
+}
+
+func (d BookmarkPostgresRepository) RefreshSnapshots(ctx context.Context, snapshots []models.Post) (opStatus status.OperationStatus, err error) {
+
+	timeNow := time.Now()
+	err = d.inTransaction(ctx, func(tx *sql.Tx) error {
+		for _, snapshot := range snapshots {
+			_, err := tx.ExecContext(ctx, `UPDATE bookmark_items SET name = $2, image_url = $3, snapshot_at = $4
+				WHERE post_id = $1`, snapshot.ID.Hex(), snapshot.Name, snapshot.ImageUrl, timeNow)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY REFRESH SNAPSHOTS: ", err.Error())
+		return status.BookmarkSnapshotFailed, err
+	}
+
+	return status.BookmarkSnapshotSuccess, nil
+}
+
+func (d BookmarkPostgresRepository) PrunePosts(ctx context.Context, postIDs []string) (opStatus status.OperationStatus, err error) {
+
+	err = d.inTransaction(ctx, func(tx *sql.Tx) error {
+
+		//every bookmark holding one of the posts, trashed ones included
+		rows, err := tx.QueryContext(ctx, `DELETE FROM bookmark_items i USING bookmarks b
+			WHERE b.id = i.bookmark_id AND i.post_id = ANY($1)
+			RETURNING b.user_id, b.deleted_at IS NULL, i.post_id`, pq.Array(d.hexIDs(postIDs)))
+		if err != nil {
+			return err
+		}
+		users, removed, err := scanRemovedPosts(rows)
+		if err != nil {
+			return err
+		}
+
+		timeNow := time.Now()
+		for _, userID := range users {
+			if err := d.insertOutbox(ctx, tx, events.NewBookmarkPostRevoked(userID, primitive.NilObjectID, removed[userID], timeNow)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY PRUNE POSTS: ", err.Error())
+		return status.BookmarkDeletePostFailed, err
+	}
+
+	return status.BookmarkDeletePostSuccess, nil
+}
+
+func (d BookmarkPostgresRepository) FetchOutbox(ctx context.Context, limit int64) (outbox []models.OutboxEvent, opStatus status.OperationStatus, err error) {
+
+	//LIMIT NULL returns every row
+	rows, err := d.DB.QueryContext(ctx, `SELECT id, subject, payload, created_at FROM bookmark_outbox
+		ORDER BY position LIMIT NULLIF($1::bigint, 0)`, limit)
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY FETCH OUTBOX: ", err.Error())
+		return nil, status.BookmarkOutboxFailed, err
+	}
+	defer rows.Close()
+
+	outbox = make([]models.OutboxEvent, 0)
+	for rows.Next() {
+		var event models.OutboxEvent
+		var id string
+		if err := rows.Scan(&id, &event.Subject, &event.Payload, &event.CreatedAt); err != nil {
+			log.Println("BOOKMARK REPOSITORY FETCH OUTBOX: ", err.Error())
+			return nil, status.BookmarkOutboxFailed, err
+		}
+		event.ID = d.GenerateObjectIDFromString(id)
+		outbox = append(outbox, event)
+	}
+	if err := rows.Err(); err != nil {
+		log.Println("BOOKMARK REPOSITORY FETCH OUTBOX: ", err.Error())
+		return nil, status.BookmarkOutboxFailed, err
+	}
+
+	return outbox, status.BookmarkOutboxSuccess, nil
+}
+
+func (d BookmarkPostgresRepository) RemoveOutboxEvents(ctx context.Context, eventIDs []string) (opStatus status.OperationStatus, err error) {
+
+	_, err = d.DB.ExecContext(ctx, `DELETE FROM bookmark_outbox WHERE id = ANY($1)`, pq.Array(d.hexIDs(eventIDs)))
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY REMOVE OUTBOX EVENTS: ", err.Error())
+		return status.BookmarkOutboxFailed, err
+	}
+
+	return status.BookmarkOutboxSuccess, nil
+}
+
+func (d BookmarkPostgresRepository) CountByPosts(ctx context.Context, postIDs []string) (counts map[string]int64, opStatus status.OperationStatus, err error) {
+
+	//a post saved in several collections counts once per bookmark
+	rows, err := d.DB.QueryContext(ctx, `SELECT i.post_id, COUNT(DISTINCT i.bookmark_id) FROM bookmark_items i
+		JOIN bookmarks b ON b.id = i.bookmark_id
+		WHERE b.deleted_at IS NULL AND i.post_id = ANY($1)
+		GROUP BY i.post_id`, pq.Array(d.hexIDs(postIDs)))
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY COUNT BY POSTS: ", err.Error())
+		return nil, status.BookmarkFetchingFailed, err
+	}
+	defer rows.Close()
+
+	counts = make(map[string]int64)
+	for rows.Next() {
+		var postID string
+		var count int64
+		if err := rows.Scan(&postID, &count); err != nil {
+			return nil, status.BookmarkFetchingFailed, err
+		}
+		counts[postID] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, status.BookmarkFetchingFailed, err
+	}
+
+	return counts, status.OperationSuccess, nil
+}
+
+func (d BookmarkPostgresRepository) FetchUsersByPost(ctx context.Context, postID string, limit int64, skip int64) (userIDs []string, total int64, opStatus status.OperationStatus, err error) {
+
+	if _, err := primitive.ObjectIDFromHex(postID); err != nil {
+		return nil, 0, status.BookmarkFetchingFailed, err
+	}
+
+	const matching = `FROM bookmarks b WHERE b.deleted_at IS NULL
+		AND EXISTS (SELECT 1 FROM bookmark_items i WHERE i.bookmark_id = b.id AND i.post_id = $1)`
+
+	err = d.DB.QueryRowContext(ctx, `SELECT COUNT(*) `+matching, postID).Scan(&total)
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY FETCH USERS BY POST: ", err.Error())
+		return nil, 0, status.BookmarkFetchingFailed, err
+	}
+
+	//sorted by id like the Mongo implementation
+	rows, err := d.DB.QueryContext(ctx, `SELECT b.user_id `+matching+` ORDER BY b.id LIMIT $2 OFFSET $3`,
+		postID, sqlLimit(limit), skip)
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY FETCH USERS BY POST: ", err.Error())
+		return nil, 0, status.BookmarkFetchingFailed, err
+	}
+	defer rows.Close()
+
+	userIDs = make([]string, 0)
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, 0, status.BookmarkFetchingFailed, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, status.BookmarkFetchingFailed, err
+	}
+
+	return userIDs, total, status.OperationSuccess, nil
+}
+
+func (d BookmarkPostgresRepository) Create(ctx context.Context, bookmark *models.Bookmark) (bookmarkID primitive.ObjectID, opStatus status.OperationStatus, err error) {
+
+	stored := copyBookmark(*bookmark)
+	if stored.ID.IsZero() {
+		stored.ID = d.GenerateModelID()
+	}
+
+	err = d.inTransaction(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO bookmarks (`+bookmarkColumns+`) VALUES ($1, $2, $3, $4, $5)`,
+			stored.ID.Hex(), stored.UserID, stored.CreatedAt, stored.UpdatedAt, stored.DeletedAt)
+		if err != nil {
+			return err
+		}
+		if err := d.insertChildren(ctx, tx, stored); err != nil {
+			return err
+		}
+		for _, event := range createdEvents(stored, time.Now()) {
+			if err := d.insertOutbox(ctx, tx, event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY CREATE: ", err.Error())
+		if isUniqueViolation(err) {
+			return primitive.NilObjectID, status.BookmarkDuplicationOccurs, err
+		}
+		return primitive.NilObjectID, status.BookmarkCreateFailed, err
+	}
+
+	return stored.ID, status.BookmarkCreateSuccess, nil
+}
+
+func (d BookmarkPostgresRepository) Update(ctx context.Context, bookmark *models.Bookmark, bookmarkID string) (opStatus status.OperationStatus, err error) {
+
+	objectID, err := primitive.ObjectIDFromHex(bookmarkID)
+	if err != nil {
+		return status.BookmarkUpdateFailed, err
+	}
+
+	stored := copyBookmark(*bookmark)
+	stored.ID = objectID
+
+	//replace the whole bookmark, like Mongo's $set of the document
+	err = d.inTransaction(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `UPDATE bookmarks SET user_id = $2, created_at = $3, updated_at = $4, deleted_at = $5
+			WHERE id = $1`, bookmarkID, stored.UserID, stored.CreatedAt, stored.UpdatedAt, stored.DeletedAt)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return errNoDocuments
+		}
+
+		//dropping the collections cascades to their items
+		if _, err := tx.ExecContext(ctx, `DELETE FROM bookmark_collections WHERE bookmark_id = $1`, bookmarkID); err != nil {
+			return err
+		}
+		return d.insertChildren(ctx, tx, stored)
+	})
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY UPDATE: ", err.Error())
+		if errors.Is(err, errNoDocuments) {
+			return status.BookmarkNotExist, errors.New("document not matched")
+		}
+		if isUniqueViolation(err) {
+			return status.BookmarkDuplicationOccurs, err
+		}
+		return status.BookmarkUpdateFailed, err
+	}
+
+	return status.BookmarkUpdateSuccess, nil
+}
+
+func (d BookmarkPostgresRepository) Delete(ctx context.Context, bookmarkID string) (opStatus status.OperationStatus, err error) {
+
+	if _, err := primitive.ObjectIDFromHex(bookmarkID); err != nil {
+		return status.BookmarkDeleteFailed, err
+	}
+
+	timeNow := time.Now()
+	err = d.inTransaction(ctx, func(tx *sql.Tx) error {
+		var userID string
+		err := tx.QueryRowContext(ctx, `UPDATE bookmarks SET deleted_at = $2, updated_at = $2
+			WHERE id = $1 AND deleted_at IS NULL
+			RETURNING user_id`, bookmarkID, timeNow).Scan(&userID)
+		if err != nil {
+			return err
+		}
+		return d.insertOutbox(ctx, tx, events.NewBookmarkDeleted(d.GenerateObjectIDFromString(bookmarkID), userID, timeNow))
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return status.BookmarkNotExist, errors.New("document not matched")
+		}
+		log.Println("BOOKMARK REPOSITORY DELETE: ", err.Error())
+		return status.BookmarkDeleteFailed, err
+	}
+
+	return status.BookmarkDeleteSuccess, nil
+}
+
+func (d BookmarkPostgresRepository) FetchTrashed(ctx context.Context, userID string, limit int64, skip int64) (bookmarks []models.Bookmark, opStatus status.OperationStatus, err error) {
+
+	//latest deleted first
+	bookmarks, err = d.queryBookmarks(ctx, d.DB, `SELECT `+bookmarkColumns+` FROM bookmarks
+		WHERE user_id = $1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC LIMIT $2 OFFSET $3`,
+		userID, sqlLimit(limit), skip)
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY FETCH TRASHED: ", err.Error())
+		return nil, status.BookmarkFetchingFailed, err
+	}
+
+	return bookmarks, status.OperationSuccess, nil
+}
+
+func (d BookmarkPostgresRepository) FetchTrashedById(ctx context.Context, userID string, bookmarkID string) (bookmark models.Bookmark, opStatus status.OperationStatus, err error) {
+
+	if _, err := primitive.ObjectIDFromHex(bookmarkID); err != nil {
+		return bookmark, status.BookmarkNotExist, err
+	}
+
+	return d.fetchOne(ctx, []string{}, `SELECT `+bookmarkColumns+` FROM bookmarks
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL`, bookmarkID, userID)
+}
+
+func (d BookmarkPostgresRepository) Restore(ctx context.Context, bookmarkID string) (opStatus status.OperationStatus, err error) {
+
+	if _, err := primitive.ObjectIDFromHex(bookmarkID); err != nil {
+		return status.BookmarkRestoreFailed, err
+	}
+
+	result, err := d.DB.ExecContext(ctx, `UPDATE bookmarks SET deleted_at = NULL, updated_at = $2
+		WHERE id = $1 AND deleted_at IS NOT NULL`, bookmarkID, time.Now())
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY RESTORE: ", err.Error())
+		//the user already owns another active bookmark
+		if isUniqueViolation(err) {
+			return status.BookmarkDuplicationOccurs, err
+		}
+		return status.BookmarkRestoreFailed, err
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return status.BookmarkNotExist, errors.New("document not matched")
+	}
+
+	return status.BookmarkRestoreSuccess, nil
+}
+
+func (d BookmarkPostgresRepository) Purge(ctx context.Context, deletedBefore time.Time) (purged int64, opStatus status.OperationStatus, err error) {
+
+	//collections and items go with their bookmark through ON DELETE CASCADE
+	result, err := d.DB.ExecContext(ctx, `DELETE FROM bookmarks WHERE deleted_at < $1`, deletedBefore)
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY PURGE: ", err.Error())
+		return 0, status.BookmarkPurgeFailed, err
+	}
+
+	purged, err = result.RowsAffected()
+	if err != nil {
+		return 0, status.BookmarkPurgeFailed, err
+	}
+
+	return purged, status.BookmarkPurgeSuccess, nil
+}
+
+func (d BookmarkPostgresRepository) AddPost(ctx context.Context, userID string, collectionID string, postIDs []string) (opStatus status.OperationStatus, err error) {
+
+	collectionObjID, err := primitive.ObjectIDFromHex(collectionID)
+	if err != nil {
+		return status.BookmarkCollectionNotExist, err
+	}
+
+	timeNow := time.Now()
+	err = d.inTransaction(ctx, func(tx *sql.Tx) error {
+
+		//insert every post in input order, the primary key makes already saved posts a no-op
+		rows, err := tx.QueryContext(ctx, `INSERT INTO bookmark_items (bookmark_id, collection_id, post_id, added_at)
+			SELECT c.bookmark_id, c.id, p.post_id, $4::timestamptz
+			FROM bookmark_collections c
+			JOIN bookmarks b ON b.id = c.bookmark_id AND b.user_id = $1 AND b.deleted_at IS NULL
+			CROSS JOIN unnest($3::text[]) WITH ORDINALITY AS p(post_id, n)
+			WHERE c.id = $2
+			ORDER BY p.n
+			ON CONFLICT DO NOTHING
+			RETURNING post_id`, userID, collectionID, pq.Array(d.hexIDs(postIDs)), timeNow)
+		if err != nil {
+			return err
+		}
+		added, err := scanObjectIDs(rows)
+		if err != nil {
+			return err
+		}
+
+		if len(added) > 0 {
+			return d.insertOutbox(ctx, tx, events.NewBookmarkPostAdded(userID, collectionObjID, added, timeNow))
+		}
+
+		//nothing inserted, either every post was already saved or the collection doesn't exist
+		var exists bool
+		err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM bookmark_collections c
+			JOIN bookmarks b ON b.id = c.bookmark_id AND b.user_id = $1 AND b.deleted_at IS NULL
+			WHERE c.id = $2)`, userID, collectionID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return errNoDocuments
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errNoDocuments) {
+			log.Println("BOOKMARK REPOSITORY ADD POST: document not matched")
+			return status.BookmarkNotExist, errors.New("document not matched")
+		}
+		log.Println("BOOKMARK REPOSITORY ADD POST: ", err.Error())
+		return status.BookmarkPostFailed, err
+	}
+
+	return status.BookmarkPostSuccess, nil
+}
+
+func (d BookmarkPostgresRepository) UpdatePost(ctx context.Context, userID string, collectionID string, postID string, note *string, tags []string) (opStatus status.OperationStatus, err error) {
+
+	if _, err := primitive.ObjectIDFromHex(postID); err != nil {
+		return status.BookmarkPostNotExist, err
+	}
+	if collectionID != "" {
+		if _, err := primitive.ObjectIDFromHex(collectionID); err != nil {
+			return status.BookmarkCollectionNotExist, err
+		}
+	}
+
+	//nil note or tags are bound as NULL and keep the stored value
+	var tagsParam interface{}
+	if tags != nil {
+		tagsParam = pq.Array(tags)
+	}
+
+	var matched int64
+	err = d.inTransaction(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `UPDATE bookmark_items i
+			SET note = COALESCE($4::text, i.note), tags = COALESCE($5::text[], i.tags)
+			FROM bookmarks b
+			WHERE b.id = i.bookmark_id AND b.user_id = $1 AND b.deleted_at IS NULL
+			AND i.post_id = $3 AND ($2::text = '' OR i.collection_id = $2)`, userID, collectionID, postID, note, tagsParam)
+		if err != nil {
+			return err
+		}
+		if matched, err = result.RowsAffected(); err != nil || matched == 0 {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE bookmarks SET updated_at = $2 WHERE user_id = $1 AND deleted_at IS NULL`,
+			userID, time.Now())
+		return err
+	})
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY UPDATE POST: ", err.Error())
+		return status.BookmarkPostUpdateFailed, err
+	}
+
+	if matched == 0 {
+		return status.BookmarkPostNotExist, errors.New("post not matched")
+	}
+
+	return status.BookmarkPostUpdateSuccess, nil
+}
+
+func (d BookmarkPostgresRepository) RevokePost(ctx context.Context, userID string, collectionID string, postIDs []string) (opStatus status.OperationStatus, err error) {
+
+	if collectionID != "" {
+		if _, err := primitive.ObjectIDFromHex(collectionID); err != nil {
+			return status.BookmarkCollectionNotExist, err
+		}
+	}
+
+	bookmarkID, err := d.activeBookmarkID(ctx, d.DB, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return status.BookmarkNotExist, errors.New("document not matched")
+		}
+		return status.BookmarkDeletePostFailed, err
+	}
+
+	//an empty collection id revokes the posts from every collection
+	err = d.inTransaction(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `DELETE FROM bookmark_items
+			WHERE bookmark_id = $1 AND post_id = ANY($2) AND ($3::text = '' OR collection_id = $3)
+			RETURNING post_id`, bookmarkID, pq.Array(d.hexIDs(postIDs)), collectionID)
+		if err != nil {
+			return err
+		}
+		removed, err := scanObjectIDs(rows)
+		if err != nil || len(removed) == 0 {
+			return err
+		}
+
+		collectionObjID, _ := primitive.ObjectIDFromHex(collectionID)
+		return d.insertOutbox(ctx, tx, events.NewBookmarkPostRevoked(userID, collectionObjID, removed, time.Now()))
+	})
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY REVOKE POST: ", err.Error())
+		return status.BookmarkDeletePostFailed, err
+	}
+
+	return status.BookmarkDeletePostSuccess, nil
+}
+
+func (d BookmarkPostgresRepository) CreateCollection(ctx context.Context, userID string, collection *models.Collection) (opStatus status.OperationStatus, err error) {
+
+	result, err := d.DB.ExecContext(ctx, `INSERT INTO bookmark_collections (id, bookmark_id, name, is_default, created_at, updated_at)
+		SELECT $2::varchar, b.id, $3::text, $4::boolean, $5::timestamptz, $6::timestamptz FROM bookmarks b WHERE b.user_id = $1 AND b.deleted_at IS NULL`,
+		userID, collection.ID.Hex(), collection.Name, collection.IsDefault, collection.CreatedAt, collection.UpdatedAt)
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY CREATE COLLECTION: ", err.Error())
+		return status.BookmarkCollectionCreateFailed, err
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return status.BookmarkNotExist, errors.New("document not matched")
+	}
+
+	return status.BookmarkCollectionCreateSuccess, nil
+}
+
+func (d BookmarkPostgresRepository) RenameCollection(ctx context.Context, userID string, collectionID string, name string) (opStatus status.OperationStatus, err error) {
+
+	if _, err := primitive.ObjectIDFromHex(collectionID); err != nil {
+		return status.BookmarkCollectionNotExist, err
+	}
+
+	result, err := d.DB.ExecContext(ctx, `UPDATE bookmark_collections c SET name = $3, updated_at = $4
+		FROM bookmarks b
+		WHERE b.id = c.bookmark_id AND b.user_id = $1 AND b.deleted_at IS NULL AND c.id = $2`,
+		userID, collectionID, name, time.Now())
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY RENAME COLLECTION: ", err.Error())
+		return status.BookmarkCollectionUpdateFailed, err
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return status.BookmarkCollectionNotExist, errors.New("collection not matched")
+	}
+
+	return status.BookmarkCollectionUpdateSuccess, nil
+}
+
+func (d BookmarkPostgresRepository) DeleteCollection(ctx context.Context, userID string, collectionID string) (opStatus status.OperationStatus, err error) {
+
+	if _, err := primitive.ObjectIDFromHex(collectionID); err != nil {
+		return status.BookmarkCollectionNotExist, err
+	}
+
+	err = d.inTransaction(ctx, func(tx *sql.Tx) error {
+
+		//the items are removed ahead of their collection, the cascade wouldn't tell which posts went
+		rows, err := tx.QueryContext(ctx, `DELETE FROM bookmark_items i
+			USING bookmark_collections c, bookmarks b
+			WHERE c.id = i.collection_id AND b.id = c.bookmark_id AND b.user_id = $1 AND b.deleted_at IS NULL
+			AND c.id = $2 AND c.is_default = FALSE
+			RETURNING i.post_id`, userID, collectionID)
+		if err != nil {
+			return err
+		}
+		removed, err := scanObjectIDs(rows)
+		if err != nil {
+			return err
+		}
+
+		//the default collection can never be removed
+		result, err := tx.ExecContext(ctx, `DELETE FROM bookmark_collections c
+			USING bookmarks b
+			WHERE b.id = c.bookmark_id AND b.user_id = $1 AND b.deleted_at IS NULL
+			AND c.id = $2 AND c.is_default = FALSE`, userID, collectionID)
+		if err != nil {
+			return err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return errNoDocuments
+		}
+
+		if len(removed) == 0 {
+			return nil
+		}
+		return d.insertOutbox(ctx, tx, events.NewBookmarkPostRevoked(userID, d.GenerateObjectIDFromString(collectionID), removed, time.Now()))
+	})
+	if err != nil {
+		if errors.Is(err, errNoDocuments) {
+			return status.BookmarkCollectionNotExist, errors.New("collection not matched")
+		}
+		log.Println("BOOKMARK REPOSITORY DELETE COLLECTION: ", err.Error())
+		return status.BookmarkCollectionDeleteFailed, err
+	}
+
+	return status.BookmarkCollectionDeleteSuccess, nil
+}
+
+func (d BookmarkPostgresRepository) GenerateModelID() primitive.ObjectID {
+	return primitive.NewObjectID()
+}
+
+func (d BookmarkPostgresRepository) GenerateObjectIDFromString(id string) primitive.ObjectID {
+	hex, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID
+	}
+	return hex
+}
+
+func (d BookmarkPostgresRepository) fetchOne(ctx context.Context, exclude []string, query string, args ...interface{}) (bookmark models.Bookmark, opStatus status.OperationStatus, err error) {
+
+	bookmarks, err := d.queryBookmarks(ctx, d.DB, query, args...)
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY FETCH: ", err.Error())
+		return bookmark, status.BookmarkFetchingFailed, err
+	}
+
+	if len(bookmarks) == 0 {
+		return bookmark, status.BookmarkNotExist, errNoDocuments
+	}
+
+	return excludeFields(bookmarks[0], exclude), status.OperationSuccess, nil
+}
+
+// queryBookmarks run a query selecting bookmarkColumns and load the collections and posts of every row
+func (d BookmarkPostgresRepository) queryBookmarks(ctx context.Context, q queryer, query string, args ...interface{}) ([]models.Bookmark, error) {
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bookmarks := make([]models.Bookmark, 0)
+	index := make(map[string]int)
+	for rows.Next() {
+		var id string
+		var createdAt, updatedAt, deletedAt sql.NullTime
+		bookmark := models.Bookmark{Collections: make([]models.Collection, 0), Posts: make([]models.Post, 0)}
+		if err := rows.Scan(&id, &bookmark.UserID, &createdAt, &updatedAt, &deletedAt); err != nil {
+			return nil, err
+		}
+		bookmark.ID = d.GenerateObjectIDFromString(id)
+		bookmark.CreatedAt = nullTime(createdAt)
+		bookmark.UpdatedAt = nullTime(updatedAt)
+		bookmark.DeletedAt = nullTime(deletedAt)
+
+		index[id] = len(bookmarks)
+		bookmarks = append(bookmarks, bookmark)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(bookmarks) == 0 {
+		return bookmarks, nil
+	}
+
+	ids := make([]string, 0, len(index))
+	for id := range index {
+		ids = append(ids, id)
+	}
+
+	//collections
+	collectionRows, err := q.QueryContext(ctx, `SELECT bookmark_id, id, name, is_default, created_at, updated_at
+		FROM bookmark_collections WHERE bookmark_id = ANY($1) ORDER BY position`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer collectionRows.Close()
+
+	for collectionRows.Next() {
+		var bookmarkID, id string
+		var createdAt, updatedAt sql.NullTime
+		var collection models.Collection
+		if err := collectionRows.Scan(&bookmarkID, &id, &collection.Name, &collection.IsDefault, &createdAt, &updatedAt); err != nil {
+			return nil, err
+		}
+		collection.ID = d.GenerateObjectIDFromString(id)
+		collection.CreatedAt = nullTime(createdAt)
+		collection.UpdatedAt = nullTime(updatedAt)
+
+		b := &bookmarks[index[bookmarkID]]
+		b.Collections = append(b.Collections, collection)
+	}
+	if err := collectionRows.Err(); err != nil {
+		return nil, err
+	}
+
+	//posts
+	itemRows, err := q.QueryContext(ctx, `SELECT bookmark_id, `+postColumns+`
+		FROM bookmark_items WHERE bookmark_id = ANY($1) ORDER BY position`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var bookmarkID string
+		post, err := scanPost(itemRows, &bookmarkID)
+		if err != nil {
+			return nil, err
+		}
+
+		b := &bookmarks[index[bookmarkID]]
+		b.Posts = append(b.Posts, post)
+	}
+
+	return bookmarks, itemRows.Err()
+}
+
+// insertChildren write the collections and posts of a freshly inserted bookmark row
+func (d BookmarkPostgresRepository) insertChildren(ctx context.Context, tx *sql.Tx, bookmark models.Bookmark) error {
+
+	for _, c := range bookmark.Collections {
+		_, err := tx.ExecContext(ctx, `INSERT INTO bookmark_collections (id, bookmark_id, name, is_default, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6)`, c.ID.Hex(), bookmark.ID.Hex(), c.Name, c.IsDefault, c.CreatedAt, c.UpdatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	timeNow := time.Now()
+	for _, p := range bookmark.Posts {
+		addedAt := timeNow
+		if p.AddedAt != nil {
+			addedAt = *p.AddedAt
+		}
+		tags := p.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO bookmark_items (bookmark_id, collection_id, post_id, added_at, note, tags, name, image_url, snapshot_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT DO NOTHING`,
+			bookmark.ID.Hex(), p.CollectionID.Hex(), p.ID.Hex(), addedAt, p.Note, pq.Array(tags), p.Name, p.ImageUrl, p.SnapshotAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d BookmarkPostgresRepository) activeBookmarkID(ctx context.Context, q queryer, userID string) (bookmarkID string, err error) {
+	err = q.QueryRowContext(ctx, `SELECT id FROM bookmarks WHERE user_id = $1 AND deleted_at IS NULL`, userID).Scan(&bookmarkID)
+	return bookmarkID, err
+}
+
+// insertOutbox write the event in the transaction of the change it reports
+func (d BookmarkPostgresRepository) insertOutbox(ctx context.Context, tx *sql.Tx, event models.OutboxEvent) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO bookmark_outbox (id, subject, payload, created_at) VALUES ($1, $2, $3, $4)`,
+		event.ID.Hex(), event.Subject, event.Payload, event.CreatedAt)
+	return err
+}
+
+// scanObjectIDs read a single id column, once per id, and close the rows
+func scanObjectIDs(rows *sql.Rows) ([]primitive.ObjectID, error) {
+
+	defer rows.Close()
+
+	ids := make([]primitive.ObjectID, 0)
+	seen := make(map[primitive.ObjectID]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		objectID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[objectID] {
+			seen[objectID] = true
+			ids = append(ids, objectID)
+		}
+	}
+
+	return ids, rows.Err()
+}
+
+// scanRemovedPosts read the user id, activeness and post id of removed items and close the rows.
+// it groups the posts of active bookmarks by user, users in the order they first show up
+func scanRemovedPosts(rows *sql.Rows) (users []string, removed map[string][]primitive.ObjectID, err error) {
+
+	defer rows.Close()
+
+	users = make([]string, 0)
+	removed = make(map[string][]primitive.ObjectID)
+	for rows.Next() {
+		var userID, postID string
+		var active bool
+		if err := rows.Scan(&userID, &active, &postID); err != nil {
+			return nil, nil, err
+		}
+		//trashed bookmarks lose the posts too, their removal was reported when they were deleted
+		if !active {
+			continue
+		}
+		objectID, err := primitive.ObjectIDFromHex(postID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, ok := removed[userID]; !ok {
+			users = append(users, userID)
+		}
+		if !hasID(removed[userID], objectID) {
+			removed[userID] = append(removed[userID], objectID)
+		}
+	}
+
+	return users, removed, rows.Err()
+}
+
+func (d BookmarkPostgresRepository) inTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// hexIDs normalise post ids the way GenerateObjectIDFromString does for the other storages
+func (d BookmarkPostgresRepository) hexIDs(ids []string) []string {
+	hexIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		hexIDs = append(hexIDs, d.GenerateObjectIDFromString(id).Hex())
+	}
+	return hexIDs
+}
+
+// scanPost read a row selecting postColumns, 'leading' receives the columns selected before them
+func scanPost(rows *sql.Rows, leading ...interface{}) (models.Post, error) {
+
+	var postID, collectionID string
+	var addedAt time.Time
+	var snapshotAt sql.NullTime
+	post := models.Post{Tags: []string{}}
+	dest := append(leading, &postID, &collectionID, &addedAt, &post.Note, pq.Array(&post.Tags), &post.Name, &post.ImageUrl, &snapshotAt)
+	if err := rows.Scan(dest...); err != nil {
+		return post, err
+	}
+
+	post.ID, _ = primitive.ObjectIDFromHex(postID)
+	post.CollectionID, _ = primitive.ObjectIDFromHex(collectionID)
+	post.AddedAt = &addedAt
+	post.SnapshotAt = nullTime(snapshotAt)
+
+	return post, nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// sqlLimit bind a zero limit as NULL, which Postgres reads as no limit like Mongo does
+func sqlLimit(limit int64) interface{} {
+	if limit <= 0 {
+		return nil
+	}
+	return limit
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func NewBookmarkPostgresRepository(db *sql.DB) contracts.BookmarksRepository {
+	return &BookmarkPostgresRepository{DB: db}
+}
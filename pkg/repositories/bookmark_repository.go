@@ -9,8 +9,11 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"golek_bookmark_service/pkg/contracts"
 	"golek_bookmark_service/pkg/contracts/status"
+	"golek_bookmark_service/pkg/events"
 	"golek_bookmark_service/pkg/models"
 	"log"
+	"strings"
+	"time"
 )
 
 type BookmarkRepository struct {
@@ -18,7 +21,7 @@ type BookmarkRepository struct {
 	Collection *mongo.Collection
 }
 
-func (d BookmarkRepository) Fetch(ctx context.Context, exclude []string, limit int64, skip int64) (bookmarks []models.Bookmark, opStatus status.OperationStatus, err error) {
+func (d BookmarkRepository) Fetch(ctx context.Context, filter models.BookmarkFilter, exclude []string, limit int64, after *models.Cursor) (bookmarks []models.Bookmark, total int64, opStatus status.OperationStatus, err error) {
 
 	//Exclude fields
 	excluded := make(map[string]int)
@@ -30,14 +33,45 @@ func (d BookmarkRepository) Fetch(ctx context.Context, exclude []string, limit i
 	opts := options.Find()
 	opts.SetProjection(excluded)
 	opts.SetLimit(limit)
-	opts.SetSkip(skip)
+	//ties go by ascending _id like the other storages, cursors rely on it
+	if filter.SortBy == "" {
+		opts.SetSort(bson.D{{Key: "_id", Value: 1}})
+	} else {
+		direction := 1
+		if filter.SortDescending {
+			direction = -1
+		}
+		opts.SetSort(bson.D{{Key: filter.SortBy, Value: direction}, {Key: "_id", Value: 1}})
+	}
 
 	//Fetch Records
-	filter := map[string]interface{}{"deleted_at": nil}
+	query := bson.M{"deleted_at": nil}
+	if filter.UserID != "" {
+		query["user_id"] = filter.UserID
+	}
+	if filter.PostID != "" {
+		query["posts.id"] = d.GenerateObjectIDFromString(filter.PostID)
+	}
+	if r := dateRange(filter.CreatedFrom, filter.CreatedTo); r != nil {
+		query["created_at"] = r
+	}
+	if r := dateRange(filter.UpdatedFrom, filter.UpdatedTo); r != nil {
+		query["updated_at"] = r
+	}
 
-	records, err := d.Collection.Find(ctx, filter, opts)
+	total, err = d.Collection.CountDocuments(ctx, query)
 	if err != nil {
-		return nil, status.BookmarkFetchingFailed, err
+		return nil, 0, status.BookmarkFetchingFailed, err
+	}
+
+	//the total ignores the cursor, it counts the whole listing
+	if after != nil {
+		query["$or"] = d.afterCursor(filter, *after)
+	}
+
+	records, err := d.Collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, status.BookmarkFetchingFailed, err
 	}
 
 	//Close Cursor
@@ -52,13 +86,60 @@ func (d BookmarkRepository) Fetch(ctx context.Context, exclude []string, limit i
 
 	err = records.All(ctx, &bookmarks)
 	if err != nil {
-		return nil, status.BookmarkFetchingFailed, err
+		return nil, 0, status.BookmarkFetchingFailed, err
 	}
 
-	return bookmarks, status.OperationSuccess, nil
+	return bookmarks, total, status.OperationSuccess, nil
 
 }
 
+// afterCursor match what comes after the cursor in the listing order, see models.BookmarkFilter.After.
+// nulls sort first in ascending order and last in descending order
+func (d BookmarkRepository) afterCursor(filter models.BookmarkFilter, after models.Cursor) []bson.M {
+
+	id := d.GenerateObjectIDFromString(after.ID)
+	if filter.SortBy == "" {
+		return []bson.M{{"_id": bson.M{"$gt": id}}}
+	}
+
+	var value interface{}
+	if filter.SortBy == models.SortByUserID {
+		value = after.Text
+	} else if after.Time != nil {
+		value = *after.Time
+	}
+
+	conditions := []bson.M{{filter.SortBy: value, "_id": bson.M{"$gt": id}}}
+	switch {
+	case value == nil && !filter.SortDescending:
+		conditions = append(conditions, bson.M{filter.SortBy: bson.M{"$ne": nil}})
+	case value != nil && !filter.SortDescending:
+		conditions = append(conditions, bson.M{filter.SortBy: bson.M{"$gt": value}})
+	case value != nil && filter.SortDescending:
+		conditions = append(conditions, bson.M{filter.SortBy: bson.M{"$lt": value}})
+		if filter.SortBy != models.SortByUserID {
+			conditions = append(conditions, bson.M{filter.SortBy: nil})
+		}
+	}
+
+	return conditions
+}
+
+// dateRange build an inclusive range condition, nil when both bounds are open
+func dateRange(from *time.Time, to *time.Time) bson.M {
+	if from == nil && to == nil {
+		return nil
+	}
+	condition := bson.M{}
+	if from != nil {
+		condition["$gte"] = *from
+	}
+	if to != nil {
+		condition["$lte"] = *to
+	}
+	return condition
+}
+
 func (d BookmarkRepository) FetchById(ctx context.Context, id string, exclude []string) (bookmarks models.Bookmark, opStatus status.OperationStatus, err error) {
 
 	//Exclude fields
@@ -72,7 +153,7 @@ func (d BookmarkRepository) FetchById(ctx context.Context, id string, exclude []
 	//Convert model id from string to mongodb objectID
 	modelID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return bookmark, status.BookmarkFetchingFailed, err
+		return bookmark, status.BookmarkNotExist, err
 	}
 
 	//Set options
@@ -116,37 +197,325 @@ func (d BookmarkRepository) FetchByUserId(ctx context.Context, userId string, ex
 	return bookmark, status.OperationSuccess, nil
 }
 
-func (d BookmarkRepository) Create(ctx context.Context, bookmark *models.Bookmark) (postID primitive.ObjectID, opStatus status.OperationStatus, err error) {
+func (d BookmarkRepository) FetchSavedPosts(ctx context.Context, userID string, postIDs []string) (posts []models.Post, opStatus status.OperationStatus, err error) {
+
+	//Convert postIDs string to ObjectID
+	postObjIDs := make([]primitive.ObjectID, 0)
+	for _, id := range postIDs {
+		postObjIDs = append(postObjIDs, d.GenerateObjectIDFromString(id))
+	}
 
-	//	Use Transaction
-	err = d.Connection.Client().UseSession(ctx, func(sessionContext mongo.SessionContext) error {
+	//1. Query by user id
+	//2. Project only the saved items matching the requested post ids
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "user_id", Value: userID}, {Key: "deleted_at", Value: nil}}}},
+		{{Key: "$project", Value: bson.M{
+			"_id": 0,
+			"posts": bson.M{"$filter": bson.M{
+				"input": bson.M{"$ifNull": bson.A{"$posts", bson.A{}}},
+				"as":    "post",
+				"cond":  bson.M{"$in": bson.A{"$$post.id", postObjIDs}},
+			}},
+		}}},
+	}
+
+	records, err := d.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, status.BookmarkFetchingFailed, err
+	}
 
-		// Start Transaction
-		err := sessionContext.StartTransaction()
+	//Close Cursor
+	defer func(records *mongo.Cursor, ctx context.Context) {
+		err := records.Close(ctx)
 		if err != nil {
-			return err
+			log.Println(err)
 		}
+	}(records, ctx)
+
+	var results []models.Bookmark
+	err = records.All(ctx, &results)
+	if err != nil {
+		return nil, status.BookmarkFetchingFailed, err
+	}
+
+	if len(results) == 0 {
+		return nil, status.BookmarkNotExist, errors.New("document not matched")
+	}
+
+	return results[0].Posts, status.OperationSuccess, nil
+}
+
+func (d BookmarkRepository) SearchPosts(ctx context.Context, userID string, query string) (posts []models.Post, opStatus status.OperationStatus, err error) {
+
+	//the text index only tells the bookmark holds some of the terms,
+	//posts are then narrowed to those holding every term
+	terms := models.SearchTerms(query)
+	filter := bson.D{{Key: "user_id", Value: userID}, {Key: "deleted_at", Value: nil}}
+	if len(terms) > 0 {
+		filter = append(filter, bson.E{Key: "$text", Value: bson.M{"$search": strings.Join(terms, " ")}})
+	}
+
+	var bookmark models.Bookmark
+	err = d.Collection.FindOne(ctx, filter, options.FindOne().SetProjection(bson.M{"posts": 1})).Decode(&bookmark)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Println("BOOKMARK REPOSITORY SEARCH POSTS: ", err.Error())
+			return nil, status.BookmarkFetchingFailed, err
+		}
+
+		//nothing matched, tell a missing bookmark apart from an empty result
+		count, err := d.Collection.CountDocuments(ctx, bson.M{"user_id": userID, "deleted_at": nil})
+		if err != nil {
+			return nil, status.BookmarkFetchingFailed, err
+		}
+		if count == 0 {
+			return nil, status.BookmarkNotExist, errors.New("document not matched")
+		}
+	}
+
+	return searchPosts(&bookmark, terms), status.OperationSuccess, nil
+}
+
+func (d BookmarkRepository) RefreshSnapshots(ctx context.Context, snapshots []models.Post) (opStatus status.OperationStatus, err error) {
+
+	timeNow := time.Now()
+
+	//one write per post, refreshing every copy of it in every bookmark
+	writes := make([]mongo.WriteModel, 0)
+	for _, snapshot := range snapshots {
+		writes = append(writes, mongo.NewUpdateManyModel().
+			SetFilter(bson.M{"posts.id": snapshot.ID}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"posts.$[item].name":        snapshot.Name,
+				"posts.$[item].image_url":   snapshot.ImageUrl,
+				"posts.$[item].snapshot_at": timeNow,
+			}}).
+			SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"item.id": snapshot.ID}}}))
+	}
+	if len(writes) == 0 {
+		return status.BookmarkSnapshotSuccess, nil
+	}
+
+	_, err = d.Collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY REFRESH SNAPSHOTS: ", err.Error())
+		return status.BookmarkSnapshotFailed, err
+	}
+
+	return status.BookmarkSnapshotSuccess, nil
+}
+
+func (d BookmarkRepository) FetchOutbox(ctx context.Context, limit int64) (outbox []models.OutboxEvent, opStatus status.OperationStatus, err error) {
+
+	//Set options
+	opts := options.Find()
+	opts.SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	opts.SetLimit(limit)
+
+	records, err := d.outbox().Find(ctx, bson.D{}, opts)
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY FETCH OUTBOX: ", err.Error())
+		return nil, status.BookmarkOutboxFailed, err
+	}
+
+	//Close Cursor
+	defer func(records *mongo.Cursor, ctx context.Context) {
+		err := records.Close(ctx)
+		if err != nil {
+			log.Println(err)
+		}
+	}(records, ctx)
+
+	outbox = make([]models.OutboxEvent, 0)
+	if err := records.All(ctx, &outbox); err != nil {
+		log.Println("BOOKMARK REPOSITORY FETCH OUTBOX: ", err.Error())
+		return nil, status.BookmarkOutboxFailed, err
+	}
 
-		// Insert Data To the Database & abort if it fails
-		insertedData, err := d.Collection.InsertOne(ctx, bookmark)
+	return outbox, status.BookmarkOutboxSuccess, nil
+}
+
+func (d BookmarkRepository) RemoveOutboxEvents(ctx context.Context, eventIDs []string) (opStatus status.OperationStatus, err error) {
+
+	_, err = d.outbox().DeleteMany(ctx, bson.M{"_id": bson.M{"$in": objectIDs(eventIDs)}})
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY REMOVE OUTBOX EVENTS: ", err.Error())
+		return status.BookmarkOutboxFailed, err
+	}
+
+	return status.BookmarkOutboxSuccess, nil
+}
+
+func (d BookmarkRepository) PrunePosts(ctx context.Context, postIDs []string) (opStatus status.OperationStatus, err error) {
+
+	pIDs := objectIDs(postIDs)
+	if len(pIDs) == 0 {
+		return status.BookmarkDeletePostSuccess, nil
+	}
+
+	//every bookmark holding one of the posts, trashed ones included
+	filter := bson.M{"posts.id": bson.M{"$in": pIDs}}
+	statement := bson.M{"$pull": bson.M{"posts": bson.M{"id": bson.M{"$in": pIDs}}}}
+
+	pruned := objectIDSet(postIDs)
+	err = d.inTransaction(ctx, func(sc mongo.SessionContext) error {
+
+		//only active bookmarks report what they lose, the removal of trashed ones was reported when they were deleted
+		active := bson.M{"posts.id": bson.M{"$in": pIDs}, "deleted_at": nil}
+		records, err := d.Collection.Find(sc, active, options.Find().SetProjection(bson.M{"user_id": 1, "posts.id": 1}))
 		if err != nil {
-			err := sessionContext.AbortTransaction(ctx)
-			if err != nil {
+			return err
+		}
+		var bookmarks []models.Bookmark
+		if err := records.All(sc, &bookmarks); err != nil {
+			return err
+		}
+
+		if _, err := d.Collection.UpdateMany(sc, filter, statement); err != nil {
+			return err
+		}
+
+		timeNow := time.Now()
+		for i := range bookmarks {
+			removed := removePosts(&bookmarks[i], primitive.NilObjectID, pruned)
+			if len(removed) == 0 {
+				continue
+			}
+			if err := d.insertOutbox(sc, events.NewBookmarkPostRevoked(bookmarks[i].UserID, primitive.NilObjectID, removed, timeNow)); err != nil {
 				return err
 			}
-			return err
 		}
+		return nil
+	})
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY PRUNE POSTS: ", err.Error())
+		return status.BookmarkDeletePostFailed, err
+	}
 
-		postID = insertedData.InsertedID.(primitive.ObjectID)
+	return status.BookmarkDeletePostSuccess, nil
+}
+
+func (d BookmarkRepository) CountByPosts(ctx context.Context, postIDs []string) (counts map[string]int64, opStatus status.OperationStatus, err error) {
+
+	//Convert postIDs string to ObjectID
+	postObjIDs := make([]primitive.ObjectID, 0)
+	for _, id := range postIDs {
+		postObjIDs = append(postObjIDs, d.GenerateObjectIDFromString(id))
+	}
+
+	//1. Match bookmarks holding any of the posts, served by the posts.id multikey index
+	//2. Keep each requested post once per bookmark, a post saved in several collections counts once
+	//3. Count bookmarks per post
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "posts.id", Value: bson.M{"$in": postObjIDs}},
+			{Key: "deleted_at", Value: nil},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":      0,
+			"post_ids": bson.M{"$setIntersection": bson.A{"$posts.id", postObjIDs}},
+		}}},
+		{{Key: "$unwind", Value: "$post_ids"}},
+		{{Key: "$group", Value: bson.M{"_id": "$post_ids", "count": bson.M{"$sum": 1}}}},
+	}
 
-		// Commit Data if no error
-		err = sessionContext.CommitTransaction(ctx)
+	records, err := d.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, status.BookmarkFetchingFailed, err
+	}
+
+	//Close Cursor
+	defer func(records *mongo.Cursor, ctx context.Context) {
+		err := records.Close(ctx)
+		if err != nil {
+			log.Println(err)
+		}
+	}(records, ctx)
+
+	var results []struct {
+		PostID primitive.ObjectID `bson:"_id"`
+		Count  int64              `bson:"count"`
+	}
+	err = records.All(ctx, &results)
+	if err != nil {
+		return nil, status.BookmarkFetchingFailed, err
+	}
+
+	counts = make(map[string]int64)
+	for _, r := range results {
+		counts[r.PostID.Hex()] = r.Count
+	}
+
+	return counts, status.OperationSuccess, nil
+}
+
+func (d BookmarkRepository) FetchUsersByPost(ctx context.Context, postID string, limit int64, skip int64) (userIDs []string, total int64, opStatus status.OperationStatus, err error) {
+
+	postObjID, err := primitive.ObjectIDFromHex(postID)
+	if err != nil {
+		return nil, 0, status.BookmarkFetchingFailed, err
+	}
+
+	filter := bson.D{{Key: "posts.id", Value: postObjID}, {Key: "deleted_at", Value: nil}}
+
+	total, err = d.Collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, status.BookmarkFetchingFailed, err
+	}
+
+	//Set options
+	opts := options.Find()
+	opts.SetProjection(bson.M{"_id": 0, "user_id": 1})
+	opts.SetSort(bson.D{{Key: "_id", Value: 1}})
+	opts.SetLimit(limit)
+	opts.SetSkip(skip)
+
+	records, err := d.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, status.BookmarkFetchingFailed, err
+	}
+
+	//Close Cursor
+	defer func(records *mongo.Cursor, ctx context.Context) {
+		err := records.Close(ctx)
+		if err != nil {
+			log.Println(err)
+		}
+	}(records, ctx)
+
+	var results []struct {
+		UserID string `bson:"user_id"`
+	}
+	err = records.All(ctx, &results)
+	if err != nil {
+		return nil, 0, status.BookmarkFetchingFailed, err
+	}
+
+	userIDs = make([]string, 0)
+	for _, r := range results {
+		userIDs = append(userIDs, r.UserID)
+	}
+
+	return userIDs, total, status.OperationSuccess, nil
+}
+
+func (d BookmarkRepository) Create(ctx context.Context, bookmark *models.Bookmark) (postID primitive.ObjectID, opStatus status.OperationStatus, err error) {
+
+	//the bookmark and the events of the posts it starts with are written together
+	err = d.inTransaction(ctx, func(sc mongo.SessionContext) error {
+
+		insertedData, err := d.Collection.InsertOne(sc, bookmark)
 		if err != nil {
 			return err
 		}
+		postID = insertedData.InsertedID.(primitive.ObjectID)
 
+		for _, event := range createdEvents(*bookmark, time.Now()) {
+			if err := d.insertOutbox(sc, event); err != nil {
+				return err
+			}
+		}
 		return nil
-
 	})
 
 	if err != nil {
@@ -166,18 +535,18 @@ func (d BookmarkRepository) Update(ctx context.Context, bookmark *models.Bookmar
 		return status.BookmarkUpdateFailed, err
 	}
 
-	filter := bson.D{{"_id", objectId}}
-	result, err := d.Collection.UpdateOne(ctx, filter, bson.D{{"$set", bookmark}})
+	filter := bson.D{{Key: "_id", Value: objectId}}
+	result, err := d.Collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: bookmark}})
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return status.BookmarkNotExist, err
 		}
 		return status.BookmarkUpdateFailed, err
 	}
-	if result.MatchedCount != 0 {
-		return status.BookmarkUpdateSuccess, nil
+	if result.MatchedCount == 0 {
+		return status.BookmarkNotExist, errors.New("document not matched")
 	}
-	return status.BookmarkUpdateFailed, err
+	return status.BookmarkUpdateSuccess, nil
 }
 
 func (d BookmarkRepository) Delete(ctx context.Context, bookmarkID string) (opStatus status.OperationStatus, err error) {
@@ -188,94 +557,381 @@ func (d BookmarkRepository) Delete(ctx context.Context, bookmarkID string) (opSt
 	}
 
 	//set filters
-	filter := bson.D{{"_id", objectID}}
+	filter := bson.D{{Key: "_id", Value: objectID}, {Key: "deleted_at", Value: nil}}
 
-	result, err := d.Collection.DeleteOne(ctx, filter)
+	//Set statements
+	//1. move the bookmark to the trash instead of removing it
+	timeNow := time.Now()
+	statement := bson.M{"$set": bson.M{"deleted_at": timeNow, "updated_at": timeNow}}
+
+	err = d.inTransaction(ctx, func(sc mongo.SessionContext) error {
+		var bookmark models.Bookmark
+		err := d.Collection.FindOneAndUpdate(sc, filter, statement, options.FindOneAndUpdate().SetProjection(bson.M{"user_id": 1})).Decode(&bookmark)
+		if err != nil {
+			return err
+		}
+		return d.insertOutbox(sc, events.NewBookmarkDeleted(objectID, bookmark.UserID, timeNow))
+	})
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return status.BookmarkNotExist, err
+			return status.BookmarkNotExist, errors.New("document not matched")
 		}
 		return status.BookmarkDeleteFailed, err
 	}
 
-	if result.DeletedCount == 0 {
-		return status.BookmarkNotExist, err
+	return status.BookmarkDeleteSuccess, nil
+}
+
+func (d BookmarkRepository) FetchTrashed(ctx context.Context, userID string, limit int64, skip int64) (bookmarks []models.Bookmark, opStatus status.OperationStatus, err error) {
+
+	//Set options
+	opts := options.Find()
+	opts.SetSort(bson.D{{Key: "deleted_at", Value: -1}})
+	opts.SetLimit(limit)
+	opts.SetSkip(skip)
+
+	//Fetch Records
+	filter := bson.D{{Key: "user_id", Value: userID}, {Key: "deleted_at", Value: bson.M{"$ne": nil}}}
+
+	records, err := d.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, status.BookmarkFetchingFailed, err
 	}
 
-	return status.BookmarkDeleteSuccess, nil
+	//Close Cursor
+	defer func(records *mongo.Cursor, ctx context.Context) {
+		err := records.Close(ctx)
+		if err != nil {
+			log.Println(err)
+		}
+	}(records, ctx)
+
+	bookmarks = make([]models.Bookmark, 0)
+
+	err = records.All(ctx, &bookmarks)
+	if err != nil {
+		return nil, status.BookmarkFetchingFailed, err
+	}
+
+	return bookmarks, status.OperationSuccess, nil
 }
 
-func (d BookmarkRepository) AddPost(ctx context.Context, userID string, postIDs []string) (opStatus status.OperationStatus, err error) {
+func (d BookmarkRepository) FetchTrashedById(ctx context.Context, userID string, bookmarkID string) (bookmark models.Bookmark, opStatus status.OperationStatus, err error) {
 
-	//set filters
-	//1. Query by user id
-	filter := bson.D{{"user_id", userID}}
+	//Convert model id from string to mongodb objectID
+	modelID, err := primitive.ObjectIDFromHex(bookmarkID)
+	if err != nil {
+		return bookmark, status.BookmarkNotExist, err
+	}
 
-	//Convert postIDs string to ObjectID
-	postObjIDs := make([]bson.D, 0)
-	for _, c := range postIDs {
-		postObjIDs = append(postObjIDs, bson.D{{"id", d.GenerateObjectIDFromString(c)}})
+	//Set filters
+	filter := bson.D{{Key: "_id", Value: modelID}, {Key: "user_id", Value: userID}, {Key: "deleted_at", Value: bson.M{"$ne": nil}}}
+	err = d.Collection.FindOne(ctx, filter).Decode(&bookmark)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return bookmark, status.BookmarkNotExist, err
+		}
+		return bookmark, status.BookmarkFetchingFailed, err
 	}
 
-	//Set statements
-	//1. Append post id to posts array field, if already exists in array, id will not be added
-	statement := bson.M{"$addToSet": bson.M{"posts": bson.M{"$each": postObjIDs}}}
+	return bookmark, status.OperationSuccess, nil
+}
+
+func (d BookmarkRepository) Restore(ctx context.Context, bookmarkID string) (opStatus status.OperationStatus, err error) {
+
+	objectID, err := primitive.ObjectIDFromHex(bookmarkID)
+	if err != nil {
+		return status.BookmarkRestoreFailed, err
+	}
+
+	filter := bson.D{{Key: "_id", Value: objectID}, {Key: "deleted_at", Value: bson.M{"$ne": nil}}}
+	statement := bson.M{"$set": bson.M{"deleted_at": nil, "updated_at": time.Now()}}
 
-	//execute
 	result, err := d.Collection.UpdateOne(ctx, filter, statement)
 	if err != nil {
-		log.Println("BOOKMARK REPOSITORY ADD POST: ", err.Error())
-		return status.BookmarkPostFailed, err
+		//the user already owns another active bookmark
+		if mongo.IsDuplicateKeyError(err) {
+			return status.BookmarkDuplicationOccurs, err
+		}
+		return status.BookmarkRestoreFailed, err
 	}
 
 	if result.MatchedCount == 0 {
-		log.Println("BOOKMARK REPOSITORY ADD POST: document not matched")
 		return status.BookmarkNotExist, errors.New("document not matched")
 	}
 
-	//if result.ModifiedCount == 0 {
-	//	log.Println("BOOKMARK REPOSITORY ADD POST: document not modified")
-	//	return status.BookmarkMatchedNotModified, errors.New("document not modified")
-	//}
+	return status.BookmarkRestoreSuccess, nil
+}
+
+func (d BookmarkRepository) Purge(ctx context.Context, deletedBefore time.Time) (purged int64, opStatus status.OperationStatus, err error) {
+
+	filter := bson.D{{Key: "deleted_at", Value: bson.M{"$ne": nil, "$lt": deletedBefore}}}
+
+	result, err := d.Collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, status.BookmarkPurgeFailed, err
+	}
+
+	return result.DeletedCount, status.BookmarkPurgeSuccess, nil
+}
+
+func (d BookmarkRepository) AddPost(ctx context.Context, userID string, collectionID string, postIDs []string) (opStatus status.OperationStatus, err error) {
+
+	collectionObjID, err := primitive.ObjectIDFromHex(collectionID)
+	if err != nil {
+		return status.BookmarkCollectionNotExist, err
+	}
+
+	//set filters
+	//1. Query by user id
+	//2. The target collection must exist
+	filter := bson.D{
+		{Key: "user_id", Value: userID},
+		{Key: "deleted_at", Value: nil},
+		{Key: "collections.id", Value: collectionObjID},
+	}
+
+	timeNow := time.Now()
+	err = d.inTransaction(ctx, func(sc mongo.SessionContext) error {
+
+		//the posts already saved tell which ones are new, a concurrent change to the bookmark
+		//conflicts with the transaction and has it run again
+		var bookmark models.Bookmark
+		err := d.Collection.FindOne(sc, filter, options.FindOne().SetProjection(bson.M{"posts.id": 1, "posts.collection_id": 1})).Decode(&bookmark)
+		if err != nil {
+			return err
+		}
+
+		//Set statements
+		//1. Push the posts that aren't saved in the collection yet, each item carries its own added_at
+		added := make([]primitive.ObjectID, 0)
+		items := make([]models.Post, 0)
+		for _, c := range postIDs {
+			postObjID := d.GenerateObjectIDFromString(c)
+			if bookmark.HasPost(postObjID.Hex(), collectionID) {
+				continue
+			}
+			bookmark.Posts = append(bookmark.Posts, models.Post{ID: postObjID, CollectionID: collectionObjID})
+			added = append(added, postObjID)
+			items = append(items, models.Post{ID: postObjID, CollectionID: collectionObjID, AddedAt: &timeNow, Tags: []string{}})
+		}
+		if len(added) == 0 {
+			return nil
+		}
+
+		statement := bson.M{"$push": bson.M{"posts": bson.M{"$each": items}}}
+		if _, err := d.Collection.UpdateOne(sc, bson.M{"_id": bookmark.ID}, statement); err != nil {
+			return err
+		}
+
+		return d.insertOutbox(sc, events.NewBookmarkPostAdded(userID, collectionObjID, added, timeNow))
+	})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			log.Println("BOOKMARK REPOSITORY ADD POST: document not matched")
+			return status.BookmarkNotExist, errors.New("document not matched")
+		}
+		log.Println("BOOKMARK REPOSITORY ADD POST: ", err.Error())
+		return status.BookmarkPostFailed, err
+	}
 
 	return status.BookmarkPostSuccess, nil
 }
 
-func (d BookmarkRepository) RevokePost(ctx context.Context, userID string, postIDs []string) (opStatus status.OperationStatus, err error) {
+func (d BookmarkRepository) UpdatePost(ctx context.Context, userID string, collectionID string, postID string, note *string, tags []string) (opStatus status.OperationStatus, err error) {
+
+	postObjID, err := primitive.ObjectIDFromHex(postID)
+	if err != nil {
+		return status.BookmarkPostNotExist, err
+	}
+
+	//match the saved item, limited to a single collection when given
+	item := bson.M{"id": postObjID}
+	arrayFilter := bson.M{"item.id": postObjID}
+	if collectionID != "" {
+		collectionObjID, err := primitive.ObjectIDFromHex(collectionID)
+		if err != nil {
+			return status.BookmarkCollectionNotExist, err
+		}
+		item["collection_id"] = collectionObjID
+		arrayFilter["item.collection_id"] = collectionObjID
+	}
+
+	filter := bson.D{
+		{Key: "user_id", Value: userID},
+		{Key: "deleted_at", Value: nil},
+		{Key: "posts", Value: bson.M{"$elemMatch": item}},
+	}
+
+	fields := bson.M{"updated_at": time.Now()}
+	if note != nil {
+		fields["posts.$[item].note"] = *note
+	}
+	if tags != nil {
+		fields["posts.$[item].tags"] = tags
+	}
+
+	opts := options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{arrayFilter}})
+
+	result, err := d.Collection.UpdateOne(ctx, filter, bson.M{"$set": fields}, opts)
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY UPDATE POST: ", err.Error())
+		return status.BookmarkPostUpdateFailed, err
+	}
+
+	if result.MatchedCount == 0 {
+		return status.BookmarkPostNotExist, errors.New("post not matched")
+	}
+
+	return status.BookmarkPostUpdateSuccess, nil
+}
+
+func (d BookmarkRepository) RevokePost(ctx context.Context, userID string, collectionID string, postIDs []string) (opStatus status.OperationStatus, err error) {
 
 	//set filters
 	//1. Query by user id
-	filter := bson.D{{"user_id", userID}}
+	filter := bson.D{{Key: "user_id", Value: userID}, {Key: "deleted_at", Value: nil}}
 
-	//Convert postIDs string to ObjectID
-	cID := make([]primitive.ObjectID, 0)
-	for _, s := range postIDs {
-		cID = append(cID, d.GenerateObjectIDFromString(s))
+	var collectionObjID primitive.ObjectID
+	if collectionID != "" {
+		collectionObjID, err = primitive.ObjectIDFromHex(collectionID)
+		if err != nil {
+			return status.BookmarkCollectionNotExist, err
+		}
 	}
 
-	//Set statements
-	//1. remove post id matched in postObjIDs
-	statement := bson.M{"$pull": bson.M{"posts": bson.M{"id": bson.M{"$in": cID}}}}
+	revoked := objectIDSet(postIDs)
+	err = d.inTransaction(ctx, func(sc mongo.SessionContext) error {
 
-	//execute
-	result, err := d.Collection.UpdateOne(ctx, filter, statement)
+		//the saved posts tell which ones actually go
+		var bookmark models.Bookmark
+		err := d.Collection.FindOne(sc, filter, options.FindOne().SetProjection(bson.M{"posts.id": 1, "posts.collection_id": 1})).Decode(&bookmark)
+		if err != nil {
+			return err
+		}
+		removed := removePosts(&bookmark, collectionObjID, revoked)
+		if len(removed) == 0 {
+			return nil
+		}
+
+		//Set statements
+		//1. remove the posts, limited to a single collection when given
+		pulled := bson.M{"id": bson.M{"$in": removed}}
+		if collectionID != "" {
+			pulled["collection_id"] = collectionObjID
+		}
+		statement := bson.M{"$pull": bson.M{"posts": pulled}}
+		if _, err := d.Collection.UpdateOne(sc, bson.M{"_id": bookmark.ID}, statement); err != nil {
+			return err
+		}
+
+		return d.insertOutbox(sc, events.NewBookmarkPostRevoked(userID, collectionObjID, removed, time.Now()))
+	})
 	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			log.Println("BOOKMARK REPOSITORY DELETE POST: document not matched")
+			return status.BookmarkNotExist, errors.New("document not matched")
+		}
 		log.Println("BOOKMARK REPOSITORY DELETE POST: ", err.Error())
 		return status.BookmarkDeletePostFailed, err
 	}
 
+	return status.BookmarkDeletePostSuccess, nil
+}
+
+func (d BookmarkRepository) CreateCollection(ctx context.Context, userID string, collection *models.Collection) (opStatus status.OperationStatus, err error) {
+
+	filter := bson.D{{Key: "user_id", Value: userID}, {Key: "deleted_at", Value: nil}}
+	statement := bson.M{"$push": bson.M{"collections": collection}}
+
+	result, err := d.Collection.UpdateOne(ctx, filter, statement)
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY CREATE COLLECTION: ", err.Error())
+		return status.BookmarkCollectionCreateFailed, err
+	}
+
 	if result.MatchedCount == 0 {
-		log.Println("BOOKMARK REPOSITORY DELETE POST: document not matched")
 		return status.BookmarkNotExist, errors.New("document not matched")
+	}
+
+	return status.BookmarkCollectionCreateSuccess, nil
+}
+
+func (d BookmarkRepository) RenameCollection(ctx context.Context, userID string, collectionID string, name string) (opStatus status.OperationStatus, err error) {
+
+	collectionObjID, err := primitive.ObjectIDFromHex(collectionID)
+	if err != nil {
+		return status.BookmarkCollectionNotExist, err
+	}
 
+	filter := bson.D{
+		{Key: "user_id", Value: userID},
+		{Key: "deleted_at", Value: nil},
+		{Key: "collections.id", Value: collectionObjID},
 	}
+	//1. '$' points to the collection matched by the filter
+	statement := bson.M{"$set": bson.M{
+		"collections.$.name":       name,
+		"collections.$.updated_at": time.Now(),
+	}}
 
-	//if result.ModifiedCount == 0 {
-	//	log.Println("BOOKMARK REPOSITORY DELETE POST: document not modified")
-	//	//return false, errors.New("document not modified")
-	//}
+	result, err := d.Collection.UpdateOne(ctx, filter, statement)
+	if err != nil {
+		log.Println("BOOKMARK REPOSITORY RENAME COLLECTION: ", err.Error())
+		return status.BookmarkCollectionUpdateFailed, err
+	}
 
-	return status.BookmarkDeletePostSuccess, nil
+	if result.MatchedCount == 0 {
+		return status.BookmarkCollectionNotExist, errors.New("collection not matched")
+	}
+
+	return status.BookmarkCollectionUpdateSuccess, nil
+}
+
+func (d BookmarkRepository) DeleteCollection(ctx context.Context, userID string, collectionID string) (opStatus status.OperationStatus, err error) {
+
+	collectionObjID, err := primitive.ObjectIDFromHex(collectionID)
+	if err != nil {
+		return status.BookmarkCollectionNotExist, err
+	}
+
+	//the default collection can never be removed
+	filter := bson.D{
+		{Key: "user_id", Value: userID},
+		{Key: "deleted_at", Value: nil},
+		{Key: "collections", Value: bson.M{"$elemMatch": bson.M{"id": collectionObjID, "is_default": false}}},
+	}
+	//1. remove the collection itself
+	//2. remove every post saved in the collection
+	statement := bson.M{"$pull": bson.M{
+		"collections": bson.M{"id": collectionObjID},
+		"posts":       bson.M{"collection_id": collectionObjID},
+	}}
+
+	err = d.inTransaction(ctx, func(sc mongo.SessionContext) error {
+
+		//the bookmark as it was before the change tells which posts went with the collection
+		var bookmark models.Bookmark
+		opts := options.FindOneAndUpdate().SetProjection(bson.M{"posts.id": 1, "posts.collection_id": 1})
+		if err := d.Collection.FindOneAndUpdate(sc, filter, statement, opts).Decode(&bookmark); err != nil {
+			return err
+		}
+
+		removed := postsIn(&bookmark, collectionObjID)
+		if len(removed) == 0 {
+			return nil
+		}
+		return d.insertOutbox(sc, events.NewBookmarkPostRevoked(userID, collectionObjID, removed, time.Now()))
+	})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return status.BookmarkCollectionNotExist, errors.New("collection not matched")
+		}
+		log.Println("BOOKMARK REPOSITORY DELETE COLLECTION: ", err.Error())
+		return status.BookmarkCollectionDeleteFailed, err
+	}
+
+	return status.BookmarkCollectionDeleteSuccess, nil
 }
 
 func (d BookmarkRepository) GenerateModelID() primitive.ObjectID {
@@ -290,6 +946,32 @@ func (d BookmarkRepository) GenerateObjectIDFromString(id string) primitive.Obje
 	return hex
 }
 
+// inTransaction run 'fn' in a transaction, so a change and the outbox event reporting it are written together.
+// transactions need Mongo to run as a replica set
+func (d BookmarkRepository) inTransaction(ctx context.Context, fn func(sc mongo.SessionContext) error) error {
+
+	session, err := d.Collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
+// insertOutbox write the event in the transaction of 'sc'
+func (d BookmarkRepository) insertOutbox(sc mongo.SessionContext, event models.OutboxEvent) error {
+	_, err := d.outbox().InsertOne(sc, event)
+	return err
+}
+
+func (d BookmarkRepository) outbox() *mongo.Collection {
+	return d.Collection.Database().Collection(models.OutboxCollectionName)
+}
+
 func NewBookmarkDBRepository(conn *mongo.Database, coll *mongo.Collection) contracts.BookmarksRepository {
 
 	return &BookmarkRepository{
@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golek_bookmark_service/pkg/config"
+	"golek_bookmark_service/pkg/contracts"
+	"golek_bookmark_service/pkg/database"
+	"golek_bookmark_service/pkg/database/migrations"
+	"golek_bookmark_service/pkg/models"
+	"golek_bookmark_service/pkg/repositories/repositorytest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBookmarkMemoryRepository(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) contracts.BookmarksRepository {
+		return NewBookmarkMemoryRepository()
+	})
+}
+
+func TestBookmarkBoltRepository(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) contracts.BookmarksRepository {
+		db := database.NewBolt(&config.Config{Database: map[string]string{
+			"PATH": filepath.Join(t.TempDir(), "bookmarks.db"),
+		}})
+		db.Prepare()
+		t.Cleanup(func() { _ = db.GetConnection().Close() })
+
+		return NewBookmarkBoltRepository(db.GetConnection())
+	})
+}
+
+// TestBookmarkDBRepository runs the suite against MongoDB, it is skipped unless TEST_DB_HOST is set.
+// the server must run as a replica set, writes go through transactions
+func TestBookmarkDBRepository(t *testing.T) {
+
+	if os.Getenv("TEST_DB_HOST") == "" {
+		t.Skip("TEST_DB_HOST is not set")
+	}
+
+	cfg := &config.Config{Database: map[string]string{
+		"USERNAME": os.Getenv("TEST_DB_USERNAME"),
+		"PASSWORD": os.Getenv("TEST_DB_PASSWORD"),
+		"HOST":     os.Getenv("TEST_DB_HOST"),
+		"PORT":     os.Getenv("TEST_DB_PORT"),
+		"NAME":     os.Getenv("TEST_DB_NAME"),
+	}}
+
+	repositorytest.Run(t, func(t *testing.T) contracts.BookmarksRepository {
+
+		//every subtest works on a fresh collection carrying the production indexes
+		cfg.Database["COLLECTION_BOOKMARKS"] = "bookmarks_" + primitive.NewObjectID().Hex()
+		db := database.New(cfg)
+		db.Prepare()
+
+		if _, err := migrations.New(db).Up(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		collection := db.GetCollection(cfg.Database["COLLECTION_BOOKMARKS"])
+		t.Cleanup(func() {
+			_ = collection.Drop(context.Background())
+			_ = db.GetConnection().Collection(models.OutboxCollectionName).Drop(context.Background())
+			_ = db.GetConnection().Collection("schema_migrations").Drop(context.Background())
+		})
+
+		return NewBookmarkDBRepository(db.GetConnection(), collection)
+	})
+}
+
+// TestBookmarkPostgresRepository runs the suite against PostgreSQL, it is skipped unless TEST_POSTGRES_HOST is set
+func TestBookmarkPostgresRepository(t *testing.T) {
+
+	if os.Getenv("TEST_POSTGRES_HOST") == "" {
+		t.Skip("TEST_POSTGRES_HOST is not set")
+	}
+
+	db := database.NewPostgres(&config.Config{Database: map[string]string{
+		"USERNAME": os.Getenv("TEST_POSTGRES_USERNAME"),
+		"PASSWORD": os.Getenv("TEST_POSTGRES_PASSWORD"),
+		"HOST":     os.Getenv("TEST_POSTGRES_HOST"),
+		"PORT":     os.Getenv("TEST_POSTGRES_PORT"),
+		"NAME":     os.Getenv("TEST_POSTGRES_NAME"),
+	}})
+	db.Prepare()
+
+	if _, err := migrations.NewPostgres(db).Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	repositorytest.Run(t, func(t *testing.T) contracts.BookmarksRepository {
+
+		//collections and items are truncated through the foreign keys, events left by the previous subtest go too
+		if _, err := db.GetConnection().Exec(`TRUNCATE bookmarks, bookmark_outbox CASCADE`); err != nil {
+			t.Fatal(err)
+		}
+
+		return NewBookmarkPostgresRepository(db.GetConnection())
+	})
+}
@@ -0,0 +1,312 @@
+package repositories
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golek_bookmark_service/pkg/events"
+	"golek_bookmark_service/pkg/models"
+	"sort"
+	"time"
+)
+
+var (
+	errNoDocuments  = errors.New("document not found")
+	errDuplicateKey = errors.New("duplicate key: user already owns an active bookmark")
+)
+
+// copyBookmark deep copy a bookmark so stored state never aliases caller owned slices
+func copyBookmark(b models.Bookmark) models.Bookmark {
+
+	if b.Collections != nil {
+		collections := make([]models.Collection, len(b.Collections))
+		copy(collections, b.Collections)
+		b.Collections = collections
+	}
+
+	if b.Posts != nil {
+		posts := make([]models.Post, len(b.Posts))
+		for i, p := range b.Posts {
+			posts[i] = copyPost(p)
+		}
+		b.Posts = posts
+	}
+
+	return b
+}
+
+func copyPost(p models.Post) models.Post {
+	if p.Tags != nil {
+		p.Tags = append([]string{}, p.Tags...)
+	}
+	return p
+}
+
+// excludeFields blank the fields named after their bson keys, the same way a Mongo exclusion projection does
+func excludeFields(b models.Bookmark, exclude []string) models.Bookmark {
+	for _, field := range exclude {
+		switch field {
+		case "_id":
+			b.ID = primitive.NilObjectID
+		case "user_id":
+			b.UserID = ""
+		case "collections":
+			b.Collections = nil
+		case "posts":
+			b.Posts = nil
+		case "updated_at":
+			b.UpdatedAt = nil
+		case "created_at":
+			b.CreatedAt = nil
+		case "deleted_at":
+			b.DeletedAt = nil
+		}
+	}
+	return b
+}
+
+// paginate apply skip and limit, a zero limit means no limit like Mongo's
+func paginate[T any](items []T, limit int64, skip int64) []T {
+	if skip > int64(len(items)) {
+		return items[:0]
+	}
+	if skip > 0 {
+		items = items[skip:]
+	}
+	if limit > 0 && limit < int64(len(items)) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// sortBookmarks order a listing the way filter asks, see models.BookmarkFilter.Less
+func sortBookmarks(bookmarks []models.Bookmark, filter models.BookmarkFilter) {
+	sort.SliceStable(bookmarks, func(i, j int) bool {
+		return filter.Less(bookmarks[i], bookmarks[j])
+	})
+}
+
+func hasCollection(b *models.Bookmark, collectionID primitive.ObjectID) bool {
+	for _, c := range b.Collections {
+		if c.ID == collectionID {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPost(b *models.Bookmark, postID primitive.ObjectID, collectionID primitive.ObjectID) bool {
+	for _, p := range b.Posts {
+		if p.ID == postID && p.CollectionID == collectionID {
+			return true
+		}
+	}
+	return false
+}
+
+// the helpers below apply a change to a bookmark held in memory, storages that keep whole documents
+// (memory, bolt) share them so they can't drift apart
+
+// appendPosts save every post into the collection unless it is already there, it returns the posts it saved
+func appendPosts(b *models.Bookmark, collectionID primitive.ObjectID, postIDs []primitive.ObjectID, addedAt time.Time) (added []primitive.ObjectID) {
+	added = make([]primitive.ObjectID, 0)
+	for _, postID := range postIDs {
+		if hasPost(b, postID, collectionID) {
+			continue
+		}
+		added = append(added, postID)
+		postAddedAt := addedAt
+		b.Posts = append(b.Posts, models.Post{
+			ID:           postID,
+			CollectionID: collectionID,
+			AddedAt:      &postAddedAt,
+			Tags:         []string{},
+		})
+	}
+	return added
+}
+
+// createdEvents build the post_added events of a bookmark stored along with its posts, one per collection
+// in the order the collections first show up. a bookmark created in the trash reports nothing
+func createdEvents(b models.Bookmark, occurredAt time.Time) []models.OutboxEvent {
+
+	outbox := make([]models.OutboxEvent, 0)
+	if b.DeletedAt != nil {
+		return outbox
+	}
+
+	collections := make([]primitive.ObjectID, 0)
+	added := make(map[primitive.ObjectID][]primitive.ObjectID)
+	for _, p := range b.Posts {
+		if _, ok := added[p.CollectionID]; !ok {
+			collections = append(collections, p.CollectionID)
+		}
+		if !hasID(added[p.CollectionID], p.ID) {
+			added[p.CollectionID] = append(added[p.CollectionID], p.ID)
+		}
+	}
+
+	for _, collectionID := range collections {
+		outbox = append(outbox, events.NewBookmarkPostAdded(b.UserID, collectionID, added[collectionID], occurredAt))
+	}
+	return outbox
+}
+
+func hasID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// annotatePosts set the note and tags of a saved post, a nil collection id matches every collection.
+// it reports whether any post matched
+func annotatePosts(b *models.Bookmark, collectionID primitive.ObjectID, postID primitive.ObjectID, note *string, tags []string) bool {
+
+	matched := false
+	for i, p := range b.Posts {
+		if p.ID != postID || (!collectionID.IsZero() && p.CollectionID != collectionID) {
+			continue
+		}
+		matched = true
+		if note != nil {
+			b.Posts[i].Note = *note
+		}
+		if tags != nil {
+			b.Posts[i].Tags = append([]string{}, tags...)
+		}
+	}
+
+	return matched
+}
+
+// removePosts drop the posts from the collection, a nil collection id removes them from every collection.
+// it returns the posts it removed, once each
+func removePosts(b *models.Bookmark, collectionID primitive.ObjectID, postIDs map[primitive.ObjectID]bool) (removed []primitive.ObjectID) {
+	removed = make([]primitive.ObjectID, 0)
+	seen := make(map[primitive.ObjectID]bool)
+	kept := make([]models.Post, 0, len(b.Posts))
+	for _, p := range b.Posts {
+		if postIDs[p.ID] && (collectionID.IsZero() || p.CollectionID == collectionID) {
+			if !seen[p.ID] {
+				seen[p.ID] = true
+				removed = append(removed, p.ID)
+			}
+			continue
+		}
+		kept = append(kept, p)
+	}
+	b.Posts = kept
+	return removed
+}
+
+// refreshSnapshots copy the name and image of the snapshots, keyed by post id, onto every saved copy of
+// their post. it reports whether any post matched
+func refreshSnapshots(b *models.Bookmark, snapshots map[primitive.ObjectID]models.Post, snapshotAt time.Time) bool {
+	matched := false
+	for i, p := range b.Posts {
+		snapshot, ok := snapshots[p.ID]
+		if !ok {
+			continue
+		}
+		matched = true
+		refreshedAt := snapshotAt
+		b.Posts[i].Name = snapshot.Name
+		b.Posts[i].ImageUrl = snapshot.ImageUrl
+		b.Posts[i].SnapshotAt = &refreshedAt
+	}
+	return matched
+}
+
+// searchPosts keep the saved posts holding every term, see models.Post.MatchTerms
+func searchPosts(b *models.Bookmark, terms []string) []models.Post {
+	posts := make([]models.Post, 0)
+	for _, p := range b.Posts {
+		if p.MatchTerms(terms) {
+			posts = append(posts, copyPost(p))
+		}
+	}
+	return posts
+}
+
+func snapshotsByID(snapshots []models.Post) map[primitive.ObjectID]models.Post {
+	byID := make(map[primitive.ObjectID]models.Post)
+	for _, s := range snapshots {
+		byID[s.ID] = s
+	}
+	return byID
+}
+
+func renameCollection(b *models.Bookmark, collectionID primitive.ObjectID, name string, updatedAt time.Time) bool {
+	for i, c := range b.Collections {
+		if c.ID == collectionID {
+			b.Collections[i].Name = name
+			b.Collections[i].UpdatedAt = &updatedAt
+			return true
+		}
+	}
+	return false
+}
+
+// removeCollection drop a collection along with its posts, the default collection is never removed.
+// it returns the posts it removed, once each, and whether the collection was found
+func removeCollection(b *models.Bookmark, collectionID primitive.ObjectID) (removed []primitive.ObjectID, ok bool) {
+
+	collections := make([]models.Collection, 0, len(b.Collections))
+	found := false
+	for _, c := range b.Collections {
+		if c.ID == collectionID && !c.IsDefault {
+			found = true
+			continue
+		}
+		collections = append(collections, c)
+	}
+	if !found {
+		return nil, false
+	}
+
+	removed = postsIn(b, collectionID)
+	posts := make([]models.Post, 0, len(b.Posts))
+	for _, p := range b.Posts {
+		if p.CollectionID != collectionID {
+			posts = append(posts, p)
+		}
+	}
+
+	b.Collections = collections
+	b.Posts = posts
+
+	return removed, true
+}
+
+// postsIn list the posts saved in the collection, once each in the order they were saved
+func postsIn(b *models.Bookmark, collectionID primitive.ObjectID) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0)
+	for _, p := range b.Posts {
+		if p.CollectionID == collectionID && !hasID(ids, p.ID) {
+			ids = append(ids, p.ID)
+		}
+	}
+	return ids
+}
+
+func objectIDs(ids []string) []primitive.ObjectID {
+	objectIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		objectID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			objectID = primitive.NilObjectID
+		}
+		objectIDs = append(objectIDs, objectID)
+	}
+	return objectIDs
+}
+
+func objectIDSet(ids []string) map[primitive.ObjectID]bool {
+	set := make(map[primitive.ObjectID]bool)
+	for _, id := range objectIDs(ids) {
+		set[id] = true
+	}
+	return set
+}
@@ -0,0 +1,734 @@
+// Package repositorytest holds the behaviour every contracts.BookmarksRepository implementation must share,
+// storage backends run it from their own tests
+package repositorytest
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-playground/assert/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golek_bookmark_service/pkg/contracts"
+	"golek_bookmark_service/pkg/contracts/status"
+	"golek_bookmark_service/pkg/events"
+	"golek_bookmark_service/pkg/models"
+	"sort"
+	"testing"
+	"time"
+)
+
+// Factory returns an empty repository, every subtest gets its own
+type Factory func(t *testing.T) contracts.BookmarksRepository
+
+// Run execute the conformance suite against the repositories built by newRepository
+func Run(t *testing.T, newRepository Factory) {
+
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo contracts.BookmarksRepository)
+	}{
+		{"CreateAndFetch", testCreateAndFetch},
+		{"FilterAndSort", testFilterAndSort},
+		{"UniqueActiveUser", testUniqueActiveUser},
+		{"AddPostDeduplicates", testAddPostDeduplicates},
+		{"AddPostRequiresCollection", testAddPostRequiresCollection},
+		{"RevokePost", testRevokePost},
+		{"UpdatePost", testUpdatePost},
+		{"Collections", testCollections},
+		{"SoftDelete", testSoftDelete},
+		{"RestoreAndPurge", testRestoreAndPurge},
+		{"SavedPostsLookup", testSavedPostsLookup},
+		{"ReverseIndex", testReverseIndex},
+		{"SnapshotsAndSearch", testSnapshotsAndSearch},
+		{"PrunePosts", testPrunePosts},
+		{"Outbox", testOutbox},
+		{"FirstAddPostEmitsPostAdded", testFirstAddPostEmitsPostAdded},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, newRepository(t))
+		})
+	}
+}
+
+// newBookmark build a bookmark the way the usecase does, with a default collection holding 'postIDs'
+func newBookmark(repo contracts.BookmarksRepository, userID string, postIDs ...primitive.ObjectID) models.Bookmark {
+
+	timeNow := time.Now()
+	defaultCollection := models.Collection{
+		ID:        repo.GenerateModelID(),
+		Name:      models.DefaultCollectionName,
+		IsDefault: true,
+		UpdatedAt: &timeNow,
+		CreatedAt: &timeNow,
+	}
+
+	posts := make([]models.Post, 0)
+	for _, id := range postIDs {
+		posts = append(posts, models.Post{ID: id, CollectionID: defaultCollection.ID, AddedAt: &timeNow, Tags: []string{}})
+	}
+
+	return models.Bookmark{
+		ID:          repo.GenerateModelID(),
+		UserID:      userID,
+		Collections: []models.Collection{defaultCollection},
+		Posts:       posts,
+		UpdatedAt:   &timeNow,
+		CreatedAt:   &timeNow,
+	}
+}
+
+func mustCreate(t *testing.T, repo contracts.BookmarksRepository, bookmark models.Bookmark) models.Bookmark {
+	t.Helper()
+	id, opStatus, err := repo.Create(context.Background(), &bookmark)
+	if err != nil {
+		t.Fatalf("create bookmark: %v (%d)", err, opStatus)
+	}
+	bookmark.ID = id
+	return bookmark
+}
+
+// revokedEvents decode the post_revoked events waiting in the outbox
+func revokedEvents(t *testing.T, repo contracts.BookmarksRepository) []events.BookmarkPostRevoked {
+	t.Helper()
+	outbox, _, err := repo.FetchOutbox(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked := make([]events.BookmarkPostRevoked, 0)
+	for _, event := range outbox {
+		if event.Subject != events.BookmarkPostRevokedSubject {
+			continue
+		}
+		var e events.BookmarkPostRevoked
+		if err := json.Unmarshal(event.Payload, &e); err != nil {
+			t.Fatal(err)
+		}
+		revoked = append(revoked, e)
+	}
+	return revoked
+}
+
+// sortedIDs order ids some storages report in no particular order
+func sortedIDs(ids []string) []string {
+	sorted := append([]string{}, ids...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func postIDsOf(posts []models.Post) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0)
+	for _, p := range posts {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func testCreateAndFetch(t *testing.T, repo contracts.BookmarksRepository) {
+
+	ctx := context.Background()
+	post := primitive.NewObjectID()
+	created := mustCreate(t, repo, newBookmark(repo, "user-1", post))
+
+	byID, opStatus, err := repo.FetchById(ctx, created.ID.Hex(), []string{})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.OperationSuccess)
+	assert.Equal(t, byID.UserID, "user-1")
+	assert.Equal(t, postIDsOf(byID.Posts), []primitive.ObjectID{post})
+	assert.Equal(t, len(byID.Collections), 1)
+	assert.Equal(t, byID.Collections[0].IsDefault, true)
+
+	byUser, _, err := repo.FetchByUserId(ctx, "user-1", []string{})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, byUser.ID, created.ID)
+
+	_, opStatus, err = repo.FetchByUserId(ctx, "nobody", []string{})
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkNotExist)
+
+	_, opStatus, err = repo.FetchById(ctx, primitive.NewObjectID().Hex(), []string{})
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkNotExist)
+	_, opStatus, err = repo.FetchById(ctx, "not-an-id", []string{})
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkNotExist)
+
+	mustCreate(t, repo, newBookmark(repo, "user-2"))
+	mustCreate(t, repo, newBookmark(repo, "user-3"))
+
+	all, total, _, err := repo.Fetch(ctx, models.BookmarkFilter{}, []string{"posts"}, 0, nil)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(all), 3)
+	assert.Equal(t, total, int64(3))
+	assert.Equal(t, len(all[0].Posts), 0)
+
+	after := models.BookmarkFilter{}.CursorOf(all[0])
+	page, total, _, err := repo.Fetch(ctx, models.BookmarkFilter{}, []string{}, 1, &after)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(page), 1)
+	assert.Equal(t, page[0].ID, all[1].ID)
+	assert.Equal(t, total, int64(3))
+}
+
+func testFilterAndSort(t *testing.T, repo contracts.BookmarksRepository) {
+
+	ctx := context.Background()
+	post := primitive.NewObjectID()
+	day := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	//created in an order that differs from every sort below, user-d was never updated
+	for i, userID := range []string{"user-b", "user-c", "user-a", "user-d"} {
+		created := day.AddDate(0, 0, []int{1, 0, 2, 1}[i])
+		updated := day.AddDate(0, 0, []int{5, 6, 4, 0}[i])
+		bookmark := newBookmark(repo, userID)
+		if userID == "user-c" || userID == "user-a" {
+			bookmark = newBookmark(repo, userID, post)
+		}
+		bookmark.CreatedAt, bookmark.UpdatedAt = &created, &updated
+		if userID == "user-d" {
+			bookmark.UpdatedAt = nil
+		}
+		mustCreate(t, repo, bookmark)
+	}
+
+	//userIDs walk the whole listing 'perPage' bookmarks at a time, following the cursor of each last bookmark
+	userIDs := func(filter models.BookmarkFilter, perPage int64) ([]string, int64) {
+		t.Helper()
+		ids := make([]string, 0)
+		var after *models.Cursor
+		var total int64
+		for {
+			bookmarks, pageTotal, opStatus, err := repo.Fetch(ctx, filter, []string{}, perPage, after)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, opStatus, status.OperationSuccess)
+			for _, b := range bookmarks {
+				ids = append(ids, b.UserID)
+			}
+			total = pageTotal
+			if perPage == 0 || int64(len(bookmarks)) < perPage || len(ids) > 10 {
+				return ids, total
+			}
+			cursor := filter.CursorOf(bookmarks[len(bookmarks)-1])
+			after = &cursor
+		}
+	}
+
+	for _, perPage := range []int64{0, 1, 3} {
+		ids, total := userIDs(models.BookmarkFilter{}, perPage)
+		assert.Equal(t, ids, []string{"user-b", "user-c", "user-a", "user-d"})
+		assert.Equal(t, total, int64(4))
+
+		ids, _ = userIDs(models.BookmarkFilter{SortBy: models.SortByCreatedAt}, perPage)
+		assert.Equal(t, ids, []string{"user-c", "user-b", "user-d", "user-a"})
+		ids, _ = userIDs(models.BookmarkFilter{SortBy: models.SortByCreatedAt, SortDescending: true}, perPage)
+		assert.Equal(t, ids, []string{"user-a", "user-b", "user-d", "user-c"})
+
+		//a missing date sorts first ascending and last descending
+		ids, _ = userIDs(models.BookmarkFilter{SortBy: models.SortByUpdatedAt}, perPage)
+		assert.Equal(t, ids, []string{"user-d", "user-a", "user-b", "user-c"})
+		ids, _ = userIDs(models.BookmarkFilter{SortBy: models.SortByUpdatedAt, SortDescending: true}, perPage)
+		assert.Equal(t, ids, []string{"user-c", "user-b", "user-a", "user-d"})
+
+		ids, _ = userIDs(models.BookmarkFilter{SortBy: models.SortByUserID, SortDescending: true}, perPage)
+		assert.Equal(t, ids, []string{"user-d", "user-c", "user-b", "user-a"})
+
+		ids, total = userIDs(models.BookmarkFilter{PostID: post.Hex(), SortBy: models.SortByUserID}, perPage)
+		assert.Equal(t, ids, []string{"user-a", "user-c"})
+		assert.Equal(t, total, int64(2))
+	}
+
+	ids, total := userIDs(models.BookmarkFilter{UserID: "user-c"}, 0)
+	assert.Equal(t, ids, []string{"user-c"})
+	assert.Equal(t, total, int64(1))
+
+	//bounds are inclusive
+	from, to := day, day.AddDate(0, 0, 1)
+	ids, _ = userIDs(models.BookmarkFilter{CreatedFrom: &from, CreatedTo: &to, SortBy: models.SortByCreatedAt}, 0)
+	assert.Equal(t, ids, []string{"user-c", "user-b", "user-d"})
+
+	from = day.AddDate(0, 0, 5)
+	ids, _ = userIDs(models.BookmarkFilter{UpdatedFrom: &from}, 0)
+	assert.Equal(t, ids, []string{"user-b", "user-c"})
+
+	ids, total = userIDs(models.BookmarkFilter{UserID: "user-a", UpdatedFrom: &from}, 0)
+	assert.Equal(t, len(ids), 0)
+	assert.Equal(t, total, int64(0))
+}
+
+func testUniqueActiveUser(t *testing.T, repo contracts.BookmarksRepository) {
+
+	mustCreate(t, repo, newBookmark(repo, "user-1"))
+
+	duplicate := newBookmark(repo, "user-1")
+	_, opStatus, err := repo.Create(context.Background(), &duplicate)
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkDuplicationOccurs)
+}
+
+func testAddPostDeduplicates(t *testing.T, repo contracts.BookmarksRepository) {
+
+	ctx := context.Background()
+	saved := primitive.NewObjectID()
+	bookmark := mustCreate(t, repo, newBookmark(repo, "user-1", saved))
+	collectionID := bookmark.Collections[0].ID.Hex()
+
+	fresh := primitive.NewObjectID()
+	opStatus, err := repo.AddPost(ctx, "user-1", collectionID, []string{saved.Hex(), fresh.Hex(), fresh.Hex()})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkPostSuccess)
+
+	//adding only already saved posts is not an error
+	opStatus, err = repo.AddPost(ctx, "user-1", collectionID, []string{fresh.Hex()})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkPostSuccess)
+
+	stored, _, _ := repo.FetchByUserId(ctx, "user-1", []string{})
+	assert.Equal(t, postIDsOf(stored.Posts), []primitive.ObjectID{saved, fresh})
+	assert.NotEqual(t, stored.Posts[1].AddedAt, nil)
+	assert.Equal(t, stored.Posts[1].CollectionID, bookmark.Collections[0].ID)
+
+	//the same post can live in several collections
+	other := models.Collection{ID: repo.GenerateModelID(), Name: "Later"}
+	_, err = repo.CreateCollection(ctx, "user-1", &other)
+	assert.Equal(t, err, nil)
+
+	_, err = repo.AddPost(ctx, "user-1", other.ID.Hex(), []string{saved.Hex()})
+	assert.Equal(t, err, nil)
+
+	stored, _, _ = repo.FetchByUserId(ctx, "user-1", []string{})
+	assert.Equal(t, postIDsOf(stored.Posts), []primitive.ObjectID{saved, fresh, saved})
+}
+
+func testAddPostRequiresCollection(t *testing.T, repo contracts.BookmarksRepository) {
+
+	ctx := context.Background()
+	post := primitive.NewObjectID().Hex()
+
+	opStatus, err := repo.AddPost(ctx, "nobody", primitive.NewObjectID().Hex(), []string{post})
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkNotExist)
+
+	mustCreate(t, repo, newBookmark(repo, "user-1"))
+
+	opStatus, err = repo.AddPost(ctx, "user-1", primitive.NewObjectID().Hex(), []string{post})
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkNotExist)
+
+	opStatus, err = repo.AddPost(ctx, "user-1", "not-an-id", []string{post})
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkCollectionNotExist)
+}
+
+func testRevokePost(t *testing.T, repo contracts.BookmarksRepository) {
+
+	ctx := context.Background()
+	first, second := primitive.NewObjectID(), primitive.NewObjectID()
+	bookmark := mustCreate(t, repo, newBookmark(repo, "user-1", first, second))
+
+	other := models.Collection{ID: repo.GenerateModelID(), Name: "Later"}
+	_, _ = repo.CreateCollection(ctx, "user-1", &other)
+	_, _ = repo.AddPost(ctx, "user-1", other.ID.Hex(), []string{first.Hex()})
+
+	//revoking from one collection keeps the post in the others
+	opStatus, err := repo.RevokePost(ctx, "user-1", other.ID.Hex(), []string{first.Hex()})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkDeletePostSuccess)
+
+	stored, _, _ := repo.FetchByUserId(ctx, "user-1", []string{})
+	assert.Equal(t, postIDsOf(stored.Posts), []primitive.ObjectID{first, second})
+
+	//an empty collection id revokes from everywhere
+	_, _ = repo.AddPost(ctx, "user-1", other.ID.Hex(), []string{first.Hex()})
+	_, err = repo.RevokePost(ctx, "user-1", "", []string{first.Hex()})
+	assert.Equal(t, err, nil)
+
+	stored, _, _ = repo.FetchByUserId(ctx, "user-1", []string{})
+	assert.Equal(t, postIDsOf(stored.Posts), []primitive.ObjectID{second})
+	assert.Equal(t, stored.Posts[0].CollectionID, bookmark.Collections[0].ID)
+
+	opStatus, err = repo.RevokePost(ctx, "nobody", "", []string{first.Hex()})
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkNotExist)
+}
+
+func testUpdatePost(t *testing.T, repo contracts.BookmarksRepository) {
+
+	ctx := context.Background()
+	post := primitive.NewObjectID()
+	bookmark := mustCreate(t, repo, newBookmark(repo, "user-1", post))
+
+	note := "left near the library"
+	opStatus, err := repo.UpdatePost(ctx, "user-1", "", post.Hex(), &note, []string{"wallet", "blue"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkPostUpdateSuccess)
+
+	//nil fields stay untouched
+	_, err = repo.UpdatePost(ctx, "user-1", bookmark.Collections[0].ID.Hex(), post.Hex(), nil, nil)
+	assert.Equal(t, err, nil)
+
+	stored, _, _ := repo.FetchByUserId(ctx, "user-1", []string{})
+	assert.Equal(t, stored.Posts[0].Note, note)
+	assert.Equal(t, stored.Posts[0].Tags, []string{"wallet", "blue"})
+
+	opStatus, err = repo.UpdatePost(ctx, "user-1", "", primitive.NewObjectID().Hex(), &note, nil)
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkPostNotExist)
+
+	opStatus, err = repo.UpdatePost(ctx, "user-1", primitive.NewObjectID().Hex(), post.Hex(), &note, nil)
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkPostNotExist)
+}
+
+func testCollections(t *testing.T, repo contracts.BookmarksRepository) {
+
+	ctx := context.Background()
+	kept, dropped := primitive.NewObjectID(), primitive.NewObjectID()
+	bookmark := mustCreate(t, repo, newBookmark(repo, "user-1", kept))
+	defaultID := bookmark.Collections[0].ID.Hex()
+
+	other := models.Collection{ID: repo.GenerateModelID(), Name: "Later"}
+	opStatus, err := repo.CreateCollection(ctx, "user-1", &other)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkCollectionCreateSuccess)
+
+	opStatus, err = repo.CreateCollection(ctx, "nobody", &other)
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkNotExist)
+
+	opStatus, err = repo.RenameCollection(ctx, "user-1", other.ID.Hex(), "Follow up")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkCollectionUpdateSuccess)
+
+	opStatus, err = repo.RenameCollection(ctx, "user-1", primitive.NewObjectID().Hex(), "Missing")
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkCollectionNotExist)
+
+	_, _ = repo.AddPost(ctx, "user-1", other.ID.Hex(), []string{dropped.Hex(), kept.Hex()})
+
+	stored, _, _ := repo.FetchByUserId(ctx, "user-1", []string{})
+	assert.Equal(t, len(stored.Collections), 2)
+	assert.Equal(t, stored.Collections[1].Name, "Follow up")
+
+	//the default collection is never removed
+	opStatus, err = repo.DeleteCollection(ctx, "user-1", defaultID)
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkCollectionNotExist)
+
+	//removing a collection removes its posts only
+	opStatus, err = repo.DeleteCollection(ctx, "user-1", other.ID.Hex())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkCollectionDeleteSuccess)
+
+	stored, _, _ = repo.FetchByUserId(ctx, "user-1", []string{})
+	assert.Equal(t, len(stored.Collections), 1)
+	assert.Equal(t, postIDsOf(stored.Posts), []primitive.ObjectID{kept})
+
+	//the posts of the collection are reported revoked from it
+	revoked := revokedEvents(t, repo)
+	assert.Equal(t, len(revoked), 1)
+	assert.Equal(t, revoked[0].UserID, "user-1")
+	assert.Equal(t, revoked[0].CollectionID, other.ID.Hex())
+	assert.Equal(t, sortedIDs(revoked[0].PostIDs), sortedIDs([]string{dropped.Hex(), kept.Hex()}))
+}
+
+func testSoftDelete(t *testing.T, repo contracts.BookmarksRepository) {
+
+	ctx := context.Background()
+	post := primitive.NewObjectID()
+	bookmark := mustCreate(t, repo, newBookmark(repo, "user-1", post))
+
+	opStatus, err := repo.Delete(ctx, bookmark.ID.Hex())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkDeleteSuccess)
+
+	//soft deleted bookmarks are hidden from every read
+	_, opStatus, _ = repo.FetchById(ctx, bookmark.ID.Hex(), []string{})
+	assert.Equal(t, opStatus, status.BookmarkNotExist)
+	_, opStatus, _ = repo.FetchByUserId(ctx, "user-1", []string{})
+	assert.Equal(t, opStatus, status.BookmarkNotExist)
+	all, total, _, _ := repo.Fetch(ctx, models.BookmarkFilter{}, []string{}, 0, nil)
+	assert.Equal(t, len(all), 0)
+	assert.Equal(t, total, int64(0))
+	counts, _, _ := repo.CountByPosts(ctx, []string{post.Hex()})
+	assert.Equal(t, counts[post.Hex()], int64(0))
+
+	opStatus, err = repo.AddPost(ctx, "user-1", bookmark.Collections[0].ID.Hex(), []string{post.Hex()})
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkNotExist)
+
+	//deleting twice reports the bookmark as gone
+	opStatus, err = repo.Delete(ctx, bookmark.ID.Hex())
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkNotExist)
+
+	trashed, _, err := repo.FetchTrashed(ctx, "user-1", 0, 0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(trashed), 1)
+	assert.Equal(t, trashed[0].ID, bookmark.ID)
+	assert.NotEqual(t, trashed[0].DeletedAt, nil)
+
+	one, opStatus, err := repo.FetchTrashedById(ctx, "user-1", bookmark.ID.Hex())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.OperationSuccess)
+	assert.Equal(t, one.ID, bookmark.ID)
+	assert.NotEqual(t, one.DeletedAt, nil)
+
+	//the trash of others and active bookmarks aren't found
+	_, opStatus, _ = repo.FetchTrashedById(ctx, "user-2", bookmark.ID.Hex())
+	assert.Equal(t, opStatus, status.BookmarkNotExist)
+
+	//the trashed bookmark no longer blocks a new one
+	active := mustCreate(t, repo, newBookmark(repo, "user-1"))
+	_, opStatus, _ = repo.FetchTrashedById(ctx, "user-1", active.ID.Hex())
+	assert.Equal(t, opStatus, status.BookmarkNotExist)
+}
+
+func testRestoreAndPurge(t *testing.T, repo contracts.BookmarksRepository) {
+
+	ctx := context.Background()
+	bookmark := mustCreate(t, repo, newBookmark(repo, "user-1"))
+	_, _ = repo.Delete(ctx, bookmark.ID.Hex())
+
+	opStatus, err := repo.Restore(ctx, bookmark.ID.Hex())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkRestoreSuccess)
+
+	_, opStatus, _ = repo.FetchByUserId(ctx, "user-1", []string{})
+	assert.Equal(t, opStatus, status.OperationSuccess)
+
+	opStatus, err = repo.Restore(ctx, bookmark.ID.Hex())
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkNotExist)
+
+	//restoring while the user owns another active bookmark conflicts
+	_, _ = repo.Delete(ctx, bookmark.ID.Hex())
+	replacement := mustCreate(t, repo, newBookmark(repo, "user-1"))
+	opStatus, err = repo.Restore(ctx, bookmark.ID.Hex())
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkDuplicationOccurs)
+
+	//purge only hard deletes bookmarks trashed before the cut off
+	purged, _, err := repo.Purge(ctx, time.Now().Add(-time.Hour))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, purged, int64(0))
+
+	purged, _, err = repo.Purge(ctx, time.Now().Add(time.Second))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, purged, int64(1))
+
+	trashed, _, _ := repo.FetchTrashed(ctx, "user-1", 0, 0)
+	assert.Equal(t, len(trashed), 0)
+
+	_, opStatus, _ = repo.FetchById(ctx, replacement.ID.Hex(), []string{})
+	assert.Equal(t, opStatus, status.OperationSuccess)
+}
+
+func testSavedPostsLookup(t *testing.T, repo contracts.BookmarksRepository) {
+
+	ctx := context.Background()
+	saved, unsaved := primitive.NewObjectID(), primitive.NewObjectID()
+	mustCreate(t, repo, newBookmark(repo, "user-1", saved, primitive.NewObjectID()))
+
+	posts, opStatus, err := repo.FetchSavedPosts(ctx, "user-1", []string{saved.Hex(), unsaved.Hex()})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.OperationSuccess)
+	assert.Equal(t, postIDsOf(posts), []primitive.ObjectID{saved})
+	assert.NotEqual(t, posts[0].AddedAt, nil)
+
+	_, opStatus, err = repo.FetchSavedPosts(ctx, "nobody", []string{saved.Hex()})
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkNotExist)
+}
+
+func testReverseIndex(t *testing.T, repo contracts.BookmarksRepository) {
+
+	ctx := context.Background()
+	popular, rare, unsaved := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+	first := mustCreate(t, repo, newBookmark(repo, "user-1", popular, rare))
+	mustCreate(t, repo, newBookmark(repo, "user-2", popular))
+	mustCreate(t, repo, newBookmark(repo, "user-3", popular))
+
+	//saving the post in a second collection doesn't count twice
+	other := models.Collection{ID: repo.GenerateModelID(), Name: "Later"}
+	_, _ = repo.CreateCollection(ctx, "user-1", &other)
+	_, _ = repo.AddPost(ctx, "user-1", other.ID.Hex(), []string{popular.Hex()})
+
+	counts, _, err := repo.CountByPosts(ctx, []string{popular.Hex(), rare.Hex(), unsaved.Hex()})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, counts[popular.Hex()], int64(3))
+	assert.Equal(t, counts[rare.Hex()], int64(1))
+	assert.Equal(t, counts[unsaved.Hex()], int64(0))
+
+	userIDs, total, _, err := repo.FetchUsersByPost(ctx, popular.Hex(), 2, 0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, total, int64(3))
+	assert.Equal(t, userIDs, []string{"user-1", "user-2"})
+
+	userIDs, _, _, _ = repo.FetchUsersByPost(ctx, popular.Hex(), 2, 2)
+	assert.Equal(t, userIDs, []string{"user-3"})
+
+	_, _ = repo.Delete(ctx, first.ID.Hex())
+	userIDs, total, _, _ = repo.FetchUsersByPost(ctx, popular.Hex(), 0, 0)
+	assert.Equal(t, total, int64(2))
+	assert.Equal(t, userIDs, []string{"user-2", "user-3"})
+}
+
+func testSnapshotsAndSearch(t *testing.T, repo contracts.BookmarksRepository) {
+
+	ctx := context.Background()
+	goPost, rustPost, unnamed := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	mustCreate(t, repo, newBookmark(repo, "user-1", goPost, rustPost, unnamed))
+	mustCreate(t, repo, newBookmark(repo, "user-2", goPost))
+
+	opStatus, err := repo.RefreshSnapshots(ctx, []models.Post{
+		{ID: goPost, Name: "Learning Go", ImageUrl: "go.png"},
+		{ID: rustPost, Name: "Rust basics"},
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkSnapshotSuccess)
+
+	//every copy of the post is refreshed
+	stored, _, _ := repo.FetchByUserId(ctx, "user-2", []string{})
+	assert.Equal(t, stored.Posts[0].Name, "Learning Go")
+	assert.Equal(t, stored.Posts[0].ImageUrl, "go.png")
+	assert.NotEqual(t, stored.Posts[0].SnapshotAt, nil)
+
+	note := "read it when there is time to go"
+	_, _ = repo.UpdatePost(ctx, "user-1", "", unnamed.Hex(), &note, nil)
+	_, _ = repo.UpdatePost(ctx, "user-1", "", rustPost.Hex(), nil, []string{"Systems"})
+
+	search := func(query string) []primitive.ObjectID {
+		t.Helper()
+		posts, opStatus, err := repo.SearchPosts(ctx, "user-1", query)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, opStatus, status.OperationSuccess)
+		return postIDsOf(posts)
+	}
+
+	assert.Equal(t, search("GO"), []primitive.ObjectID{goPost, unnamed})
+	assert.Equal(t, search("learning go"), []primitive.ObjectID{goPost})
+	assert.Equal(t, search("systems"), []primitive.ObjectID{rustPost})
+	//every word must match, and match whole
+	assert.Equal(t, search("go rust"), []primitive.ObjectID{})
+	assert.Equal(t, search("learn"), []primitive.ObjectID{})
+
+	//snapshots survive a whole bookmark update
+	stored, _, _ = repo.FetchByUserId(ctx, "user-1", []string{})
+	_, err = repo.Update(ctx, &stored, stored.ID.Hex())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, search("rust"), []primitive.ObjectID{rustPost})
+
+	_, opStatus, err = repo.SearchPosts(ctx, "nobody", "go")
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkNotExist)
+}
+
+func testPrunePosts(t *testing.T, repo contracts.BookmarksRepository) {
+
+	ctx := context.Background()
+	gone, kept := primitive.NewObjectID(), primitive.NewObjectID()
+	mustCreate(t, repo, newBookmark(repo, "user-1", gone, kept))
+	trashed := mustCreate(t, repo, newBookmark(repo, "user-2", gone))
+	_, _ = repo.Delete(ctx, trashed.ID.Hex())
+
+	opStatus, err := repo.PrunePosts(ctx, []string{gone.Hex()})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkDeletePostSuccess)
+
+	stored, _, _ := repo.FetchByUserId(ctx, "user-1", []string{})
+	assert.Equal(t, postIDsOf(stored.Posts), []primitive.ObjectID{kept})
+
+	//only the active bookmark reports it, the trashed one was reported deleted already
+	revoked := revokedEvents(t, repo)
+	assert.Equal(t, len(revoked), 1)
+	assert.Equal(t, revoked[0].UserID, "user-1")
+	assert.Equal(t, revoked[0].CollectionID, "")
+	assert.Equal(t, revoked[0].PostIDs, []string{gone.Hex()})
+
+	//a trashed bookmark comes back without the post
+	_, _ = repo.Restore(ctx, trashed.ID.Hex())
+	stored, _, _ = repo.FetchByUserId(ctx, "user-2", []string{})
+	assert.Equal(t, postIDsOf(stored.Posts), []primitive.ObjectID{})
+}
+
+func testOutbox(t *testing.T, repo contracts.BookmarksRepository) {
+
+	ctx := context.Background()
+	saved, fresh := primitive.NewObjectID(), primitive.NewObjectID()
+	bookmark := mustCreate(t, repo, newBookmark(repo, "user-1", saved))
+	collectionID := bookmark.Collections[0].ID.Hex()
+
+	//only the posts actually saved or revoked are reported, changes that do nothing aren't
+	_, _ = repo.AddPost(ctx, "user-1", collectionID, []string{saved.Hex(), fresh.Hex(), fresh.Hex()})
+	_, _ = repo.AddPost(ctx, "user-1", collectionID, []string{saved.Hex()})
+	_, _ = repo.RevokePost(ctx, "user-1", "", []string{saved.Hex(), primitive.NewObjectID().Hex()})
+	_, _ = repo.RevokePost(ctx, "user-1", "", []string{saved.Hex()})
+	_, _ = repo.Delete(ctx, bookmark.ID.Hex())
+	_, _ = repo.AddPost(ctx, "user-1", collectionID, []string{saved.Hex()})
+
+	outbox, opStatus, err := repo.FetchOutbox(ctx, 0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkOutboxSuccess)
+	subjects := make([]string, 0)
+	for _, event := range outbox {
+		subjects = append(subjects, event.Subject)
+	}
+	//the first event reports the post the bookmark was created with
+	assert.Equal(t, subjects, []string{events.BookmarkPostAddedSubject, events.BookmarkPostAddedSubject, events.BookmarkPostRevokedSubject, events.BookmarkDeletedSubject})
+
+	var added events.BookmarkPostAdded
+	assert.Equal(t, json.Unmarshal(outbox[1].Payload, &added), nil)
+	assert.Equal(t, added.EventID, outbox[1].ID.Hex())
+	assert.Equal(t, added.UserID, "user-1")
+	assert.Equal(t, added.CollectionID, collectionID)
+	assert.Equal(t, added.PostIDs, []string{fresh.Hex()})
+
+	var revoked events.BookmarkPostRevoked
+	assert.Equal(t, json.Unmarshal(outbox[2].Payload, &revoked), nil)
+	assert.Equal(t, revoked.CollectionID, "")
+	assert.Equal(t, revoked.PostIDs, []string{saved.Hex()})
+
+	var deleted events.BookmarkDeleted
+	assert.Equal(t, json.Unmarshal(outbox[3].Payload, &deleted), nil)
+	assert.Equal(t, deleted.BookmarkID, bookmark.ID.Hex())
+	assert.Equal(t, deleted.UserID, "user-1")
+
+	//published events leave the outbox, the rest keep their order
+	first, _, _ := repo.FetchOutbox(ctx, 1)
+	assert.Equal(t, len(first), 1)
+	assert.Equal(t, first[0].ID, outbox[0].ID)
+	opStatus, err = repo.RemoveOutboxEvents(ctx, []string{outbox[0].ID.Hex(), outbox[1].ID.Hex(), outbox[3].ID.Hex()})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkOutboxSuccess)
+	left, _, _ := repo.FetchOutbox(ctx, 0)
+	assert.Equal(t, len(left), 1)
+	assert.Equal(t, left[0].ID, outbox[2].ID)
+}
+
+func testFirstAddPostEmitsPostAdded(t *testing.T, repo contracts.BookmarksRepository) {
+
+	ctx := context.Background()
+	first, second := primitive.NewObjectID(), primitive.NewObjectID()
+
+	//a user's first save creates their bookmark along with the posts
+	bookmark := newBookmark(repo, "user-1", first, second, first)
+	mustCreate(t, repo, bookmark)
+
+	outbox, _, err := repo.FetchOutbox(ctx, 0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(outbox), 1)
+	assert.Equal(t, outbox[0].Subject, events.BookmarkPostAddedSubject)
+
+	var added events.BookmarkPostAdded
+	assert.Equal(t, json.Unmarshal(outbox[0].Payload, &added), nil)
+	assert.Equal(t, added.EventID, outbox[0].ID.Hex())
+	assert.Equal(t, added.UserID, "user-1")
+	assert.Equal(t, added.CollectionID, bookmark.Collections[0].ID.Hex())
+	assert.Equal(t, added.PostIDs, []string{first.Hex(), second.Hex()})
+
+	//a bookmark created without posts has nothing to report
+	mustCreate(t, repo, newBookmark(repo, "user-2"))
+	outbox, _, _ = repo.FetchOutbox(ctx, 0)
+	assert.Equal(t, len(outbox), 1)
+}
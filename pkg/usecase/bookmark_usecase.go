@@ -9,7 +9,9 @@ import (
 	"golek_bookmark_service/pkg/http/middleware"
 	"golek_bookmark_service/pkg/http/requests"
 	"golek_bookmark_service/pkg/models"
+	"golek_bookmark_service/pkg/policy"
 	"log"
+	"sort"
 	"strings"
 	"time"
 )
@@ -17,19 +19,74 @@ import (
 type BookmarkUsecase struct {
 	DBRepository          contracts.BookmarksRepository
 	GRPCPostServiceClient contracts.GRPCPostService
+	Policy                *policy.Policy
+	MissingPosts          MissingPosts
 }
 
-func NewBookmarkUsecase(DBRepository contracts.BookmarksRepository, GRPCPostServiceClient contracts.GRPCPostService) contracts.BookmarkUsecase {
-	return &BookmarkUsecase{DBRepository: DBRepository, GRPCPostServiceClient: GRPCPostServiceClient}
+// MissingPosts tells what reads do with saved posts the post service reports as deleted
+type MissingPosts string
+
+const (
+	// ShowMissingPosts list them flagged as deleted
+	ShowMissingPosts MissingPosts = "show"
+	// HideMissingPosts leave them out of the response, they stay saved
+	HideMissingPosts MissingPosts = "hide"
+	// PruneMissingPosts leave them out and detach them from every bookmark
+	PruneMissingPosts MissingPosts = "prune"
+)
+
+// ParseMissingPosts read the MISSING_POSTS setting, empty means show
+func ParseMissingPosts(value string) (MissingPosts, error) {
+	switch MissingPosts(value) {
+	case "":
+		return ShowMissingPosts, nil
+	case ShowMissingPosts, HideMissingPosts, PruneMissingPosts:
+		return MissingPosts(value), nil
+	}
+	return "", fmt.Errorf("unknown missing posts handling %q, expected show, hide or prune", value)
 }
 
-func (b BookmarkUsecase) Fetch(ctx context.Context, exclude []string, limit int64, skip int64) (bookmarks []models.Bookmark, opStatus status.OperationStatus, err error) {
+func NewBookmarkUsecase(DBRepository contracts.BookmarksRepository, GRPCPostServiceClient contracts.GRPCPostService, Policy *policy.Policy, MissingPosts MissingPosts) contracts.BookmarkUsecase {
+	return &BookmarkUsecase{DBRepository: DBRepository, GRPCPostServiceClient: GRPCPostServiceClient, Policy: Policy, MissingPosts: MissingPosts}
+}
+
+func (b BookmarkUsecase) Fetch(ctx context.Context, filter models.BookmarkFilter, exclude []string, page models.CursorPage) (bookmarks []models.Bookmark, total int64, next *models.Cursor, opStatus status.OperationStatus, err error) {
 
-	bookmarks, opStatus, err = b.DBRepository.Fetch(ctx, exclude, limit, skip)
+	//admins list every user's bookmarks, anybody else is scoped to their own
+	authenticated, opStatus, err := b.ProtectResource(ctx, contracts.Resource{
+		Permission: policy.PermissionRead,
+		Name:       "Fetch Service",
+	}, models.Bookmark{}, func(isOwner bool) (opStatus status.OperationStatus, err error) {
+		return status.OperationAuthorized, nil
+	})
 	if err != nil {
-		return nil, opStatus, err
+		return nil, 0, nil, opStatus, err
 	}
-	return bookmarks, opStatus, nil
+	if !b.Policy.Can(authenticated.Role, authenticated.Permissions, policy.PermissionAdmin) {
+		filter.UserID = authenticated.UserID
+	}
+
+	//the cursor is built from the last bookmark, the fields it is keyed on can't be excluded
+	exclude = withoutFields(exclude, "_id", filter.SortBy)
+
+	//one extra bookmark tells whether another page follows
+	limit := page.PerPage
+	if limit > 0 {
+		limit++
+	}
+
+	bookmarks, total, opStatus, err = b.DBRepository.Fetch(ctx, filter, exclude, limit, page.After)
+	if err != nil {
+		return nil, 0, nil, opStatus, err
+	}
+
+	if page.PerPage > 0 && int64(len(bookmarks)) > page.PerPage {
+		bookmarks = bookmarks[:page.PerPage]
+		cursor := filter.CursorOf(bookmarks[len(bookmarks)-1])
+		next = &cursor
+	}
+
+	return bookmarks, total, next, opStatus, nil
 }
 
 func (b BookmarkUsecase) FetchById(ctx context.Context, bookmarkID string, exclude []string) (bookmark models.Bookmark, opStatus status.OperationStatus, err error) {
@@ -41,87 +98,251 @@ func (b BookmarkUsecase) FetchById(ctx context.Context, bookmarkID string, exclu
 		return bookmark, opStatus, err
 	}
 
-	//Fetch Post Data From postService through GRPC
-	pIDs := make([]string, 0)
-	for _, c := range bookmark.Posts {
-		pIDs = append(pIDs, c.ID.Hex())
-	}
-
-	posts, err := b.GRPCPostServiceClient.Fetch(ctx, pIDs)
+	//Check user authorization & model owner
+	_, opStatus, err = b.ProtectResource(ctx, contracts.Resource{
+		Permission: policy.PermissionRead,
+		Name:       "Fetch By Id Service",
+	}, bookmark, func(isOwner bool) (opStatus status.OperationStatus, err error) {
+		if !isOwner {
+			return status.OperationForbidden, errors.New("you are not the owner")
+		}
+		return status.OperationAuthorized, nil
+	})
 	if err != nil {
-		log.Printf("Fetching Bookmark Failed >> %v", err.Error())
+		return models.Bookmark{}, opStatus, err
 	}
-	//log.Println("BOOKMARK USECASE: FETCH BY ID: gRPC postService Result >>", posts)
 
-	//Attach post data from postService to a Bookmark
-	if err == nil {
-		if len(posts) != 0 {
-			bookmark.Posts = posts
-		}
+	//Fetch Post Data From postService through GRPC, posts keep their snapshot when it fails
+	if bookmark.Posts, err = b.attachPostService(ctx, bookmark.Posts); err != nil {
+		log.Printf("Fetching Bookmark Failed >> %v", err.Error())
 	}
 
 	//log.Println(bookmark)
-	return bookmark, opStatus, nil
+	return bookmark, status.OperationSuccess, nil
 }
 
-func (b BookmarkUsecase) FetchByUserId(ctx context.Context, userID string, exclude []string) (bookmark models.Bookmark, opStatus status.OperationStatus, err error) {
+func (b BookmarkUsecase) FetchByUserId(ctx context.Context, userID string, exclude []string, filter models.PostFilter, page models.CursorPage) (bookmark models.Bookmark, next *models.Cursor, opStatus status.OperationStatus, err error) {
+
+	//Check user authorization & model owner
+	_, opStatus, err = b.ProtectResource(ctx, contracts.Resource{
+		Permission: policy.PermissionRead,
+		Name:       "Fetch By User Id Service",
+	}, models.Bookmark{UserID: userID}, func(isOwner bool) (opStatus status.OperationStatus, err error) {
+		if !isOwner {
+			return status.OperationForbidden, errors.New("you are not the owner")
+		}
+		return status.OperationAuthorized, nil
+	})
+	if err != nil {
+		return models.Bookmark{}, nil, opStatus, err
+	}
 
 	bookmark, opStatus, err = b.DBRepository.FetchByUserId(ctx, userID, exclude)
 	if err != nil {
 		log.Println("BOOKMARK USECASE: FetchByUserId ERROR >>", err)
-		return bookmark, opStatus, err
+		return bookmark, nil, opStatus, err
+	}
+
+	if bookmark.Posts == nil {
+		return bookmark, nil, opStatus, nil
+	}
+
+	//searching and sorting by name need the details of every post, otherwise only the page gets them.
+	//posts keep their snapshot when the post service fails, a page losing hidden posts comes out short
+	if filter.NeedsDetails() {
+		if bookmark.Posts, err = b.attachPostService(ctx, bookmark.Posts); err != nil {
+			log.Printf("Fetching Bookmark Failed >> %v", err.Error())
+		}
+	}
+
+	bookmark.Posts, next = pagePosts(bookmark.Posts, filter, page)
+
+	if !filter.NeedsDetails() {
+		if bookmark.Posts, err = b.attachPostService(ctx, bookmark.Posts); err != nil {
+			log.Printf("Fetching Bookmark Failed >> %v", err.Error())
+		}
+	}
+
+	return bookmark, next, opStatus, nil
+}
+
+func (b BookmarkUsecase) SearchPosts(ctx context.Context, userID string, query string, page models.CursorPage) (posts []models.Post, next *models.Cursor, opStatus status.OperationStatus, err error) {
+
+	//Check user authorization & model owner
+	_, opStatus, err = b.ProtectResource(ctx, contracts.Resource{
+		Permission: policy.PermissionRead,
+		Name:       "Search Posts Service",
+	}, models.Bookmark{UserID: userID}, func(isOwner bool) (opStatus status.OperationStatus, err error) {
+		if !isOwner {
+			return status.OperationForbidden, errors.New("you are not the owner")
+		}
+		return status.OperationAuthorized, nil
+	})
+	if err != nil {
+		return nil, nil, opStatus, err
+	}
+
+	//matching runs on the stored snapshots, only the page gets refreshed
+	posts, opStatus, err = b.DBRepository.SearchPosts(ctx, userID, query)
+	if err != nil {
+		log.Println("BOOKMARK USECASE: SearchPosts ERROR >>", err)
+		return nil, nil, opStatus, err
+	}
+
+	posts, next = pagePosts(posts, models.PostFilter{}, page)
+
+	if posts, err = b.attachPostService(ctx, posts); err != nil {
+		log.Printf("Searching Bookmark Failed >> %v", err.Error())
+	}
+
+	return posts, next, status.OperationSuccess, nil
+}
+
+// attachPostService fetch post data from postService through GRPC and attach it to the saved posts,
+// then write back the snapshots that went stale. on failure the saved posts keep their stored snapshot
+// and are flagged unavailable. it returns the saved posts left once MissingPosts dealt with deleted ones
+func (b BookmarkUsecase) attachPostService(ctx context.Context, saved []models.Post) ([]models.Post, error) {
+
+	if len(saved) == 0 {
+		return saved, nil
 	}
 
-	//Fetch Post Data From postService through GRPC
 	pIDs := make([]string, 0)
-	for _, c := range bookmark.Posts {
+	for _, c := range saved {
 		pIDs = append(pIDs, c.ID.Hex())
 	}
 
-	//Attach post data from postService to a Bookmark
 	posts, err := b.GRPCPostServiceClient.Fetch(ctx, pIDs)
 	if err != nil {
-		log.Printf("Fetching Bookmark Failed >> %v", err.Error())
+		for i := range saved {
+			saved[i].Availability = models.PostUnavailable
+		}
+		return saved, err
 	}
-	//Attach post data from postService to a Bookmark
-	if err == nil {
-		if len(posts) != 0 {
-			bookmark.Posts = posts
+
+	//a snapshot that can't be written is only stale, the read goes on
+	if stale := attachPostDetails(saved, posts, time.Now()); len(stale) > 0 {
+		if _, err := b.DBRepository.RefreshSnapshots(ctx, stale); err != nil {
+			log.Println("BOOKMARK USECASE: RefreshSnapshots ERROR >>", err)
 		}
 	}
 
-	return bookmark, opStatus, nil
+	return b.dropMissingPosts(ctx, saved), nil
+}
+
+// dropMissingPosts leave out the posts the post service reported deleted unless they are shown, pruning
+// detaches them for good. a prune that fails is tried again by the next read
+func (b BookmarkUsecase) dropMissingPosts(ctx context.Context, saved []models.Post) []models.Post {
+
+	if b.MissingPosts != HideMissingPosts && b.MissingPosts != PruneMissingPosts {
+		return saved
+	}
+
+	kept := make([]models.Post, 0, len(saved))
+	gone := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, post := range saved {
+		if post.Availability != models.PostDeleted {
+			kept = append(kept, post)
+		} else if !seen[post.ID.Hex()] {
+			seen[post.ID.Hex()] = true
+			gone = append(gone, post.ID.Hex())
+		}
+	}
+
+	if b.MissingPosts == PruneMissingPosts && len(gone) > 0 {
+		if _, err := b.DBRepository.PrunePosts(ctx, gone); err != nil {
+			log.Println("BOOKMARK USECASE: PrunePosts ERROR >>", err)
+		}
+	}
+
+	return kept
+}
+
+// pagePosts keep the posts matching 'filter' that follow page.After in the filter's order, up to page.PerPage of them
+func pagePosts(posts []models.Post, filter models.PostFilter, page models.CursorPage) (paged []models.Post, next *models.Cursor) {
+
+	paged = make([]models.Post, 0, len(posts))
+	for _, p := range posts {
+		if filter.Match(p) && (page.After == nil || filter.After(p, *page.After)) {
+			paged = append(paged, p)
+		}
+	}
+
+	sort.SliceStable(paged, func(i, j int) bool {
+		return filter.Less(paged[i], paged[j])
+	})
+
+	if page.PerPage > 0 && int64(len(paged)) > page.PerPage {
+		paged = paged[:page.PerPage]
+		cursor := filter.CursorOf(paged[len(paged)-1])
+		next = &cursor
+	}
+
+	return paged, next
+}
+
+// withoutFields drop 'fields' from an exclusion list
+func withoutFields(exclude []string, fields ...string) []string {
+	kept := make([]string, 0, len(exclude))
+	for _, field := range exclude {
+		keep := true
+		for _, f := range fields {
+			if field == f {
+				keep = false
+			}
+		}
+		if keep {
+			kept = append(kept, field)
+		}
+	}
+	return kept
 }
 
 func (b BookmarkUsecase) Create(ctx context.Context, request *requests.CreateBookmarkRequest) (bookmark models.Bookmark, opStatus status.OperationStatus, err error) {
 
 	//Check user authorization
-	authenticated, opStatus, err := ProtectResource(ctx, contracts.Resource{
-		Alias: "c",
-		Name:  "Create Service",
-	}, models.Bookmark{}, func(isOwner bool) (opStatus status.OperationStatus, err error) {
+	_, opStatus, err = b.ProtectResource(ctx, contracts.Resource{
+		Permission: policy.PermissionWrite,
+		Name:       "Create Service",
+	}, models.Bookmark{UserID: request.UserID}, func(isOwner bool) (opStatus status.OperationStatus, err error) {
+		if !isOwner {
+			return status.OperationForbidden, errors.New("user id doesn't match with authenticated token")
+		}
 		return status.OperationAuthorized, nil
 	})
 	if err != nil {
 		return models.Bookmark{}, opStatus, err
 	}
 
-	if authenticated.UserID != request.UserID {
-		return models.Bookmark{}, status.OperationForbidden, errors.New("user id doesn't match with authenticated token")
+	timeNow := time.Now()
+
+	//every bookmark starts with a default collection holding the initial posts
+	defaultCollection := models.Collection{
+		ID:        b.DBRepository.GenerateModelID(),
+		Name:      models.DefaultCollectionName,
+		IsDefault: true,
+		UpdatedAt: &timeNow,
+		CreatedAt: &timeNow,
 	}
 
 	posts := make([]models.Post, 0)
 	for _, post := range request.Posts {
-		posts = append(posts, models.Post{ID: b.DBRepository.GenerateObjectIDFromString(post.ID)})
+		posts = append(posts, models.Post{
+			ID:           b.DBRepository.GenerateObjectIDFromString(post.ID),
+			CollectionID: defaultCollection.ID,
+			AddedAt:      &timeNow,
+			Tags:         []string{},
+		})
 	}
 
-	timeNow := time.Now()
 	newBookmark := models.Bookmark{
-		ID:        b.DBRepository.GenerateModelID(),
-		UserID:    authenticated.UserID,
-		Posts:     posts,
-		UpdatedAt: &timeNow,
-		CreatedAt: &timeNow,
+		ID:          b.DBRepository.GenerateModelID(),
+		UserID:      request.UserID,
+		Collections: []models.Collection{defaultCollection},
+		Posts:       posts,
+		UpdatedAt:   &timeNow,
+		CreatedAt:   &timeNow,
 	}
 
 	bookmarkID, opStatus, err := b.DBRepository.Create(ctx, &newBookmark)
@@ -141,11 +362,15 @@ func (b BookmarkUsecase) AddPost(ctx context.Context, request *requests.AddPostB
 
 		//if bookmark not exists, create new
 		if opStatus == status.BookmarkNotExist {
-			_, opStatus, err = b.Create(ctx, (*requests.CreateBookmarkRequest)(request))
+			//a fresh bookmark only owns the default collection
+			if request.CollectionID != "" {
+				return status.BookmarkCollectionNotExist, errors.New("collection doesn't exist")
+			}
+			_, opStatus, err = b.Create(ctx, &requests.CreateBookmarkRequest{UserID: userID, Posts: request.Posts})
 			if err != nil {
-				if opStatus == status.OperationUnauthorized {
+				if opStatus == status.OperationUnauthorized || opStatus == status.OperationForbidden {
 					log.Println("BOOKMARK USECASE: AddPost >>", err.Error())
-					return status.OperationUnauthorized, err
+					return opStatus, err
 				}
 				log.Println("BOOKMARK USECASE: AddPost >>", err.Error())
 				return status.BookmarkPostFailed, err
@@ -159,9 +384,9 @@ func (b BookmarkUsecase) AddPost(ctx context.Context, request *requests.AddPostB
 	}
 
 	//Check user authorization & model owner
-	authenticated, opStatus, err := ProtectResource(ctx, contracts.Resource{
-		Alias: "u",
-		Name:  "Add Post Service",
+	_, opStatus, err = b.ProtectResource(ctx, contracts.Resource{
+		Permission: policy.PermissionWrite,
+		Name:       "Add Post Service",
 	}, bookmark, func(isOwner bool) (opStatus status.OperationStatus, err error) {
 		if !isOwner {
 			return status.OperationForbidden, errors.New("you are not the owner")
@@ -172,8 +397,13 @@ func (b BookmarkUsecase) AddPost(ctx context.Context, request *requests.AddPostB
 		return opStatus, err
 	}
 
-	if authenticated.UserID != request.UserID && authenticated.UserID != userID {
-		return status.OperationForbidden, errors.New("user id doesn't match with authenticated token")
+	//posts without an explicit collection are saved into the default one
+	collection, ok := bookmark.DefaultCollection()
+	if request.CollectionID != "" {
+		collection, ok = bookmark.FindCollection(request.CollectionID)
+	}
+	if !ok {
+		return status.BookmarkCollectionNotExist, errors.New("collection doesn't exist")
 	}
 
 	postID := make([]string, 0)
@@ -181,7 +411,7 @@ func (b BookmarkUsecase) AddPost(ctx context.Context, request *requests.AddPostB
 		postID = append(postID, post.ID)
 	}
 
-	opStatus, err = b.DBRepository.AddPost(ctx, userID, postID)
+	opStatus, err = b.DBRepository.AddPost(ctx, userID, collection.ID.Hex(), postID)
 	if err != nil {
 		log.Println("BOOKMARK USECASE: AddPost >>", err)
 		return opStatus, err
@@ -202,9 +432,9 @@ func (b BookmarkUsecase) RevokePost(ctx context.Context, request *requests.Delet
 	}
 
 	//Check user authorization & model owner
-	authenticated, opStatus, err := ProtectResource(ctx, contracts.Resource{
-		Alias: "u",
-		Name:  "Revoke Post Service",
+	_, opStatus, err = b.ProtectResource(ctx, contracts.Resource{
+		Permission: policy.PermissionWrite,
+		Name:       "Revoke Post Service",
 	}, bookmark, func(isOwner bool) (opStatus status.OperationStatus, err error) {
 		if !isOwner {
 			return status.OperationForbidden, errors.New("you are not the owner")
@@ -215,8 +445,10 @@ func (b BookmarkUsecase) RevokePost(ctx context.Context, request *requests.Delet
 		return opStatus, err
 	}
 
-	if authenticated.UserID != request.UserID && authenticated.UserID != userID {
-		return status.OperationForbidden, errors.New("user id doesn't match with authenticated token")
+	if request.CollectionID != "" {
+		if _, ok := bookmark.FindCollection(request.CollectionID); !ok {
+			return status.BookmarkCollectionNotExist, errors.New("collection doesn't exist")
+		}
 	}
 
 	postsID := make([]string, 0)
@@ -224,7 +456,7 @@ func (b BookmarkUsecase) RevokePost(ctx context.Context, request *requests.Delet
 		postsID = append(postsID, c.ID)
 	}
 
-	opStatus, err = b.DBRepository.RevokePost(ctx, userID, postsID)
+	opStatus, err = b.DBRepository.RevokePost(ctx, userID, request.CollectionID, postsID)
 	if err != nil {
 		log.Println("BOOKMARK USECASE REVOKE post:", err.Error())
 		return opStatus, err
@@ -233,8 +465,120 @@ func (b BookmarkUsecase) RevokePost(ctx context.Context, request *requests.Delet
 	return opStatus, nil
 }
 
+func (b BookmarkUsecase) UpdatePost(ctx context.Context, request *requests.UpdatePostRequest, userID string, postID string) (opStatus status.OperationStatus, err error) {
+
+	bookmark, opStatus, err := b.DBRepository.FetchByUserId(ctx, userID, []string{})
+	if err != nil {
+		if opStatus == status.BookmarkNotExist {
+			return status.BookmarkNotExist, err
+		}
+		return status.BookmarkPostUpdateFailed, err
+	}
+
+	//Check user authorization & model owner
+	_, opStatus, err = b.ProtectResource(ctx, contracts.Resource{
+		Permission: policy.PermissionWrite,
+		Name:       "Update Post Service",
+	}, bookmark, func(isOwner bool) (opStatus status.OperationStatus, err error) {
+		if !isOwner {
+			return status.OperationForbidden, errors.New("you are not the owner")
+		}
+		return status.OperationAuthorized, nil
+	})
+	if err != nil {
+		return opStatus, err
+	}
+
+	if !bookmark.HasPost(postID, request.CollectionID) {
+		return status.BookmarkPostNotExist, errors.New("post isn't saved in the bookmark")
+	}
+
+	var tags []string
+	if request.Tags != nil {
+		tags = normalizeTags(request.Tags)
+	}
+
+	opStatus, err = b.DBRepository.UpdatePost(ctx, userID, request.CollectionID, postID, request.Note, tags)
+	if err != nil {
+		log.Println("BOOKMARK USECASE: UpdatePost >>", err)
+		return opStatus, err
+	}
+
+	return opStatus, nil
+}
+
+func (b BookmarkUsecase) IsBookmarked(ctx context.Context, userID string, postIDs []string) (statuses map[string]models.BookmarkStatus, opStatus status.OperationStatus, err error) {
+
+	//Check user authorization & model owner
+	_, opStatus, err = b.ProtectResource(ctx, contracts.Resource{
+		Permission: policy.PermissionRead,
+		Name:       "Is Bookmarked Service",
+	}, models.Bookmark{UserID: userID}, func(isOwner bool) (opStatus status.OperationStatus, err error) {
+		if !isOwner {
+			return status.OperationForbidden, errors.New("you are not the owner")
+		}
+		return status.OperationAuthorized, nil
+	})
+	if err != nil {
+		return nil, opStatus, err
+	}
+
+	statuses = make(map[string]models.BookmarkStatus)
+	for _, id := range postIDs {
+		statuses[id] = models.BookmarkStatus{Bookmarked: false}
+	}
+
+	saved, opStatus, err := b.DBRepository.FetchSavedPosts(ctx, userID, postIDs)
+	if err != nil {
+		//a user without bookmark simply hasn't saved anything yet
+		if opStatus == status.BookmarkNotExist {
+			return statuses, status.OperationSuccess, nil
+		}
+		log.Println("BOOKMARK USECASE: IsBookmarked ERROR >>", err)
+		return nil, opStatus, err
+	}
+
+	//a post saved in several collections reports its earliest save
+	for _, post := range saved {
+		current, ok := statuses[post.ID.Hex()]
+		if !ok {
+			continue
+		}
+		if !current.Bookmarked || (post.AddedAt != nil && (current.SavedAt == nil || post.AddedAt.Before(*current.SavedAt))) {
+			current.SavedAt = post.AddedAt
+		}
+		current.Bookmarked = true
+		statuses[post.ID.Hex()] = current
+	}
+
+	return statuses, status.OperationSuccess, nil
+}
+
 func (b BookmarkUsecase) Delete(ctx context.Context, bookmarkID string) (opStatus status.OperationStatus, err error) {
 
+	bookmark, opStatus, err := b.DBRepository.FetchById(ctx, bookmarkID, []string{})
+	if err != nil {
+		if opStatus == status.BookmarkNotExist {
+			return status.BookmarkNotExist, err
+		}
+		log.Println("BOOKMARK USECASE: Delete >>", err)
+		return status.BookmarkDeleteFailed, err
+	}
+
+	//Check user authorization & model owner
+	_, opStatus, err = b.ProtectResource(ctx, contracts.Resource{
+		Permission: policy.PermissionWrite,
+		Name:       "Delete Service",
+	}, bookmark, func(isOwner bool) (opStatus status.OperationStatus, err error) {
+		if !isOwner {
+			return status.OperationForbidden, errors.New("you are not the owner")
+		}
+		return status.OperationAuthorized, nil
+	})
+	if err != nil {
+		return opStatus, err
+	}
+
 	opStatus, err = b.DBRepository.Delete(ctx, bookmarkID)
 	if err != nil {
 		return opStatus, err
@@ -242,19 +586,387 @@ func (b BookmarkUsecase) Delete(ctx context.Context, bookmarkID string) (opStatu
 	return opStatus, nil
 }
 
-// ProtectResource Test
-func ProtectResource(ctx context.Context, resource contracts.Resource, model models.Bookmark, callback func(isOwner bool) (opStatus status.OperationStatus, err error)) (*middleware.AuthenticatedRequest, status.OperationStatus, error) {
+func (b BookmarkUsecase) CountBookmarks(ctx context.Context, postIDs []string) (counts map[string]int64, opStatus status.OperationStatus, err error) {
+
+	//counts are anonymous, any reader may see them
+	_, opStatus, err = b.ProtectResource(ctx, contracts.Resource{
+		Permission: policy.PermissionRead,
+		Name:       "Count Bookmarks Service",
+	}, models.Bookmark{}, func(isOwner bool) (opStatus status.OperationStatus, err error) {
+		return status.OperationAuthorized, nil
+	})
+	if err != nil {
+		return nil, opStatus, err
+	}
+
+	stored, opStatus, err := b.DBRepository.CountByPosts(ctx, postIDs)
+	if err != nil {
+		log.Println("BOOKMARK USECASE: CountBookmarks ERROR >>", err)
+		return nil, opStatus, err
+	}
+
+	//posts nobody saved are reported with a zero count
+	counts = make(map[string]int64)
+	for _, id := range postIDs {
+		counts[id] = stored[id]
+	}
+
+	return counts, opStatus, nil
+}
+
+// FetchBookmarkingUsers list who saved 'postID', to the author of the post or an admin only
+func (b BookmarkUsecase) FetchBookmarkingUsers(ctx context.Context, postID string, limit int64, skip int64) (userIDs []string, total int64, opStatus status.OperationStatus, err error) {
+
+	authenticated, opStatus, err := b.ProtectResource(ctx, contracts.Resource{
+		Permission: policy.PermissionRead,
+		Name:       "Fetch Bookmarking Users Service",
+	}, models.Bookmark{}, func(isOwner bool) (opStatus status.OperationStatus, err error) {
+		return status.OperationAuthorized, nil
+	})
+	if err != nil {
+		return nil, 0, opStatus, err
+	}
+
+	if !b.Policy.Can(authenticated.Role, authenticated.Permissions, policy.PermissionAdmin) {
+		posts, err := b.GRPCPostServiceClient.Fetch(ctx, []string{postID})
+		if err != nil {
+			log.Println("BOOKMARK USECASE: FetchBookmarkingUsers post lookup ERROR >>", err)
+			return nil, 0, status.BookmarkFetchingFailed, err
+		}
+		if len(posts) == 0 || posts[0].Availability == models.PostDeleted {
+			return nil, 0, status.OperationForbidden, errors.New("only the post owner can list who bookmarked it")
+		}
+		//the author comes with the post since the post service sends user_id, older ones leave it empty
+		if posts[0].OwnerID == "" {
+			return nil, 0, status.BookmarkPostOwnerUnknown, errors.New("the post service doesn't tell who owns the post")
+		}
+		if posts[0].OwnerID != authenticated.UserID {
+			return nil, 0, status.OperationForbidden, errors.New("only the post owner can list who bookmarked it")
+		}
+	}
+
+	userIDs, total, opStatus, err = b.DBRepository.FetchUsersByPost(ctx, postID, limit, skip)
+	if err != nil {
+		log.Println("BOOKMARK USECASE: FetchBookmarkingUsers ERROR >>", err)
+		return nil, 0, opStatus, err
+	}
+
+	return userIDs, total, opStatus, nil
+}
+
+func (b BookmarkUsecase) FetchTrash(ctx context.Context, limit int64, skip int64) (bookmarks []models.Bookmark, opStatus status.OperationStatus, err error) {
+
+	//the trash only ever lists bookmarks of the authenticated user
+	authenticated, opStatus, err := b.ProtectResource(ctx, contracts.Resource{
+		Permission: policy.PermissionRead,
+		Name:       "Fetch Trash Service",
+	}, models.Bookmark{}, func(isOwner bool) (opStatus status.OperationStatus, err error) {
+		return status.OperationAuthorized, nil
+	})
+	if err != nil {
+		return nil, opStatus, err
+	}
+
+	bookmarks, opStatus, err = b.DBRepository.FetchTrashed(ctx, authenticated.UserID, limit, skip)
+	if err != nil {
+		log.Println("BOOKMARK USECASE: FetchTrash ERROR >>", err)
+		return nil, opStatus, err
+	}
+
+	return bookmarks, opStatus, nil
+}
+
+func (b BookmarkUsecase) Restore(ctx context.Context, bookmarkID string) (opStatus status.OperationStatus, err error) {
+
+	authenticated, ok := middleware.Authenticated(ctx)
+	if !ok {
+		return status.OperationUnauthorized, errors.New("request isn't authenticated")
+	}
+
+	//Look the bookmark up in the user's own trash
+	bookmark, opStatus, err := b.DBRepository.FetchTrashedById(ctx, authenticated.UserID, bookmarkID)
+	if err != nil {
+		if status.Is(opStatus, status.BookmarkNotExist) {
+			return opStatus, errors.New("bookmark isn't in the trash")
+		}
+		log.Println("BOOKMARK USECASE: Restore ERROR >>", err)
+		return status.BookmarkRestoreFailed, err
+	}
+
+	//Check user authorization & model owner
+	_, opStatus, err = b.ProtectResource(ctx, contracts.Resource{
+		Permission: policy.PermissionWrite,
+		Name:       "Restore Bookmark Service",
+	}, bookmark, func(isOwner bool) (opStatus status.OperationStatus, err error) {
+		if !isOwner {
+			return status.OperationForbidden, errors.New("you are not the owner")
+		}
+		return status.OperationAuthorized, nil
+	})
+	if err != nil {
+		return opStatus, err
+	}
+
+	opStatus, err = b.DBRepository.Restore(ctx, bookmarkID)
+	if err != nil {
+		log.Println("BOOKMARK USECASE: Restore ERROR >>", err)
+		return opStatus, err
+	}
+
+	return opStatus, nil
+}
+
+func (b BookmarkUsecase) FetchCollections(ctx context.Context, userID string) (collections []models.Collection, opStatus status.OperationStatus, err error) {
+
+	//Check user authorization & model owner
+	_, opStatus, err = b.ProtectResource(ctx, contracts.Resource{
+		Permission: policy.PermissionRead,
+		Name:       "Fetch Collections Service",
+	}, models.Bookmark{UserID: userID}, func(isOwner bool) (opStatus status.OperationStatus, err error) {
+		if !isOwner {
+			return status.OperationForbidden, errors.New("you are not the owner")
+		}
+		return status.OperationAuthorized, nil
+	})
+	if err != nil {
+		return nil, opStatus, err
+	}
+
+	bookmark, opStatus, err := b.DBRepository.FetchByUserId(ctx, userID, []string{})
+	if err != nil {
+		log.Println("BOOKMARK USECASE: FetchCollections ERROR >>", err)
+		return nil, opStatus, err
+	}
+
+	//Count saved posts per collection
+	counts := make(map[string]int)
+	for _, post := range bookmark.Posts {
+		counts[post.CollectionID.Hex()]++
+	}
+
+	collections = make([]models.Collection, 0)
+	for _, c := range bookmark.Collections {
+		c.PostCount = counts[c.ID.Hex()]
+		collections = append(collections, c)
+	}
+
+	return collections, status.OperationSuccess, nil
+}
+
+func (b BookmarkUsecase) CreateCollection(ctx context.Context, request *requests.CreateCollectionRequest, userID string) (collection models.Collection, opStatus status.OperationStatus, err error) {
+
+	bookmark, opStatus, err := b.DBRepository.FetchByUserId(ctx, userID, []string{})
+	if err != nil {
+		if opStatus != status.BookmarkNotExist {
+			log.Println("BOOKMARK USECASE: CreateCollection >>", err)
+			return models.Collection{}, status.BookmarkCollectionCreateFailed, err
+		}
+
+		//if bookmark not exists, create new
+		bookmark, opStatus, err = b.Create(ctx, &requests.CreateBookmarkRequest{UserID: userID, Posts: []requests.Post{}})
+		if err != nil {
+			log.Println("BOOKMARK USECASE: CreateCollection >>", err)
+			return models.Collection{}, opStatus, err
+		}
+	}
+
+	//Check user authorization & model owner
+	_, opStatus, err = b.ProtectResource(ctx, contracts.Resource{
+		Permission: policy.PermissionWrite,
+		Name:       "Create Collection Service",
+	}, bookmark, func(isOwner bool) (opStatus status.OperationStatus, err error) {
+		if !isOwner {
+			return status.OperationForbidden, errors.New("you are not the owner")
+		}
+		return status.OperationAuthorized, nil
+	})
+	if err != nil {
+		return models.Collection{}, opStatus, err
+	}
+
+	if hasCollectionNamed(bookmark, request.Name) {
+		return models.Collection{}, status.BookmarkCollectionDuplicated, errors.New("collection name already used")
+	}
+
+	timeNow := time.Now()
+	collection = models.Collection{
+		ID:        b.DBRepository.GenerateModelID(),
+		Name:      request.Name,
+		UpdatedAt: &timeNow,
+		CreatedAt: &timeNow,
+	}
+
+	opStatus, err = b.DBRepository.CreateCollection(ctx, userID, &collection)
+	if err != nil {
+		log.Println("BOOKMARK USECASE: CreateCollection >>", err)
+		return models.Collection{}, opStatus, err
+	}
+
+	return collection, opStatus, nil
+}
+
+func (b BookmarkUsecase) RenameCollection(ctx context.Context, request *requests.RenameCollectionRequest, userID string, collectionID string) (opStatus status.OperationStatus, err error) {
+
+	bookmark, opStatus, err := b.DBRepository.FetchByUserId(ctx, userID, []string{})
+	if err != nil {
+		if opStatus == status.BookmarkNotExist {
+			return status.BookmarkNotExist, err
+		}
+		return status.BookmarkCollectionUpdateFailed, err
+	}
+
+	//Check user authorization & model owner
+	_, opStatus, err = b.ProtectResource(ctx, contracts.Resource{
+		Permission: policy.PermissionWrite,
+		Name:       "Rename Collection Service",
+	}, bookmark, func(isOwner bool) (opStatus status.OperationStatus, err error) {
+		if !isOwner {
+			return status.OperationForbidden, errors.New("you are not the owner")
+		}
+		return status.OperationAuthorized, nil
+	})
+	if err != nil {
+		return opStatus, err
+	}
+
+	collection, ok := bookmark.FindCollection(collectionID)
+	if !ok {
+		return status.BookmarkCollectionNotExist, errors.New("collection doesn't exist")
+	}
+
+	if collection.Name != request.Name && hasCollectionNamed(bookmark, request.Name) {
+		return status.BookmarkCollectionDuplicated, errors.New("collection name already used")
+	}
+
+	opStatus, err = b.DBRepository.RenameCollection(ctx, userID, collectionID, request.Name)
+	if err != nil {
+		log.Println("BOOKMARK USECASE: RenameCollection >>", err)
+		return opStatus, err
+	}
+
+	return opStatus, nil
+}
+
+func (b BookmarkUsecase) DeleteCollection(ctx context.Context, userID string, collectionID string) (opStatus status.OperationStatus, err error) {
+
+	bookmark, opStatus, err := b.DBRepository.FetchByUserId(ctx, userID, []string{})
+	if err != nil {
+		if opStatus == status.BookmarkNotExist {
+			return status.BookmarkNotExist, err
+		}
+		return status.BookmarkCollectionDeleteFailed, err
+	}
+
+	//Check user authorization & model owner
+	_, opStatus, err = b.ProtectResource(ctx, contracts.Resource{
+		Permission: policy.PermissionWrite,
+		Name:       "Delete Collection Service",
+	}, bookmark, func(isOwner bool) (opStatus status.OperationStatus, err error) {
+		if !isOwner {
+			return status.OperationForbidden, errors.New("you are not the owner")
+		}
+		return status.OperationAuthorized, nil
+	})
+	if err != nil {
+		return opStatus, err
+	}
+
+	collection, ok := bookmark.FindCollection(collectionID)
+	if !ok {
+		return status.BookmarkCollectionNotExist, errors.New("collection doesn't exist")
+	}
+
+	if collection.IsDefault {
+		return status.BookmarkCollectionImmutable, errors.New("default collection can't be deleted")
+	}
+
+	opStatus, err = b.DBRepository.DeleteCollection(ctx, userID, collectionID)
+	if err != nil {
+		log.Println("BOOKMARK USECASE: DeleteCollection >>", err)
+		return opStatus, err
+	}
+
+	return opStatus, nil
+}
+
+// attachPostDetails fill saved posts with the data returned by postService, matched by post id, and flag
+// the ones it reported deleted. a post left out of the answer isn't known to be deleted, it's only flagged
+// unavailable and keeps its snapshot. it returns the details of posts whose snapshot was missing or differed, once per post
+func attachPostDetails(saved []models.Post, details []models.Post, snapshotAt time.Time) (stale []models.Post) {
+
+	detailsByID := make(map[string]models.Post)
+	for _, post := range details {
+		detailsByID[post.ID.Hex()] = post
+	}
+
+	stale = make([]models.Post, 0)
+	refreshed := make(map[string]bool)
+	for i, post := range saved {
+		detail, ok := detailsByID[post.ID.Hex()]
+		if !ok {
+			saved[i].Availability = models.PostUnavailable
+			continue
+		}
+		if detail.Availability == models.PostDeleted {
+			saved[i].Availability = models.PostDeleted
+			continue
+		}
+		saved[i].Availability = models.PostAvailable
+		if post.SnapshotAt == nil || post.Name != detail.Name || post.ImageUrl != detail.ImageUrl {
+			if !refreshed[post.ID.Hex()] {
+				refreshed[post.ID.Hex()] = true
+				stale = append(stale, detail)
+			}
+			saved[i].SnapshotAt = &snapshotAt
+		}
+		saved[i].Name = detail.Name
+		saved[i].ImageUrl = detail.ImageUrl
+	}
+
+	return stale
+}
+
+// normalizeTags trim tags and drop empty and duplicated ones, keeping the first occurrence order
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		seen[strings.ToLower(tag)] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+func hasCollectionNamed(bookmark models.Bookmark, name string) bool {
+	for _, c := range bookmark.Collections {
+		if strings.EqualFold(c.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProtectResource check the authenticated caller holds the resource permission, then run the callback
+// telling whether the caller owns 'model'. holders of bookmark:admin are treated as the owner of every model
+func (b BookmarkUsecase) ProtectResource(ctx context.Context, resource contracts.Resource, model models.Bookmark, callback func(isOwner bool) (opStatus status.OperationStatus, err error)) (*middleware.AuthenticatedRequest, status.OperationStatus, error) {
 
 	log.Println("Checking User Permissions")
 
 	//Check User Authorization
-	authenticated := ctx.Value("authenticatedRequest").(*middleware.AuthenticatedRequest)
-	if !strings.Contains(authenticated.Permissions, resource.Alias) {
-		return authenticated, status.OperationUnauthorized, errors.New(fmt.Sprintf("User ID %v doesn't have any permission to access %v resource", authenticated.UserID, resource.Name))
+	authenticated, ok := middleware.Authenticated(ctx)
+	if !ok {
+		return nil, status.OperationUnauthorized, errors.New("request isn't authenticated")
+	}
+	if !b.Policy.Can(authenticated.Role, authenticated.Permissions, resource.Permission) {
+		return authenticated, status.OperationUnauthorized, fmt.Errorf("user id %v lacks %v to access %v resource", authenticated.UserID, resource.Permission, resource.Name)
 	}
 
 	//Check Model's owner
-	isOwner := authenticated.UserID == model.UserID
+	isOwner := authenticated.UserID == model.UserID || b.Policy.Can(authenticated.Role, authenticated.Permissions, policy.PermissionAdmin)
 
 	//Run the callback
 	opStatus, err := callback(isOwner)
@@ -0,0 +1,659 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"golek_bookmark_service/pkg/contracts"
+	"golek_bookmark_service/pkg/contracts/status"
+	"golek_bookmark_service/pkg/http/middleware"
+	"golek_bookmark_service/pkg/http/requests"
+	"golek_bookmark_service/pkg/models"
+	"golek_bookmark_service/pkg/policy"
+	"golek_bookmark_service/pkg/repositories"
+	"testing"
+	"time"
+)
+
+// fakeBookmarkRepository stubs the repository methods under test, calling any other method panics
+type fakeBookmarkRepository struct {
+	contracts.BookmarksRepository
+	bookmarks map[string]models.Bookmark
+	deleted   []string
+	saved     []models.Post
+	listed    []models.Bookmark
+	filter    models.BookmarkFilter
+	limit     int64
+	refreshed []models.Post
+	pruned    []string
+	restored  []string
+}
+
+func (f *fakeBookmarkRepository) Fetch(ctx context.Context, filter models.BookmarkFilter, exclude []string, limit int64, after *models.Cursor) ([]models.Bookmark, int64, status.OperationStatus, error) {
+	f.filter, f.limit = filter, limit
+	listed := f.listed
+	if limit > 0 && limit < int64(len(listed)) {
+		listed = listed[:limit]
+	}
+	return listed, int64(len(f.listed)), status.OperationSuccess, nil
+}
+
+func (f *fakeBookmarkRepository) FetchByUserId(ctx context.Context, userID string, exclude []string) (models.Bookmark, status.OperationStatus, error) {
+	for _, bookmark := range f.bookmarks {
+		if bookmark.UserID == userID {
+			return bookmark, status.OperationSuccess, nil
+		}
+	}
+	return models.Bookmark{}, status.BookmarkNotExist, errors.New("not found")
+}
+
+func (f *fakeBookmarkRepository) FetchById(ctx context.Context, id string, exclude []string) (models.Bookmark, status.OperationStatus, error) {
+	bookmark, ok := f.bookmarks[id]
+	if !ok {
+		return models.Bookmark{}, status.BookmarkNotExist, errors.New("not found")
+	}
+	return bookmark, status.OperationSuccess, nil
+}
+
+func (f *fakeBookmarkRepository) Delete(ctx context.Context, bookmarkID string) (status.OperationStatus, error) {
+	f.deleted = append(f.deleted, bookmarkID)
+	return status.BookmarkDeleteSuccess, nil
+}
+
+func (f *fakeBookmarkRepository) FetchSavedPosts(ctx context.Context, userID string, postIDs []string) ([]models.Post, status.OperationStatus, error) {
+	if f.saved == nil {
+		return nil, status.BookmarkNotExist, errors.New("not found")
+	}
+	return f.saved, status.OperationSuccess, nil
+}
+
+func (f *fakeBookmarkRepository) RefreshSnapshots(ctx context.Context, snapshots []models.Post) (status.OperationStatus, error) {
+	f.refreshed = append(f.refreshed, snapshots...)
+	return status.BookmarkSnapshotSuccess, nil
+}
+
+func (f *fakeBookmarkRepository) PrunePosts(ctx context.Context, postIDs []string) (status.OperationStatus, error) {
+	f.pruned = append(f.pruned, postIDs...)
+	return status.BookmarkDeletePostSuccess, nil
+}
+
+func (f *fakeBookmarkRepository) SearchPosts(ctx context.Context, userID string, query string) ([]models.Post, status.OperationStatus, error) {
+	bookmark, opStatus, err := f.FetchByUserId(ctx, userID, []string{})
+	if err != nil {
+		return nil, opStatus, err
+	}
+	posts := make([]models.Post, 0)
+	for _, p := range bookmark.Posts {
+		if p.MatchTerms(models.SearchTerms(query)) {
+			posts = append(posts, p)
+		}
+	}
+	return posts, status.OperationSuccess, nil
+}
+
+// fakePostService answers every lookup with 'details', or fails with 'err'
+type fakePostService struct {
+	contracts.GRPCPostService
+	details []models.Post
+	err     error
+}
+
+func (f fakePostService) Fetch(ctx context.Context, postIDs []string) ([]models.Post, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.details, nil
+}
+
+func authenticatedContext(userID string, role string) context.Context {
+	return middleware.WithAuthenticated(context.Background(), &middleware.AuthenticatedRequest{
+		UserID: userID,
+		Role:   role,
+	})
+}
+
+func TestDeleteChecksOwnership(t *testing.T) {
+
+	bookmarkID := models.GenerateObjectID()
+	repo := &fakeBookmarkRepository{bookmarks: map[string]models.Bookmark{
+		bookmarkID.Hex(): {ID: bookmarkID, UserID: "owner"},
+	}}
+	uc := NewBookmarkUsecase(repo, nil, policy.Default(), ShowMissingPosts)
+
+	opStatus, err := uc.Delete(authenticatedContext("someone-else", "user"), bookmarkID.Hex())
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.OperationForbidden)
+
+	//a role the policy doesn't know is granted nothing
+	opStatus, err = uc.Delete(authenticatedContext("owner", "guest"), bookmarkID.Hex())
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.OperationUnauthorized)
+	assert.Equal(t, len(repo.deleted), 0)
+
+	opStatus, err = uc.Delete(context.Background(), bookmarkID.Hex())
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.OperationUnauthorized)
+
+	opStatus, err = uc.Delete(authenticatedContext("owner", "user"), bookmarkID.Hex())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkDeleteSuccess)
+	assert.Equal(t, repo.deleted, []string{bookmarkID.Hex()})
+
+	opStatus, _ = uc.Delete(authenticatedContext("owner", "user"), models.GenerateObjectID().Hex())
+	assert.Equal(t, opStatus, status.BookmarkNotExist)
+}
+
+func TestAdminOverridesOwnership(t *testing.T) {
+
+	bookmarkID := models.GenerateObjectID()
+	repo := &fakeBookmarkRepository{bookmarks: map[string]models.Bookmark{
+		bookmarkID.Hex(): {ID: bookmarkID, UserID: "owner"},
+	}}
+	uc := NewBookmarkUsecase(repo, fakePostService{}, policy.Default(), ShowMissingPosts)
+
+	bookmark, opStatus, err := uc.FetchById(authenticatedContext("someone-else", "user"), bookmarkID.Hex(), []string{})
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.OperationForbidden)
+	assert.Equal(t, bookmark.UserID, "")
+
+	bookmark, _, err = uc.FetchById(authenticatedContext("moderator", "admin"), bookmarkID.Hex(), []string{})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, bookmark.UserID, "owner")
+
+	opStatus, err = uc.Delete(authenticatedContext("moderator", "admin"), bookmarkID.Hex())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkDeleteSuccess)
+}
+
+func TestAdminOnlyRoleOverridesOwnership(t *testing.T) {
+
+	bookmarkID := models.GenerateObjectID()
+	repo := &fakeBookmarkRepository{bookmarks: map[string]models.Bookmark{
+		bookmarkID.Hex(): {ID: bookmarkID, UserID: "owner"},
+	}}
+	//the mapping shipped as the documented example, admins hold bookmark:admin alone
+	roles, err := policy.Parse("user=bookmark:read,bookmark:write;admin=bookmark:admin")
+	assert.Equal(t, err, nil)
+	uc := NewBookmarkUsecase(repo, fakePostService{}, roles, ShowMissingPosts)
+
+	bookmark, _, err := uc.FetchById(authenticatedContext("moderator", "admin"), bookmarkID.Hex(), []string{})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, bookmark.UserID, "owner")
+
+	opStatus, err := uc.Delete(authenticatedContext("moderator", "admin"), bookmarkID.Hex())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkDeleteSuccess)
+	assert.Equal(t, repo.deleted, []string{bookmarkID.Hex()})
+}
+
+func TestFetchScopesNonAdminsToTheirOwnBookmark(t *testing.T) {
+
+	repo := &fakeBookmarkRepository{}
+	uc := NewBookmarkUsecase(repo, nil, policy.Default(), ShowMissingPosts)
+	requested := models.BookmarkFilter{UserID: "someone-else", SortBy: models.SortByCreatedAt}
+
+	_, _, _, _, err := uc.Fetch(authenticatedContext("owner", "user"), requested, []string{}, models.CursorPage{PerPage: 10})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, repo.filter, models.BookmarkFilter{UserID: "owner", SortBy: models.SortByCreatedAt})
+
+	_, _, _, _, err = uc.Fetch(authenticatedContext("moderator", "admin"), requested, []string{}, models.CursorPage{PerPage: 10})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, repo.filter, requested)
+
+	_, _, _, opStatus, err := uc.Fetch(authenticatedContext("owner", "guest"), requested, []string{}, models.CursorPage{PerPage: 10})
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.OperationUnauthorized)
+}
+
+func TestFetchReturnsCursorOfLastBookmark(t *testing.T) {
+
+	first, second := models.Bookmark{ID: models.GenerateObjectID()}, models.Bookmark{ID: models.GenerateObjectID()}
+	repo := &fakeBookmarkRepository{listed: []models.Bookmark{first, second}}
+	uc := NewBookmarkUsecase(repo, nil, policy.Default(), ShowMissingPosts)
+	ctx := authenticatedContext("moderator", "admin")
+
+	bookmarks, total, next, _, err := uc.Fetch(ctx, models.BookmarkFilter{}, []string{}, models.CursorPage{PerPage: 1})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, repo.limit, int64(2))
+	assert.Equal(t, bookmarks, []models.Bookmark{first})
+	assert.Equal(t, total, int64(2))
+	assert.Equal(t, next.ID, first.ID.Hex())
+
+	//no cursor once the last bookmark is listed
+	_, _, next, _, err = uc.Fetch(ctx, models.BookmarkFilter{}, []string{}, models.CursorPage{PerPage: 2})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, next, (*models.Cursor)(nil))
+}
+
+func TestFetchByUserIdPagesSavedPosts(t *testing.T) {
+
+	day := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+	posts := make([]models.Post, 0)
+	for _, offset := range []int{2, 0, 1} {
+		addedAt := day.AddDate(0, 0, offset)
+		posts = append(posts, models.Post{ID: models.GenerateObjectID(), AddedAt: &addedAt})
+	}
+	oldest, middle, newest := posts[1], posts[2], posts[0]
+
+	bookmarkID := models.GenerateObjectID()
+	repo := &fakeBookmarkRepository{bookmarks: map[string]models.Bookmark{
+		bookmarkID.Hex(): {ID: bookmarkID, UserID: "owner", Posts: posts},
+	}}
+	uc := NewBookmarkUsecase(repo, fakePostService{}, policy.Default(), ShowMissingPosts)
+	ctx := authenticatedContext("owner", "user")
+
+	bookmark, next, _, err := uc.FetchByUserId(ctx, "owner", []string{}, models.PostFilter{}, models.CursorPage{PerPage: 2})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, postIDsOf(bookmark.Posts), postIDsOf([]models.Post{oldest, middle}))
+	assert.NotEqual(t, next, nil)
+
+	bookmark, next, _, err = uc.FetchByUserId(ctx, "owner", []string{}, models.PostFilter{}, models.CursorPage{After: next, PerPage: 2})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, postIDsOf(bookmark.Posts), postIDsOf([]models.Post{newest}))
+	assert.Equal(t, next, (*models.Cursor)(nil))
+}
+
+func TestTokenPermissionsExtendRole(t *testing.T) {
+
+	bookmarkID := models.GenerateObjectID()
+	repo := &fakeBookmarkRepository{bookmarks: map[string]models.Bookmark{
+		bookmarkID.Hex(): {ID: bookmarkID, UserID: "owner"},
+	}}
+	readOnly, err := policy.Parse("reader=bookmark:read")
+	assert.Equal(t, err, nil)
+	uc := NewBookmarkUsecase(repo, nil, readOnly, ShowMissingPosts)
+
+	ctx := authenticatedContext("owner", "reader")
+	opStatus, err := uc.Delete(ctx, bookmarkID.Hex())
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.OperationUnauthorized)
+
+	ctx = middleware.WithAuthenticated(context.Background(), &middleware.AuthenticatedRequest{
+		UserID:      "owner",
+		Role:        "reader",
+		Permissions: "bookmark:write",
+	})
+	opStatus, err = uc.Delete(ctx, bookmarkID.Hex())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkDeleteSuccess)
+}
+
+func TestIsBookmarkedReportsEarliestSave(t *testing.T) {
+
+	saved, notSaved := models.GenerateObjectID(), models.GenerateObjectID()
+	earlier := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	later := earlier.Add(time.Hour)
+
+	repo := &fakeBookmarkRepository{saved: []models.Post{
+		{ID: saved, CollectionID: models.GenerateObjectID(), AddedAt: &later},
+		{ID: saved, CollectionID: models.GenerateObjectID(), AddedAt: &earlier},
+	}}
+	uc := NewBookmarkUsecase(repo, nil, policy.Default(), ShowMissingPosts)
+
+	statuses, _, err := uc.IsBookmarked(authenticatedContext("owner", "user"), "owner", []string{saved.Hex(), notSaved.Hex()})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, statuses[saved.Hex()].Bookmarked, true)
+	assert.Equal(t, *statuses[saved.Hex()].SavedAt, earlier)
+	assert.Equal(t, statuses[notSaved.Hex()], models.BookmarkStatus{Bookmarked: false})
+
+	//users without any bookmark haven't saved anything
+	statuses, _, err = NewBookmarkUsecase(&fakeBookmarkRepository{}, nil, policy.Default(), ShowMissingPosts).IsBookmarked(authenticatedContext("nobody", "user"), "nobody", []string{saved.Hex()})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, statuses[saved.Hex()].Bookmarked, false)
+}
+
+func TestFetchByUserIdFiltersAndSortsSavedPosts(t *testing.T) {
+
+	day := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+	posts := make([]models.Post, 0)
+	details := make([]models.Post, 0)
+	for i, name := range []string{"Learning Go", "Advanced go", "Rust basics"} {
+		addedAt := day.AddDate(0, 0, i)
+		id := models.GenerateObjectID()
+		posts = append(posts, models.Post{ID: id, AddedAt: &addedAt, Tags: []string{[]string{"Backend", "", "backend"}[i]}})
+		details = append(details, models.Post{ID: id, Name: name})
+	}
+
+	bookmarkID := models.GenerateObjectID()
+	repo := &fakeBookmarkRepository{bookmarks: map[string]models.Bookmark{
+		bookmarkID.Hex(): {ID: bookmarkID, UserID: "owner", Posts: posts},
+	}}
+	uc := NewBookmarkUsecase(repo, fakePostService{details: details}, policy.Default(), ShowMissingPosts)
+	ctx := authenticatedContext("owner", "user")
+
+	names := func(filter models.PostFilter, page models.CursorPage) ([]string, *models.Cursor) {
+		t.Helper()
+		bookmark, next, _, err := uc.FetchByUserId(ctx, "owner", []string{}, filter, page)
+		assert.Equal(t, err, nil)
+		names := make([]string, 0)
+		for _, p := range bookmark.Posts {
+			names = append(names, p.Name)
+		}
+		return names, next
+	}
+
+	sorted, _ := names(models.PostFilter{SortBy: models.PostSortByAddedAt, SortDescending: true}, models.CursorPage{})
+	assert.Equal(t, sorted, []string{"Rust basics", "Advanced go", "Learning Go"})
+
+	filter := models.PostFilter{Search: "GO", SortBy: models.PostSortByName}
+	sorted, next := names(filter, models.CursorPage{PerPage: 1})
+	assert.Equal(t, sorted, []string{"Advanced go"})
+	assert.Equal(t, next.Sort, "name")
+	sorted, next = names(filter, models.CursorPage{After: next, PerPage: 1})
+	assert.Equal(t, sorted, []string{"Learning Go"})
+	assert.Equal(t, next, (*models.Cursor)(nil))
+
+	tagged, _ := names(models.PostFilter{Tag: "BACKEND"}, models.CursorPage{})
+	assert.Equal(t, tagged, []string{"Learning Go", "Rust basics"})
+
+	//without the post service names come from the stored snapshots
+	snapshots := make([]models.Post, 0)
+	for i, p := range posts {
+		p.Name = details[i].Name
+		snapshots = append(snapshots, p)
+	}
+	repo.bookmarks[bookmarkID.Hex()] = models.Bookmark{ID: bookmarkID, UserID: "owner", Posts: snapshots}
+	uc = NewBookmarkUsecase(repo, fakePostService{err: errors.New("unavailable")}, policy.Default(), ShowMissingPosts)
+	sorted, _ = names(filter, models.CursorPage{})
+	assert.Equal(t, sorted, []string{"Advanced go", "Learning Go"})
+}
+
+func TestReadsRefreshStaleSnapshots(t *testing.T) {
+
+	snapshotAt := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+	renamed, kept, missing := models.GenerateObjectID(), models.GenerateObjectID(), models.GenerateObjectID()
+	collection := models.GenerateObjectID()
+	posts := []models.Post{
+		{ID: renamed, Name: "Old name", SnapshotAt: &snapshotAt},
+		{ID: renamed, CollectionID: collection, Name: "Old name", SnapshotAt: &snapshotAt},
+		{ID: kept, Name: "Kept", SnapshotAt: &snapshotAt},
+		{ID: missing, Note: "saved before snapshots existed"},
+	}
+
+	bookmarkID := models.GenerateObjectID()
+	repo := &fakeBookmarkRepository{bookmarks: map[string]models.Bookmark{
+		bookmarkID.Hex(): {ID: bookmarkID, UserID: "owner", Posts: posts},
+	}}
+	uc := NewBookmarkUsecase(repo, fakePostService{details: []models.Post{
+		{ID: renamed, Name: "New name"},
+		{ID: kept, Name: "Kept"},
+		{ID: missing, Name: "Missing"},
+	}}, policy.Default(), ShowMissingPosts)
+
+	//a post saved in two collections is refreshed once, an unchanged snapshot isn't written again
+	bookmark, _, err := uc.FetchById(authenticatedContext("owner", "user"), bookmarkID.Hex(), []string{})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, bookmark.Posts[1].Name, "New name")
+	assert.Equal(t, repo.refreshed, []models.Post{{ID: renamed, Name: "New name"}, {ID: missing, Name: "Missing"}})
+}
+
+func TestReadsFlagPostsMissingUpstream(t *testing.T) {
+
+	snapshotAt := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+	first, gone, left, last := models.GenerateObjectID(), models.GenerateObjectID(), models.GenerateObjectID(), models.GenerateObjectID()
+	posts := []models.Post{
+		{ID: first, Name: "First", SnapshotAt: &snapshotAt},
+		{ID: gone, Name: "Gone", SnapshotAt: &snapshotAt},
+		{ID: left, Name: "Left out", SnapshotAt: &snapshotAt},
+		{ID: last, Name: "Last", SnapshotAt: &snapshotAt},
+	}
+	bookmarkID := models.GenerateObjectID()
+	bookmarks := map[string]models.Bookmark{bookmarkID.Hex(): {ID: bookmarkID, UserID: "owner", Posts: posts}}
+	//the service answers out of order, tombstones the deleted post and leaves another one out
+	service := fakePostService{details: []models.Post{{ID: last, Name: "Last"}, {ID: gone, Availability: models.PostDeleted}, {ID: first, Name: "First"}}}
+	ctx := authenticatedContext("owner", "user")
+
+	availability := func(posts []models.Post) []models.PostAvailability {
+		states := make([]models.PostAvailability, 0)
+		for _, p := range posts {
+			states = append(states, p.Availability)
+		}
+		return states
+	}
+
+	repo := &fakeBookmarkRepository{bookmarks: bookmarks}
+	bookmark, _, err := NewBookmarkUsecase(repo, service, policy.Default(), ShowMissingPosts).FetchById(ctx, bookmarkID.Hex(), []string{})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, postIDsOf(bookmark.Posts), []string{first.Hex(), gone.Hex(), left.Hex(), last.Hex()})
+	assert.Equal(t, availability(bookmark.Posts), []models.PostAvailability{models.PostAvailable, models.PostDeleted, models.PostUnavailable, models.PostAvailable})
+	assert.Equal(t, bookmark.Posts[1].Name, "Gone")
+	assert.Equal(t, bookmark.Posts[2].Name, "Left out")
+
+	//only the tombstoned post is hidden or pruned, the left out one is flagged and kept
+	bookmark, _, _ = NewBookmarkUsecase(repo, service, policy.Default(), HideMissingPosts).FetchById(ctx, bookmarkID.Hex(), []string{})
+	assert.Equal(t, postIDsOf(bookmark.Posts), []string{first.Hex(), left.Hex(), last.Hex()})
+	assert.Equal(t, len(repo.pruned), 0)
+
+	bookmark, _, _ = NewBookmarkUsecase(repo, service, policy.Default(), PruneMissingPosts).FetchById(ctx, bookmarkID.Hex(), []string{})
+	assert.Equal(t, postIDsOf(bookmark.Posts), []string{first.Hex(), left.Hex(), last.Hex()})
+	assert.Equal(t, repo.pruned, []string{gone.Hex()})
+
+	//a post service that can't answer says nothing about deletion
+	repo = &fakeBookmarkRepository{bookmarks: bookmarks}
+	unavailable := fakePostService{err: errors.New("unavailable")}
+	bookmark, _, _ = NewBookmarkUsecase(repo, unavailable, policy.Default(), PruneMissingPosts).FetchById(ctx, bookmarkID.Hex(), []string{})
+	assert.Equal(t, len(bookmark.Posts), 4)
+	assert.Equal(t, bookmark.Posts[1].Availability, models.PostUnavailable)
+	assert.Equal(t, len(repo.pruned), 0)
+
+	_, err = ParseMissingPosts("delete")
+	assert.NotEqual(t, err, nil)
+}
+
+func TestSearchPosts(t *testing.T) {
+
+	day := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+	posts := make([]models.Post, 0)
+	for i, name := range []string{"Learning Go", "Rust basics", "Go patterns"} {
+		addedAt := day.AddDate(0, 0, i)
+		posts = append(posts, models.Post{ID: models.GenerateObjectID(), AddedAt: &addedAt, Name: name, SnapshotAt: &day})
+	}
+
+	bookmarkID := models.GenerateObjectID()
+	repo := &fakeBookmarkRepository{bookmarks: map[string]models.Bookmark{
+		bookmarkID.Hex(): {ID: bookmarkID, UserID: "owner", Posts: posts},
+	}}
+	uc := NewBookmarkUsecase(repo, fakePostService{err: errors.New("unavailable")}, policy.Default(), ShowMissingPosts)
+
+	found, next, _, err := uc.SearchPosts(authenticatedContext("owner", "user"), "owner", "go", models.CursorPage{PerPage: 1})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, postIDsOf(found), []string{posts[0].ID.Hex()})
+	found, next, _, _ = uc.SearchPosts(authenticatedContext("owner", "user"), "owner", "go", models.CursorPage{After: next, PerPage: 1})
+	assert.Equal(t, postIDsOf(found), []string{posts[2].ID.Hex()})
+	assert.Equal(t, next, (*models.Cursor)(nil))
+
+	_, _, opStatus, err := uc.SearchPosts(authenticatedContext("someone-else", "user"), "owner", "go", models.CursorPage{})
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.OperationForbidden)
+}
+
+func postIDsOf(posts []models.Post) []string {
+	ids := make([]string, 0)
+	for _, p := range posts {
+		ids = append(ids, p.ID.Hex())
+	}
+	return ids
+}
+
+func (f *fakeBookmarkRepository) FetchUsersByPost(ctx context.Context, postID string, limit int64, skip int64) ([]string, int64, status.OperationStatus, error) {
+	return []string{"reader"}, 1, status.OperationSuccess, nil
+}
+
+func TestFetchBookmarkingUsersIsForThePostOwner(t *testing.T) {
+
+	postID := models.GenerateObjectID()
+	repo := &fakeBookmarkRepository{}
+	service := fakePostService{details: []models.Post{{ID: postID, Name: "Learning Go", OwnerID: "author"}}}
+	uc := NewBookmarkUsecase(repo, service, policy.Default(), ShowMissingPosts)
+
+	userIDs, total, _, err := uc.FetchBookmarkingUsers(authenticatedContext("author", "user"), postID.Hex(), 10, 0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, userIDs, []string{"reader"})
+	assert.Equal(t, total, int64(1))
+
+	_, _, opStatus, err := uc.FetchBookmarkingUsers(authenticatedContext("reader", "user"), postID.Hex(), 10, 0)
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.OperationForbidden)
+
+	//a post the service doesn't know has no owner to match
+	missing := NewBookmarkUsecase(repo, fakePostService{}, policy.Default(), ShowMissingPosts)
+	_, _, opStatus, _ = missing.FetchBookmarkingUsers(authenticatedContext("author", "user"), postID.Hex(), 10, 0)
+	assert.Equal(t, opStatus, status.OperationForbidden)
+	deleted := NewBookmarkUsecase(repo, fakePostService{details: []models.Post{{ID: postID, Availability: models.PostDeleted}}}, policy.Default(), ShowMissingPosts)
+	_, _, opStatus, _ = deleted.FetchBookmarkingUsers(authenticatedContext("author", "user"), postID.Hex(), 10, 0)
+	assert.Equal(t, opStatus, status.OperationForbidden)
+
+	//a post service not sending user_id yet can't tell the author apart, nobody is mistaken for them
+	ownerless := NewBookmarkUsecase(repo, fakePostService{details: []models.Post{{ID: postID, Name: "Learning Go"}}}, policy.Default(), ShowMissingPosts)
+	for _, userID := range []string{"author", "reader", ""} {
+		_, _, opStatus, err = ownerless.FetchBookmarkingUsers(authenticatedContext(userID, "user"), postID.Hex(), 10, 0)
+		assert.NotEqual(t, err, nil)
+		assert.Equal(t, opStatus, status.BookmarkPostOwnerUnknown)
+	}
+
+	//admins don't need the post service
+	admin := NewBookmarkUsecase(repo, fakePostService{err: errors.New("unavailable")}, policy.Default(), ShowMissingPosts)
+	userIDs, _, _, err = admin.FetchBookmarkingUsers(authenticatedContext("moderator", "admin"), postID.Hex(), 10, 0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, userIDs, []string{"reader"})
+
+	_, _, opStatus, _ = uc.FetchBookmarkingUsers(context.Background(), postID.Hex(), 10, 0)
+	assert.Equal(t, opStatus, status.OperationUnauthorized)
+}
+
+func (f *fakeBookmarkRepository) FetchTrashedById(ctx context.Context, userID string, bookmarkID string) (models.Bookmark, status.OperationStatus, error) {
+	bookmark, ok := f.bookmarks[bookmarkID]
+	if !ok || bookmark.UserID != userID || bookmark.DeletedAt == nil {
+		return models.Bookmark{}, status.BookmarkNotExist, errors.New("document not matched")
+	}
+	return bookmark, status.OperationSuccess, nil
+}
+
+func (f *fakeBookmarkRepository) Restore(ctx context.Context, bookmarkID string) (status.OperationStatus, error) {
+	f.restored = append(f.restored, bookmarkID)
+	return status.BookmarkRestoreSuccess, nil
+}
+
+func TestRestoreLooksUpTheOwnTrash(t *testing.T) {
+
+	deletedAt := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+	trashedID, activeID := models.GenerateObjectID(), models.GenerateObjectID()
+	repo := &fakeBookmarkRepository{bookmarks: map[string]models.Bookmark{
+		trashedID.Hex(): {ID: trashedID, UserID: "owner", DeletedAt: &deletedAt},
+		activeID.Hex():  {ID: activeID, UserID: "owner"},
+	}}
+	uc := NewBookmarkUsecase(repo, fakePostService{}, policy.Default(), ShowMissingPosts)
+
+	//someone else's trash, even for an admin, and bookmarks out of the trash aren't found
+	opStatus, err := uc.Restore(authenticatedContext("intruder", "admin"), trashedID.Hex())
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkNotExist)
+	opStatus, _ = uc.Restore(authenticatedContext("owner", "user"), activeID.Hex())
+	assert.Equal(t, opStatus, status.BookmarkNotExist)
+	assert.Equal(t, len(repo.restored), 0)
+
+	opStatus, err = uc.Restore(authenticatedContext("owner", "user"), trashedID.Hex())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkRestoreSuccess)
+	assert.Equal(t, repo.restored, []string{trashedID.Hex()})
+
+	_, err = uc.Restore(context.Background(), trashedID.Hex())
+	assert.NotEqual(t, err, nil)
+}
+
+func TestCollections(t *testing.T) {
+
+	repo := repositories.NewBookmarkMemoryRepository()
+	uc := NewBookmarkUsecase(repo, fakePostService{}, policy.Default(), ShowMissingPosts)
+	ctx := authenticatedContext("owner", "user")
+	first, second := models.GenerateObjectID().Hex(), models.GenerateObjectID().Hex()
+
+	//a user without a bookmark only owns the default collection
+	opStatus, err := uc.AddPost(ctx, &requests.AddPostBookmarkRequest{UserID: "owner", CollectionID: models.GenerateObjectID().Hex(), Posts: []requests.Post{{ID: first}}}, "owner")
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkCollectionNotExist)
+
+	//creating a collection creates the bookmark next to the default collection
+	later, _, err := uc.CreateCollection(ctx, &requests.CreateCollectionRequest{Name: "Follow up later"}, "owner")
+	assert.Equal(t, err, nil)
+	_, opStatus, _ = uc.CreateCollection(ctx, &requests.CreateCollectionRequest{Name: "follow UP later"}, "owner")
+	assert.Equal(t, opStatus, status.BookmarkCollectionDuplicated)
+
+	opStatus, err = uc.AddPost(ctx, &requests.AddPostBookmarkRequest{UserID: "owner", Posts: []requests.Post{{ID: first}}}, "owner")
+	assert.Equal(t, err, nil)
+	opStatus, err = uc.AddPost(ctx, &requests.AddPostBookmarkRequest{UserID: "owner", CollectionID: later.ID.Hex(), Posts: []requests.Post{{ID: first}, {ID: second}}}, "owner")
+	assert.Equal(t, err, nil)
+
+	collections, _, err := uc.FetchCollections(ctx, "owner")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(collections), 2)
+	assert.Equal(t, collections[0].Name, models.DefaultCollectionName)
+	assert.Equal(t, collections[0].IsDefault, true)
+	assert.Equal(t, collections[0].PostCount, 1)
+	assert.Equal(t, collections[1].ID, later.ID)
+	assert.Equal(t, collections[1].PostCount, 2)
+
+	//names stay unique across the user's collections
+	opStatus, _ = uc.RenameCollection(ctx, &requests.RenameCollectionRequest{Name: "saved"}, "owner", later.ID.Hex())
+	assert.Equal(t, opStatus, status.BookmarkCollectionDuplicated)
+	opStatus, err = uc.RenameCollection(ctx, &requests.RenameCollectionRequest{Name: "Lost items near campus"}, "owner", later.ID.Hex())
+	assert.Equal(t, err, nil)
+	opStatus, _ = uc.RenameCollection(ctx, &requests.RenameCollectionRequest{Name: "Gone"}, "owner", models.GenerateObjectID().Hex())
+	assert.Equal(t, opStatus, status.BookmarkCollectionNotExist)
+
+	//others can neither read nor change them
+	_, opStatus, _ = uc.FetchCollections(authenticatedContext("intruder", "user"), "owner")
+	assert.Equal(t, opStatus, status.OperationForbidden)
+	opStatus, _ = uc.DeleteCollection(authenticatedContext("intruder", "user"), "owner", later.ID.Hex())
+	assert.Equal(t, opStatus, status.OperationForbidden)
+
+	//the default collection stays, deleting another one drops its posts
+	opStatus, _ = uc.DeleteCollection(ctx, "owner", collections[0].ID.Hex())
+	assert.Equal(t, opStatus, status.BookmarkCollectionImmutable)
+	opStatus, err = uc.DeleteCollection(ctx, "owner", later.ID.Hex())
+	assert.Equal(t, err, nil)
+
+	collections, _, _ = uc.FetchCollections(ctx, "owner")
+	assert.Equal(t, len(collections), 1)
+	bookmark, _, _ := repo.FetchByUserId(context.Background(), "owner", []string{})
+	assert.Equal(t, postIDsOf(bookmark.Posts), []string{first})
+}
+
+func TestTrash(t *testing.T) {
+
+	repo := repositories.NewBookmarkMemoryRepository()
+	uc := NewBookmarkUsecase(repo, fakePostService{}, policy.Default(), ShowMissingPosts)
+	ctx := authenticatedContext("owner", "user")
+	postID := models.GenerateObjectID().Hex()
+
+	_, err := uc.AddPost(ctx, &requests.AddPostBookmarkRequest{UserID: "owner", Posts: []requests.Post{{ID: postID}}}, "owner")
+	assert.Equal(t, err, nil)
+	bookmark, _, _ := repo.FetchByUserId(context.Background(), "owner", []string{})
+
+	opStatus, _ := uc.Delete(authenticatedContext("intruder", "user"), bookmark.ID.Hex())
+	assert.Equal(t, opStatus, status.OperationForbidden)
+	opStatus, err = uc.Delete(ctx, bookmark.ID.Hex())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkDeleteSuccess)
+
+	//the trash lists the caller's own bookmarks only, posts included
+	trashed, _, err := uc.FetchTrash(ctx, 10, 0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(trashed), 1)
+	assert.Equal(t, trashed[0].ID, bookmark.ID)
+	assert.Equal(t, postIDsOf(trashed[0].Posts), []string{postID})
+	trashed, _, _ = uc.FetchTrash(authenticatedContext("intruder", "admin"), 10, 0)
+	assert.Equal(t, len(trashed), 0)
+
+	_, opStatus, _ = uc.FetchById(ctx, bookmark.ID.Hex(), []string{})
+	assert.Equal(t, opStatus, status.BookmarkNotExist)
+
+	opStatus, err = uc.Restore(ctx, bookmark.ID.Hex())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opStatus, status.BookmarkRestoreSuccess)
+	restored, _, err := uc.FetchById(ctx, bookmark.ID.Hex(), []string{})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, postIDsOf(restored.Posts), []string{postID})
+	trashed, _, _ = uc.FetchTrash(ctx, 10, 0)
+	assert.Equal(t, len(trashed), 0)
+}
@@ -0,0 +1,81 @@
+package workers
+
+import (
+	"context"
+	"golek_bookmark_service/pkg/contracts"
+	"log"
+	"time"
+)
+
+// OutboxRelay publish the events waiting in the outbox and remove them once the transport took them. an event is
+// removed only after it was published, a crash or failed removal in between publishes it again: delivery is at least once
+type OutboxRelay struct {
+	DBRepository contracts.BookmarksRepository
+	Transport    contracts.EventTransport
+	Interval     time.Duration
+	BatchSize    int64
+}
+
+func NewOutboxRelay(DBRepository contracts.BookmarksRepository, transport contracts.EventTransport, interval time.Duration, batchSize int64) *OutboxRelay {
+	return &OutboxRelay{DBRepository: DBRepository, Transport: transport, Interval: interval, BatchSize: batchSize}
+}
+
+// Run relay the outbox every interval until the context is cancelled
+func (r *OutboxRelay) Run(ctx context.Context) {
+
+	log.Printf("Outbox Relay: started, interval %v, batch size %d", r.Interval, r.BatchSize)
+
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = r.RelayOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Println("Outbox Relay: stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOnce publish batches of events until the outbox is empty or publishing fails, events keep their order:
+// the first one that can't be published holds back the ones written after it until the next run
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (published int, err error) {
+
+	for {
+		outbox, _, err := r.DBRepository.FetchOutbox(ctx, r.BatchSize)
+		if err != nil {
+			log.Println("Outbox Relay: fetch failed >>", err)
+			return published, err
+		}
+		if len(outbox) == 0 {
+			return published, nil
+		}
+
+		done := make([]string, 0, len(outbox))
+		var publishErr error
+		for _, event := range outbox {
+			if publishErr = r.Transport.Publish(ctx, event.Subject, event.Payload); publishErr != nil {
+				log.Printf("Outbox Relay: publishing %s failed >> %v", event.Subject, publishErr)
+				break
+			}
+			done = append(done, event.ID.Hex())
+		}
+
+		if len(done) > 0 {
+			if _, err := r.DBRepository.RemoveOutboxEvents(ctx, done); err != nil {
+				log.Println("Outbox Relay: remove failed >>", err)
+				return published, err
+			}
+			published += len(done)
+		}
+		if publishErr != nil {
+			return published, publishErr
+		}
+		if r.BatchSize <= 0 || int64(len(outbox)) < r.BatchSize {
+			return published, nil
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"golek_bookmark_service/pkg/events"
+	"golek_bookmark_service/pkg/models"
+	"golek_bookmark_service/pkg/repositories"
+	"testing"
+	"time"
+)
+
+func TestOutboxRelayPublishesInOrderAtLeastOnce(t *testing.T) {
+
+	ctx := context.Background()
+	repo := repositories.NewBookmarkMemoryRepository()
+	timeNow := time.Now()
+	collection := models.Collection{ID: repo.GenerateModelID(), Name: models.DefaultCollectionName, IsDefault: true}
+	_, _, err := repo.Create(ctx, &models.Bookmark{ID: repo.GenerateModelID(), UserID: "user-1", Collections: []models.Collection{collection}, CreatedAt: &timeNow})
+	assert.Equal(t, err, nil)
+
+	posts := []string{models.GenerateObjectID().Hex(), models.GenerateObjectID().Hex(), models.GenerateObjectID().Hex()}
+	for _, post := range posts {
+		_, _ = repo.AddPost(ctx, "user-1", collection.ID.Hex(), []string{post})
+	}
+	_, _ = repo.RevokePost(ctx, "user-1", "", posts[:1])
+
+	transport := events.NewInProcessTransport()
+	received := make([]string, 0)
+	failing := true
+	handler := func(ctx context.Context, data []byte) error {
+		received = append(received, string(data))
+		//the broker loses the third event once
+		if len(received) == 3 && failing {
+			failing = false
+			return errors.New("broker unavailable")
+		}
+		return nil
+	}
+	_, _ = transport.Subscribe(events.BookmarkPostAddedSubject, handler)
+	_, _ = transport.Subscribe(events.BookmarkPostRevokedSubject, handler)
+
+	relay := NewOutboxRelay(repo, transport, time.Minute, 2)
+	published, err := relay.RelayOnce(ctx)
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, published, 2)
+
+	//the failed event goes again, ahead of the ones written after it
+	published, err = relay.RelayOnce(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, published, 2)
+	assert.Equal(t, len(received), 5)
+	assert.Equal(t, received[2], received[3])
+
+	left, _, _ := repo.FetchOutbox(ctx, 0)
+	assert.Equal(t, len(left), 0)
+	published, _ = relay.RelayOnce(ctx)
+	assert.Equal(t, published, 0)
+}
+
+func TestOutboxRelayDrainsIntoDiscard(t *testing.T) {
+
+	ctx := context.Background()
+	repo := repositories.NewBookmarkMemoryRepository()
+	timeNow := time.Now()
+	collection := models.Collection{ID: repo.GenerateModelID(), Name: models.DefaultCollectionName, IsDefault: true}
+	_, _, err := repo.Create(ctx, &models.Bookmark{ID: repo.GenerateModelID(), UserID: "user-1", Collections: []models.Collection{collection}, CreatedAt: &timeNow})
+	assert.Equal(t, err, nil)
+	for i := 0; i < 3; i++ {
+		_, _ = repo.AddPost(ctx, "user-1", collection.ID.Hex(), []string{models.GenerateObjectID().Hex()})
+	}
+
+	//with events off nothing takes the events, they mustn't pile up
+	published, err := NewOutboxRelay(repo, events.DiscardTransport{}, time.Minute, 2).RelayOnce(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, published, 3)
+	left, _, _ := repo.FetchOutbox(ctx, 0)
+	assert.Equal(t, len(left), 0)
+}
@@ -0,0 +1,74 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golek_bookmark_service/pkg/contracts"
+	"golek_bookmark_service/pkg/events"
+	"io"
+	"log"
+)
+
+// PostDeletedConsumer detach posts removed from the post service from every bookmark as their PostDeleted events
+// come in. handling an event again prunes nothing more, redelivered and duplicated events are harmless
+type PostDeletedConsumer struct {
+	DBRepository contracts.BookmarksRepository
+	Transport    contracts.EventTransport
+	Subject      string
+	// Cache is optional, the deleted posts are dropped from it
+	Cache PostInvalidator
+}
+
+// PostInvalidator forget cached details of posts
+type PostInvalidator interface {
+	Invalidate(postIDs ...string)
+}
+
+func NewPostDeletedConsumer(DBRepository contracts.BookmarksRepository, transport contracts.EventTransport) *PostDeletedConsumer {
+	return &PostDeletedConsumer{DBRepository: DBRepository, Transport: transport, Subject: events.PostDeletedSubject}
+}
+
+// Run consume the events until the context is cancelled
+func (c *PostDeletedConsumer) Run(ctx context.Context) error {
+
+	subscription, err := c.Subscribe()
+	if err != nil {
+		return err
+	}
+
+	log.Println("Post Deleted Consumer: started on", c.Subject)
+	<-ctx.Done()
+	log.Println("Post Deleted Consumer: stopped")
+
+	return subscription.Close()
+}
+
+func (c *PostDeletedConsumer) Subscribe() (io.Closer, error) {
+	return c.Transport.Subscribe(c.Subject, c.Handle)
+}
+
+// Handle prune the post of one event. events that can't be read fail with events.ErrMalformed
+func (c *PostDeletedConsumer) Handle(ctx context.Context, data []byte) error {
+
+	var event events.PostDeleted
+	if err := json.Unmarshal(data, &event); err != nil {
+		return fmt.Errorf("%w: PostDeleted: %v", events.ErrMalformed, err)
+	}
+	if _, err := primitive.ObjectIDFromHex(event.PostID); err != nil {
+		return fmt.Errorf("%w: PostDeleted with invalid post id %q", events.ErrMalformed, event.PostID)
+	}
+
+	if c.Cache != nil {
+		c.Cache.Invalidate(event.PostID)
+	}
+
+	if _, err := c.DBRepository.PrunePosts(ctx, []string{event.PostID}); err != nil {
+		log.Println("Post Deleted Consumer: prune failed >>", err)
+		return err
+	}
+
+	log.Println("Post Deleted Consumer: pruned post", event.PostID)
+	return nil
+}
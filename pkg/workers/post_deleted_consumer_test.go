@@ -0,0 +1,63 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"golek_bookmark_service/pkg/events"
+	"golek_bookmark_service/pkg/models"
+	"golek_bookmark_service/pkg/repositories"
+	"testing"
+	"time"
+)
+
+// invalidatedPosts records the posts dropped from the cache
+type invalidatedPosts []string
+
+func (i *invalidatedPosts) Invalidate(postIDs ...string) {
+	*i = append(*i, postIDs...)
+}
+
+func TestPostDeletedConsumerPrunesEveryBookmark(t *testing.T) {
+
+	ctx := context.Background()
+	repo := repositories.NewBookmarkMemoryRepository()
+	gone, kept := models.GenerateObjectID(), models.GenerateObjectID()
+	timeNow := time.Now()
+	for _, userID := range []string{"user-1", "user-2"} {
+		_, _, err := repo.Create(ctx, &models.Bookmark{
+			ID:        repo.GenerateModelID(),
+			UserID:    userID,
+			Posts:     []models.Post{{ID: gone, AddedAt: &timeNow}, {ID: kept, AddedAt: &timeNow}},
+			CreatedAt: &timeNow,
+		})
+		assert.Equal(t, err, nil)
+	}
+
+	transport := events.NewInProcessTransport()
+	cache := &invalidatedPosts{}
+	consumer := NewPostDeletedConsumer(repo, transport)
+	consumer.Cache = cache
+	subscription, err := consumer.Subscribe()
+	assert.Equal(t, err, nil)
+
+	event := []byte(`{"post_id":"` + gone.Hex() + `","deleted_at":"2022-09-01T00:00:00Z"}`)
+	assert.Equal(t, transport.Publish(ctx, events.PostDeletedSubject, event), nil)
+	//a redelivered event changes nothing
+	assert.Equal(t, transport.Publish(ctx, events.PostDeletedSubject, event), nil)
+
+	for _, userID := range []string{"user-1", "user-2"} {
+		bookmark, _, _ := repo.FetchByUserId(ctx, userID, []string{})
+		assert.Equal(t, len(bookmark.Posts), 1)
+		assert.Equal(t, bookmark.Posts[0].ID, kept)
+	}
+	assert.Equal(t, *cache, invalidatedPosts{gone.Hex(), gone.Hex()})
+
+	//malformed events aren't worth redelivering
+	assert.Equal(t, errors.Is(transport.Publish(ctx, events.PostDeletedSubject, []byte(`{"post_id":"not-an-id"}`)), events.ErrMalformed), true)
+	assert.Equal(t, errors.Is(transport.Publish(ctx, events.PostDeletedSubject, []byte(`deleted`)), events.ErrMalformed), true)
+
+	//nothing is handled once unsubscribed
+	assert.Equal(t, subscription.Close(), nil)
+	assert.Equal(t, errors.Is(transport.Publish(ctx, events.PostDeletedSubject, []byte(`deleted`)), events.ErrNoSubscribers), true)
+}
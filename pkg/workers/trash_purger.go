@@ -0,0 +1,54 @@
+package workers
+
+import (
+	"context"
+	"golek_bookmark_service/pkg/contracts"
+	"log"
+	"time"
+)
+
+// TrashPurger periodically hard delete bookmarks that stayed in the trash longer than the retention window
+type TrashPurger struct {
+	DBRepository contracts.BookmarksRepository
+	Retention    time.Duration
+	Interval     time.Duration
+}
+
+func NewTrashPurger(DBRepository contracts.BookmarksRepository, retention time.Duration, interval time.Duration) *TrashPurger {
+	return &TrashPurger{DBRepository: DBRepository, Retention: retention, Interval: interval}
+}
+
+// Run purge the trash every interval until the context is cancelled
+func (p *TrashPurger) Run(ctx context.Context) {
+
+	log.Printf("Trash Purger: started, retention %v, interval %v", p.Retention, p.Interval)
+
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = p.PurgeOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Println("Trash Purger: stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *TrashPurger) PurgeOnce(ctx context.Context) (purged int64, err error) {
+
+	purged, _, err = p.DBRepository.Purge(ctx, time.Now().Add(-p.Retention))
+	if err != nil {
+		log.Println("Trash Purger: purge failed >>", err)
+		return 0, err
+	}
+
+	if purged > 0 {
+		log.Printf("Trash Purger: %d bookmarks purged", purged)
+	}
+
+	return purged, nil
+}
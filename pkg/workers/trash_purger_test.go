@@ -0,0 +1,94 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"golek_bookmark_service/pkg/contracts"
+	"golek_bookmark_service/pkg/contracts/status"
+	"golek_bookmark_service/pkg/models"
+	"golek_bookmark_service/pkg/repositories"
+	"testing"
+	"time"
+)
+
+func TestTrashPurgerPurgesPastRetention(t *testing.T) {
+
+	ctx := context.Background()
+	repo := repositories.NewBookmarkMemoryRepository()
+	bookmarks := make([]models.Bookmark, 0)
+	for _, userID := range []string{"user-1", "user-2"} {
+		timeNow := time.Now()
+		bookmark := models.Bookmark{ID: repo.GenerateModelID(), UserID: userID, CreatedAt: &timeNow,
+			Collections: []models.Collection{{ID: repo.GenerateModelID(), Name: models.DefaultCollectionName, IsDefault: true}}}
+		_, _, err := repo.Create(ctx, &bookmark)
+		assert.Equal(t, err, nil)
+		bookmarks = append(bookmarks, bookmark)
+	}
+	_, err := repo.Delete(ctx, bookmarks[0].ID.Hex())
+	assert.Equal(t, err, nil)
+
+	//still within the retention window
+	purged, err := NewTrashPurger(repo, time.Hour, time.Hour).PurgeOnce(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, purged, int64(0))
+	trashed, _, _ := repo.FetchTrashed(ctx, "user-1", 0, 0)
+	assert.Equal(t, len(trashed), 1)
+
+	time.Sleep(time.Millisecond)
+	purged, err = NewTrashPurger(repo, 0, time.Hour).PurgeOnce(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, purged, int64(1))
+	trashed, _, _ = repo.FetchTrashed(ctx, "user-1", 0, 0)
+	assert.Equal(t, len(trashed), 0)
+
+	//active bookmarks are never purged
+	_, opStatus, _ := repo.FetchByUserId(ctx, "user-2", []string{})
+	assert.Equal(t, opStatus, status.OperationSuccess)
+}
+
+// purgeRecorder reports every purge cut off, the first purge fails
+type purgeRecorder struct {
+	contracts.BookmarksRepository
+	cutoffs chan time.Time
+	calls   int
+}
+
+func (r *purgeRecorder) Purge(ctx context.Context, deletedBefore time.Time) (int64, status.OperationStatus, error) {
+	r.calls++
+	r.cutoffs <- deletedBefore
+	if r.calls == 1 {
+		return 0, status.BookmarkPurgeFailed, errors.New("database unavailable")
+	}
+	return 0, status.BookmarkPurgeSuccess, nil
+}
+
+func TestTrashPurgerRunsUntilCancelled(t *testing.T) {
+
+	repo := &purgeRecorder{cutoffs: make(chan time.Time, 100)}
+	purger := NewTrashPurger(repo, 24*time.Hour, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		purger.Run(ctx)
+		close(stopped)
+	}()
+
+	//a failed purge doesn't stop the next ones
+	for i := 0; i < 3; i++ {
+		select {
+		case cutoff := <-repo.cutoffs:
+			assert.Equal(t, cutoff.Before(time.Now().Add(-23*time.Hour)), true)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the purger stopped purging")
+		}
+	}
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the purger didn't stop once cancelled")
+	}
+}